@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/user"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// auditedUser ist der Teil eines Users, den die CLI ändert. TokenVersion hat
+// json:"-" am User und würde sonst im Diff fehlen.
+type auditedUser struct {
+	Role         string `json:"role"`
+	IsActive     bool   `json:"is_active"`
+	TokenVersion int    `json:"token_version"`
+}
+
+func auditUser(u *store.User) auditedUser {
+	return auditedUser{Role: u.Role, IsActive: u.IsActive, TokenVersion: u.TokenVersion}
+}
+
+// audit schreibt ein Audit Event über s, also in derselben Transaktion wie
+// die Änderung. before/after wie bei recordAudit in cmd/api.
+func (c *cli) audit(ctx context.Context, s store.Storage, action, targetType string, targetID int64, before, after any) error {
+	changes, err := store.AuditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	return s.Audit.Record(ctx, &store.AuditEvent{
+		ActorType:  store.AuditActorCLI,
+		ActorID:    c.actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+	})
+}
+
+// cliActor ist der Benutzer des Betriebssystems, der die CLI aufruft
+func cliActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,161 @@
+// Command admin erledigt Ops-Aufgaben direkt auf dem Store, ohne SQL von Hand:
+//
+//	go run ./cmd/admin users list
+//	go run ./cmd/admin users promote 42
+//	go run ./cmd/admin posts delete --user 42 --output json
+//	go run ./cmd/admin tokens revoke 42
+//
+// Die Datenbank kommt wie bei cmd/api aus STORAGE_DRIVER und DB_ADDR.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/timour/go-api/internal/env"
+	"github.com/timour/go-api/internal/store"
+)
+
+// command ist ein Subcommand wie "users list"
+type command struct {
+	usage string // Argumente und Flags für die Hilfe
+	help  string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"users list":       {"[--deleted] [--limit n] [--offset n]", "list users", usersList},
+	"users create":     {"--username name --email addr [--password pw] [--role user|admin]", "create a user", usersCreate},
+	"users promote":    {"<user-id>... [--role admin|user]", "change the role of users", usersPromote},
+	"users deactivate": {"<user-id>... [--activate]", "block login and tokens of users (or unblock them)", usersDeactivate},
+	"posts list":       {"[--user id] [--deleted] [--limit n] [--offset n]", "list posts, newest first", postsList},
+	"posts delete":     {"[<post-id>...] [--user id]", "soft-delete posts, all or nothing", postsDelete},
+	"tokens revoke":    {"<user-id>...", "invalidate all access tokens and sessions of users", tokensRevoke},
+}
+
+// cli hält, was alle Subcommands brauchen
+type cli struct {
+	storage store.Storage
+	stdout  io.Writer
+	stderr  io.Writer
+	output  string // table oder json, siehe flags
+	actor   string // ActorID im Audit Log
+}
+
+func main() {
+	open := func() (store.Storage, func(), error) {
+		driver := env.GetString("STORAGE_DRIVER", "postgres")
+		storage, conn, err := store.Open(driver, store.Config{
+			Addr:         env.GetString("DB_ADDR", store.DefaultAddr(driver)),
+			MaxOpenConns: 3,
+			MaxIdleConns: 3,
+			MaxIdleTime:  "15m",
+		})
+		if err != nil || conn == nil {
+			return storage, func() {}, err
+		}
+		return storage, func() { conn.Close() }, nil
+	}
+
+	if err := run(context.Background(), os.Args[1:], open, os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "admin:", err)
+		os.Exit(1)
+	}
+}
+
+// run sucht das Subcommand aus args und öffnet den Store erst danach,
+// damit z.B. die Hilfe auch ohne Datenbank funktioniert
+func run(ctx context.Context, args []string, open func() (store.Storage, func(), error), stdout, stderr io.Writer) error {
+	if len(args) < 2 {
+		printUsage(stderr)
+		return flag.ErrHelp
+	}
+
+	name := args[0] + " " + args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage(stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	storage, closeStore, err := open()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	c := &cli{storage: storage, stdout: stdout, stderr: stderr, actor: cliActor()}
+	return cmd.run(ctx, c, args[2:])
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: admin <command> [flags] (all commands accept --output table|json)")
+	for _, name := range names {
+		fmt.Fprintf(w, "\n  %s %s\n    \t%s\n", name, commands[name].usage, commands[name].help)
+	}
+}
+
+// flags erstellt das FlagSet eines Subcommands inkl. --output
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.output, "output", "table", "output format: table or json")
+	return fs
+}
+
+// parse erlaubt Flags auch nach den Argumenten (z.B. "promote 42 --role user"),
+// was das flag Package allein nicht kann
+func (c *cli) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if c.output != "table" && c.output != "json" {
+		return nil, fmt.Errorf("invalid --output %q, use table or json", c.output)
+	}
+
+	return positional, nil
+}
+
+// parseIDs liest IDs aus den Argumenten; mindestens eine ist Pflicht
+func parseIDs(args []string, what string) ([]int64, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing %s", what)
+	}
+
+	ids := make([]int64, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid %s %q", what, arg)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// runAdmin führt ein Subcommand gegen storage aus und liefert stdout
+func runAdmin(t *testing.T, storage store.Storage, args ...string) (string, error) {
+	t.Helper()
+
+	open := func() (store.Storage, func(), error) { return storage, func() {}, nil }
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, open, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestUsersCommands(t *testing.T) {
+	storage := store.NewMemoryStorage()
+
+	out, err := runAdmin(t, storage, "users", "create", "--username", "anna", "--email", "anna@example.com",
+		"--password", "password123", "--output", "json")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var created []store.User
+	if err := json.Unmarshal([]byte(out), &created); err != nil || len(created) != 1 {
+		t.Fatalf("Expected one user as JSON, got %q: %v", out, err)
+	}
+	id := created[0].ID
+
+	if _, err := runAdmin(t, storage, "users", "promote", "1", "--role", "root"); err == nil {
+		t.Error("Expected an invalid role to be rejected")
+	}
+	if _, err := runAdmin(t, storage, "users", "promote", "1", "999"); err == nil {
+		t.Error("Expected an unknown user id to fail")
+	}
+
+	out, err = runAdmin(t, storage, "users", "promote", "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out, "ROLE") || !strings.Contains(out, "admin") {
+		t.Errorf("Expected a table with the new role, got %q", out)
+	}
+
+	if _, err := runAdmin(t, storage, "users", "deactivate", "1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := runAdmin(t, storage, "tokens", "revoke", "1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	user, _ := storage.Users.GetByID(context.Background(), id)
+	if !user.IsAdmin() || user.IsActive || user.TokenVersion != 1 {
+		t.Errorf("Expected inactive admin with revoked tokens, got %+v", user)
+	}
+
+	// Jede Änderung steht mit Vorher/Nachher im Audit Log. Der Memory Store rollt
+	// nicht zurück, das abgebrochene "promote 1 999" steht also mit drin.
+	events, _ := storage.Audit.List(context.Background(), store.AuditFilter{ActorType: store.AuditActorCLI, Limit: 10})
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	if strings.Join(actions, ",") != "user.tokens_revoke,user.deactivate,user.promote,user.promote,user.create" {
+		t.Fatalf("Expected one audit event per change, got %v", actions)
+	}
+	// Nur das erste promote hat die Rolle geändert, das zweite hat keine Changes
+	if role := events[3].Changes["role"]; string(role.From) != `"user"` || string(role.To) != `"admin"` {
+		t.Errorf("Expected the role change in the audit event, got %+v", events[3].Changes)
+	}
+	if len(events[2].Changes) != 0 {
+		t.Errorf("Expected no changes for a repeated promote, got %+v", events[2].Changes)
+	}
+	if v := events[0].Changes["token_version"]; string(v.From) != "0" || string(v.To) != "1" {
+		t.Errorf("Expected the token version in the audit event, got %+v", events[0].Changes)
+	}
+}
+
+func TestPostsDelete(t *testing.T) {
+	ctx := context.Background()
+	storage := store.NewMemoryStorage()
+
+	spammer := &store.User{Username: "spammer", Email: "spam@example.com"}
+	other := &store.User{Username: "other", Email: "other@example.com"}
+	storage.Users.Create(ctx, spammer)
+	storage.Users.Create(ctx, other)
+
+	for _, userID := range []int64{spammer.ID, spammer.ID, other.ID} {
+		storage.Posts.Create(ctx, &store.Post{Title: "Post", Content: "Content", UserID: userID})
+	}
+
+	out, err := runAdmin(t, storage, "posts", "delete", "--user", "1", "--output", "json")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.TrimSpace(out) != `{
+  "deleted": 2
+}` {
+		t.Errorf("Expected 2 deleted posts, got %q", out)
+	}
+
+	events, _ := storage.Audit.List(ctx, store.AuditFilter{ActorType: store.AuditActorCLI, TargetType: "user", TargetID: spammer.ID})
+	if len(events) != 1 || events[0].Action != "post.delete_by_user" || string(events[0].Changes["deleted_posts"].To) != "2" {
+		t.Errorf("Expected an audit event for the bulk delete, got %+v", events)
+	}
+
+	if _, err := runAdmin(t, storage, "posts", "delete", "3"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	events, _ = storage.Audit.List(ctx, store.AuditFilter{TargetType: "post", TargetID: 3})
+	if len(events) != 1 || events[0].Action != "post.delete" || events[0].Changes["title"].From == nil {
+		t.Errorf("Expected an audit event with the deleted post, got %+v", events)
+	}
+
+	out, _ = runAdmin(t, storage, "posts", "list", "--output", "json", "--deleted")
+	var posts []store.Post
+	if err := json.Unmarshal([]byte(out), &posts); err != nil || len(posts) != 3 {
+		t.Errorf("Expected all three posts deleted, got %q", out)
+	}
+
+	if _, err := runAdmin(t, storage, "posts", "delete"); err == nil {
+		t.Error("Expected delete without ids or --user to fail")
+	}
+	if _, err := runAdmin(t, storage, "posts", "list", "--output", "yaml"); err == nil {
+		t.Error("Expected an unknown output format to fail")
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	if _, err := runAdmin(t, store.NewMemoryStorage(), "users", "drop"); err == nil {
+		t.Error("Expected an unknown command to fail")
+	}
+}
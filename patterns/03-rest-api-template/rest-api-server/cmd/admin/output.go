@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// print gibt v als JSON aus oder ruft table mit einem tabwriter auf
+func (c *cli) print(v any, table func(w *tabwriter.Writer)) error {
+	if c.output == "json" {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+func (c *cli) printUsers(users []store.User) error {
+	return c.print(users, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tROLE\tACTIVE\tCREATED\tDELETED")
+		for _, u := range users {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\t%s\n",
+				u.ID, u.Username, u.Email, u.Role, u.IsActive, u.Created, orDash(u.DeletedAt))
+		}
+	})
+}
+
+func (c *cli) printPosts(posts []store.Post) error {
+	return c.print(posts, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "ID\tUSER\tTITLE\tTAGS\tCREATED\tDELETED")
+		for _, p := range posts {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\n",
+				p.ID, p.UserID, truncate(p.Title, 40), strings.Join(p.Tags, ","), p.CreatedAt, orDash(p.DeletedAt))
+		}
+	})
+}
+
+func orDash(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+// truncate kürzt lange Titel, damit die Tabelle lesbar bleibt
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
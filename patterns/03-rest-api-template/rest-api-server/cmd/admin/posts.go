@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func postsList(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("posts list")
+	userID := fs.Int64("user", 0, "only posts of this user")
+	deleted := fs.Bool("deleted", false, "list soft-deleted posts instead")
+	limit := fs.Int("limit", 50, "maximum number of posts")
+	offset := fs.Int("offset", 0, "number of posts to skip")
+	if _, err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	var (
+		posts []store.Post
+		err   error
+	)
+	switch {
+	case *deleted && *userID != 0:
+		return fmt.Errorf("--deleted cannot be combined with --user")
+	case *deleted:
+		posts, err = c.storage.Posts.ListDeleted(ctx, *limit, *offset)
+	case *userID != 0:
+		posts, err = c.storage.Posts.ListByUser(ctx, *userID, *limit, *offset)
+	default:
+		posts, err = c.storage.Posts.List(ctx, *limit, *offset)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.printPosts(posts)
+}
+
+// postsDelete löscht einzelne Posts und/oder alle Posts eines Users (Spam).
+// Es ist ein Soft Delete: die API kann die Posts wiederherstellen, bis der Purge läuft.
+func postsDelete(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("posts delete")
+	userID := fs.Int64("user", 0, "delete all posts of this user")
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	if len(positional) > 0 || *userID == 0 {
+		if ids, err = parseIDs(positional, "post id"); err != nil {
+			return err
+		}
+	}
+
+	var deleted int64
+	err = c.storage.WithTx(ctx, func(s store.Storage) error {
+		for _, id := range ids {
+			// Auch ausgeblendete Posts (Moderation) lassen sich löschen
+			post, err := s.Posts.GetByIDIncludingHidden(ctx, id)
+			if err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					return fmt.Errorf("post %d not found", id)
+				}
+				return err
+			}
+			if err := s.Posts.Delete(ctx, id); err != nil {
+				return err
+			}
+			if err := c.audit(ctx, s, "post.delete", "post", id, post, nil); err != nil {
+				return err
+			}
+			deleted++
+		}
+
+		if *userID != 0 {
+			n, err := s.Posts.DeleteByUser(ctx, *userID)
+			if err != nil {
+				return err
+			}
+			// DeleteByUser liefert keine IDs, das Event hält die Anzahl am User fest
+			err = c.audit(ctx, s, "post.delete_by_user", "user", *userID, nil, map[string]int64{"deleted_posts": n})
+			if err != nil {
+				return err
+			}
+			deleted += n
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result := map[string]int64{"deleted": deleted}
+	return c.print(result, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "deleted %d posts\n", deleted)
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func usersList(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("users list")
+	deleted := fs.Bool("deleted", false, "list soft-deleted users instead")
+	limit := fs.Int("limit", 50, "maximum number of users")
+	offset := fs.Int("offset", 0, "number of users to skip")
+	if _, err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	list := c.storage.Users.List
+	if *deleted {
+		list = c.storage.Users.ListDeleted
+	}
+
+	users, err := list(ctx, *limit, *offset)
+	if err != nil {
+		return err
+	}
+
+	return c.printUsers(users)
+}
+
+func usersCreate(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("users create")
+	username := fs.String("username", "", "username (required)")
+	email := fs.String("email", "", "email address (required)")
+	password := fs.String("password", "", "password, at least 8 characters (generated if empty)")
+	role := fs.String("role", store.RoleUser, "role: user or admin")
+	if _, err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*username) == "" || !strings.Contains(*email, "@") {
+		return fmt.Errorf("--username and a valid --email are required")
+	}
+	if !store.IsValidRole(*role) {
+		return fmt.Errorf("invalid --role %q", *role)
+	}
+
+	if *password == "" {
+		// Generiertes Passwort nur auf stderr, damit --output json sauber bleibt
+		*password = generatePassword()
+		fmt.Fprintf(c.stderr, "generated password: %s\n", *password)
+	}
+	if len(*password) < 8 {
+		return fmt.Errorf("--password must be at least 8 characters")
+	}
+
+	user := &store.User{Username: *username, Email: *email, Role: *role}
+	if err := user.SetPassword(*password); err != nil {
+		return err
+	}
+
+	err := c.storage.WithTx(ctx, func(s store.Storage) error {
+		if err := s.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		return c.audit(ctx, s, "user.create", "user", user.ID, nil, user)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return fmt.Errorf("username or email is already taken")
+		}
+		return err
+	}
+
+	return c.printUsers([]store.User{*user})
+}
+
+func usersPromote(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("users promote")
+	role := fs.String("role", store.RoleAdmin, "new role: admin or user")
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !store.IsValidRole(*role) {
+		return fmt.Errorf("invalid --role %q", *role)
+	}
+
+	return c.updateUsers(ctx, positional, "user.promote", func(s store.Storage, id int64) error {
+		return s.Users.SetRole(ctx, id, *role)
+	})
+}
+
+func usersDeactivate(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("users deactivate")
+	activate := fs.Bool("activate", false, "reactivate the users instead")
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "user.deactivate"
+	if *activate {
+		action = "user.activate"
+	}
+
+	return c.updateUsers(ctx, positional, action, func(s store.Storage, id int64) error {
+		return s.Users.SetActive(ctx, id, *activate)
+	})
+}
+
+func tokensRevoke(ctx context.Context, c *cli, args []string) error {
+	fs := c.flags("tokens revoke")
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	return c.updateUsers(ctx, positional, "user.tokens_revoke", func(s store.Storage, id int64) error {
+		if err := s.Users.RevokeTokens(ctx, id); err != nil {
+			return err
+		}
+		_, err := s.Sessions.RevokeAll(ctx, id)
+		return err
+	})
+}
+
+// updateUsers wendet fn in einer Transaktion auf alle User-IDs an, schreibt
+// je User ein Audit Event action und gibt die geänderten User aus. Eine
+// unbekannte ID bricht alles ab.
+func (c *cli) updateUsers(ctx context.Context, args []string, action string, fn func(store.Storage, int64) error) error {
+	ids, err := parseIDs(args, "user id")
+	if err != nil {
+		return err
+	}
+
+	users := make([]store.User, 0, len(ids))
+	err = c.storage.WithTx(ctx, func(s store.Storage) error {
+		for _, id := range ids {
+			before, err := s.Users.GetByID(ctx, id)
+			if err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					return fmt.Errorf("user %d not found", id)
+				}
+				return err
+			}
+
+			if err := fn(s, id); err != nil {
+				return err
+			}
+
+			user, err := s.Users.GetByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := c.audit(ctx, s, action, "user", id, auditUser(before), auditUser(user)); err != nil {
+				return err
+			}
+			users = append(users, *user)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.printUsers(users)
+}
+
+func generatePassword() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// listDeletedPostsHandler zeigt soft-deleted Posts (GET /v1/admin/posts/deleted)
+func (app *application) listDeletedPostsHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	posts, err := app.store.Posts.ListDeleted(r.Context(), p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// restorePostHandler stellt einen soft-deleted Post wieder her (PUT /v1/admin/posts/{postID}/restore)
+func (app *application) restorePostHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var post *store.Post
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Posts.Restore(r.Context(), id); err != nil {
+			return err
+		}
+		if post, err = s.Posts.GetByID(r.Context(), id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "post.restore", "post", id, nil, post)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listDeletedUsersHandler zeigt soft-deleted User (GET /v1/admin/users/deleted)
+func (app *application) listDeletedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	users, err := app.store.Users.ListDeleted(r.Context(), p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, users); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// restoreUserHandler stellt einen soft-deleted User wieder her (PUT /v1/admin/users/{userID}/restore)
+func (app *application) restoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var user *store.User
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Users.Restore(r.Context(), id); err != nil {
+			return err
+		}
+		if user, err = s.Users.GetByID(r.Context(), id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "user.restore", "user", id, nil, user)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, user); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -1,43 +1,405 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/blob"
+	"github.com/timour/go-api/internal/jobs"
+	"github.com/timour/go-api/internal/mailer"
+	"github.com/timour/go-api/internal/render"
 	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/stream"
+	"github.com/timour/go-api/internal/trace"
+	"github.com/timour/go-api/internal/webhooks"
 )
 
 // application struct hält alle Abhängigkeiten für unsere API
 type application struct {
-	config config
-	store  store.Storage
+	config        config
+	store         store.Storage
+	authenticator auth.Authenticator
+	jobs          *jobs.Queue
+	webhooks      *webhooks.Dispatcher
+	postStream    *stream.Broadcaster[store.Post]
+	blobs         blob.Store
+	blobSigner    *blob.Signer
+	tracer        *trace.Recorder
+	mailer        mailer.Mailer
+	formats       *render.Registry
 }
 
 // config struct enthält alle Konfigurationseinstellungen
 type config struct {
-	addr string   // Server-Adresse und Port
-	db   dbConfig // Database Configuration
+	env             string              // "development" oder "production"
+	addr            string              // Server-Adresse und Port
+	tls             tlsConfig           // HTTPS, leer = nur HTTP
+	shutdownTimeout time.Duration       // Wie lange Requests und Jobs beim Shutdown noch laufen dürfen
+	db              dbConfig            // Database Configuration
+	auth            authConfig          // Authentifizierung
+	softDelete      softDeleteConfig    // Soft Delete & Purge-Job
+	idempotency     idempotencyConfig   // Idempotency-Key für POST Requests
+	cache           cacheConfig         // Cache-Control Policies pro Route
+	jobs            jobsConfig          // Background Job Queue
+	webhooks        webhooksConfig      // Ausgehende Webhooks
+	stream          streamConfig        // Server-Sent Events für neue Posts
+	uploads         uploadsConfig       // Attachments & Avatare
+	trace           traceConfig         // Span-Export
+	bulk            bulkConfig          // Import & Export von Posts
+	graphql         graphqlConfig       // Limits für /v1/graphql
+	moderation      moderationConfig    // Meldungen & Moderations-Queue
+	passwordReset   passwordResetConfig // Passwort vergessen
+	mail            mailConfig          // SMTP, leer = nur Log
+	apiKeys         apiKeysConfig       // API Keys für Dienste
+	compression     compressionConfig   // gzip & brotli für Antworten
+}
+
+// compressionConfig steuert die Kompression von Antworten
+type compressionConfig struct {
+	minBytes int // Kleinere Antworten bleiben unkomprimiert
+}
+
+// apiKeysConfig steuert POST /v1/admin/api-keys
+type apiKeysConfig struct {
+	defaultTTL time.Duration // Gültigkeit, wenn beim Anlegen kein expires_at angegeben ist
+}
+
+// passwordResetConfig steuert die Reset-Links aus POST /v1/users/password-reset
+type passwordResetConfig struct {
+	ttl time.Duration // Gültigkeit eines Reset-Tokens
+	url string        // Link in der E-Mail, das Token wird angehängt
+}
+
+// mailConfig enthält den SMTP-Server für ausgehende E-Mails
+type mailConfig struct {
+	smtpHost string // leer = E-Mails nur ins Log schreiben
+	smtpPort int
+	username string
+	password string
+	from     string
+}
+
+// moderationConfig steuert das automatische Ausblenden gemeldeter Posts
+type moderationConfig struct {
+	autoHideThreshold int // Offene Meldungen, ab denen ein Post ausgeblendet wird, 0 = nie
+}
+
+// graphqlConfig begrenzt Tiefe und Kosten einer GraphQL-Operation
+type graphqlConfig struct {
+	maxDepth      int
+	maxComplexity int
+}
+
+// bulkConfig steuert Import und Export von Posts
+type bulkConfig struct {
+	batchSize      int   // Posts pro Query beim Export bzw. pro COPY beim Import
+	maxImportBytes int64 // Max. Größe eines Imports
+}
+
+// tlsConfig aktiviert HTTPS, sobald certFile und keyFile gesetzt sind
+type tlsConfig struct {
+	certFile        string        // PEM Zertifikat (inkl. Chain)
+	keyFile         string        // PEM Private Key
+	redirectAddr    string        // HTTP-Listener, der auf HTTPS umleitet (leer = keiner)
+	devCertValidity time.Duration // Laufzeit des selbstsignierten Zertifikats in der Entwicklung
+}
+
+// traceConfig legt fest, wohin Spans exportiert werden (beides leer = nirgends)
+type traceConfig struct {
+	exportFile    string        // NDJSON-Datei, z.B. ./data/spans.ndjson
+	exportURL     string        // Collector-Endpoint für POST {"spans": [...]}
+	bufferSize    int           // Spans im Puffer, danach wird verworfen
+	flushInterval time.Duration // Wie oft gebündelt exportiert wird
 }
 
 // dbConfig enthält Database Connection Pool Settings
 type dbConfig struct {
+	driver       string // Storage-Treiber: "postgres", "memory", ...
 	addr         string // Database Connection String
 	maxOpenConns int    // Max. offene Connections
 	maxIdleConns int    // Max. idle Connections
 	maxIdleTime  string // Max. idle Time (z.B. "15m")
+
+	replicaAddrs          []string      // Read Replicas (nur postgres), leer = alles auf DB_ADDR
+	replicaHealthInterval time.Duration // Wie oft die Replicas gepingt werden
+}
+
+// authConfig enthält die Zugangsdaten für geschützte Routen
+type authConfig struct {
+	basic basicConfig // Basic Auth für /v1/admin
+	token tokenConfig // JWT Access Tokens für User
+}
+
+type tokenConfig struct {
+	secret     string        // HMAC Secret
+	exp        time.Duration // Gültigkeit eines Access Tokens
+	refreshExp time.Duration // Gültigkeit einer Session bzw. ihrer Refresh Tokens
+	iss        string        // Issuer und Audience
+}
+
+type basicConfig struct {
+	user string
+	pass string
+}
+
+// softDeleteConfig steuert, wann gelöschte Rows endgültig entfernt werden
+type softDeleteConfig struct {
+	retention     time.Duration // Wie lange gelöschte Rows wiederherstellbar bleiben
+	purgeInterval time.Duration // Wie oft der Purge-Job läuft (0 = aus)
+}
+
+// idempotencyConfig steuert, wie lange Idempotency-Keys gespeichert werden
+type idempotencyConfig struct {
+	ttl time.Duration
+}
+
+// cacheConfig enthält die Cache-Control Header der lesenden Routen
+type cacheConfig struct {
+	feed   string // GET /v1/posts
+	post   string // GET /v1/posts/{postID}
+	user   string // GET /v1/users/{userID}
+	search string // GET /v1/search/posts
+}
+
+// jobsConfig steuert die Job Queue im API-Prozess
+type jobsConfig struct {
+	backend   string      // "memory" oder "postgres" (dauerhaft)
+	queueSize int         // Kapazität des Memory Backends
+	queue     jobs.Config // Worker, Retries und Backoff
+}
+
+// webhooksConfig steuert die Zustellung ausgehender Webhooks
+type webhooksConfig struct {
+	timeout     time.Duration // HTTP Timeout pro Zustellversuch
+	maxFailures int           // Fehlversuche in Folge, nach denen ein Webhook deaktiviert wird
+}
+
+// streamConfig steuert GET /v1/posts/stream
+type streamConfig struct {
+	replaySize int           // Wie viele Posts für Last-Event-ID Replays gepuffert werden
+	bufferSize int           // Wie viele Posts ein Client zurückliegen darf, bevor er getrennt wird
+	heartbeat  time.Duration // Abstand der Heartbeat-Kommentare
+}
+
+// uploadsConfig steuert Datei-Uploads und signierte Download-URLs
+type uploadsConfig struct {
+	dir            string        // Verzeichnis des lokalen blob.Store
+	maxBytes       int64         // Max. Größe eines Attachments
+	avatarMaxBytes int64         // Max. Größe eines Avatars
+	maxDimension   int           // Max. Breite/Höhe von Bildern in Pixeln
+	urlTTL         time.Duration // Gültigkeit signierter Download-URLs
+	urlSecret      string        // HMAC Secret für Download-URLs
 }
 
 // mount() registriert alle HTTP-Routen (Endpoints) für unsere API
 func (app *application) mount() http.Handler {
 	r := chi.NewRouter()
 
-	r.Use(middleware.Recoverer) // Panic Recovery
-	r.Use(middleware.Logger)    // Request Logging
+	r.Use(app.TraceMiddleware)          // X-Request-ID & traceparent
+	r.Use(app.ReadYourWritesMiddleware) // Nach dem Schreiben nur noch vom Primary lesen
+	r.Use(middleware.Recoverer)         // Panic Recovery
+	r.Use(middleware.Logger)            // Request Logging
+	r.Use(app.CompressMiddleware)       // gzip & brotli nach Accept-Encoding
+	r.Use(app.NegotiateMiddleware)      // 406 für schreibende Requests ohne passendes Format
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Get("/health", app.healthCheckHandler)
+
+		r.Route("/posts", func(r chi.Router) {
+			r.With(app.APIKeyMiddleware(store.ScopePostsRead, app.OptionalAuthMiddleware), app.ConditionalGET(app.config.cache.feed)).Get("/", app.listPostsHandler)
+			r.With(app.APIKeyMiddleware(store.ScopePostsWrite, app.AuthTokenMiddleware), app.IdempotencyMiddleware).Post("/", app.createPostHandler)
+			r.Get("/stream", app.streamPostsHandler)
+
+			r.Route("/{postID}", func(r chi.Router) {
+				r.With(app.APIKeyMiddleware(store.ScopePostsRead, app.OptionalAuthMiddleware), app.ConditionalGET(app.config.cache.post)).Get("/", app.getPostHandler)
+				r.With(app.AuthTokenMiddleware).Patch("/", app.updatePostHandler)
+				r.With(app.APIKeyMiddleware(store.ScopePostsWrite, app.BasicAuthMiddleware())).Delete("/", app.deletePostHandler)
+
+				r.Get("/attachments", app.listAttachmentsHandler)
+				r.With(app.AuthTokenMiddleware).Post("/attachments", app.uploadAttachmentHandler)
+
+				r.With(app.AuthTokenMiddleware).Post("/report", app.reportPostHandler)
+
+				r.With(app.AuthTokenMiddleware).Put("/reactions/{type}", app.addReactionHandler)
+				r.With(app.AuthTokenMiddleware).Delete("/reactions/{type}", app.removeReactionHandler)
+			})
+		})
+
+		r.Route("/users", func(r chi.Router) {
+			r.Post("/password-reset", app.requestPasswordResetHandler)
+			r.Put("/password-reset/{token}", app.resetPasswordHandler)
+
+			r.Route("/{userID}", func(r chi.Router) {
+				r.With(app.ConditionalGET(app.config.cache.user)).Get("/", app.getUserHandler)
+				r.With(app.BasicAuthMiddleware()).Delete("/", app.deleteUserHandler)
+				r.With(app.AuthTokenMiddleware).Put("/avatar", app.uploadAvatarHandler)
+			})
+		})
+
+		r.Get("/files/*", app.serveFileHandler)
+
+		r.Route("/authentication", func(r chi.Router) {
+			r.Post("/token", app.createTokenHandler)
+			r.Post("/refresh", app.refreshTokenHandler)
+			r.Post("/logout", app.logoutHandler)
+		})
+
+		r.Route("/sessions", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.listSessionsHandler)
+			r.Delete("/{sessionID}", app.revokeSessionHandler)
+		})
+
+		r.With(app.APIKeyMiddleware(store.ScopePostsRead, app.BasicAuthMiddleware())).Get("/export/posts", app.exportPostsHandler)
+		r.With(app.APIKeyMiddleware(store.ScopePostsWrite, app.BasicAuthMiddleware())).Post("/import/posts", app.importPostsHandler)
+
+		r.With(app.OptionalAuthMiddleware).Post("/graphql", app.graphqlHandler())
+
+		r.Route("/search", func(r chi.Router) {
+			r.With(app.ConditionalGET(app.config.cache.search)).Get("/posts", app.searchPostsHandler)
+		})
+
+		r.Route("/moderation", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware, app.RequireAdminMiddleware)
+
+			r.Get("/queue", app.moderationQueueHandler)
+			r.Put("/posts/{postID}/dismiss", app.dismissReportsHandler)
+			r.Put("/posts/{postID}/hide", app.hideReportedPostHandler)
+			r.Put("/posts/{postID}/suspend-author", app.suspendReportedAuthorHandler)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(app.BasicAuthMiddleware())
+
+			r.Get("/posts/deleted", app.listDeletedPostsHandler)
+			r.Put("/posts/{postID}/restore", app.restorePostHandler)
+			r.Get("/users/deleted", app.listDeletedUsersHandler)
+			r.Put("/users/{userID}/restore", app.restoreUserHandler)
+			r.Get("/users/{userID}/sessions", app.listUserSessionsHandler)
+			r.Delete("/users/{userID}/sessions", app.revokeUserSessionsHandler)
+			r.Get("/jobs/dead", app.listDeadJobsHandler)
+			r.Get("/audit", app.listAuditEventsHandler)
+
+			r.Get("/api-keys", app.listAPIKeysHandler)
+			r.Post("/api-keys", app.createAPIKeyHandler)
+			r.Delete("/api-keys/{keyID}", app.revokeAPIKeyHandler)
+
+			r.Get("/webhooks", app.listWebhooksHandler)
+			r.Post("/webhooks", app.createWebhookHandler)
+			r.Delete("/webhooks/{webhookID}", app.deleteWebhookHandler)
+			r.Put("/webhooks/{webhookID}/enable", app.enableWebhookHandler)
+			r.Get("/webhooks/{webhookID}/deliveries", app.listWebhookDeliveriesHandler)
+			r.Post("/webhooks/deliveries/{deliveryID}/replay", app.replayWebhookDeliveryHandler)
+		})
 	})
 
 	return r
 }
+
+// run startet den HTTP-Server, die Job Queue und den Purge-Job und fährt bei
+// SIGINT/SIGTERM alles geordnet herunter: erst der HTTP-Server (laufende
+// Requests dürfen noch Jobs einreihen), danach die Job Queue.
+func (app *application) run(mux http.Handler) error {
+	srv := &http.Server{
+		Addr:         app.config.addr,
+		Handler:      mux,
+		WriteTimeout: 30 * time.Second,
+		ReadTimeout:  10 * time.Second,
+		IdleTimeout:  time.Minute,
+	}
+
+	// Offene SSE-Streams beenden, sonst wartet Shutdown bis zum Timeout auf sie
+	srv.RegisterOnShutdown(app.postStream.Close)
+
+	reloader, err := app.loadCertificates()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Optionaler HTTP-Listener, der nur auf HTTPS umleitet
+	var redirect *http.Server
+	if reloader != nil {
+		srv.TLSConfig = reloader.ServerConfig()
+		go app.reloadCertificatesOnSIGHUP(ctx, reloader)
+
+		if app.config.tls.redirectAddr != "" {
+			redirect = &http.Server{
+				Addr:         app.config.tls.redirectAddr,
+				Handler:      redirectToHTTPS(app.config.addr),
+				ReadTimeout:  5 * time.Second,
+				WriteTimeout: 5 * time.Second,
+				IdleTimeout:  time.Minute,
+			}
+		}
+	}
+
+	app.jobs.Start()
+
+	// Purge-Job für Soft Deletes im Hintergrund
+	if app.config.softDelete.purgeInterval > 0 {
+		go app.runPurgeJob(ctx)
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		if reloader != nil {
+			log.Printf("Starting server on %s (TLS, certificate valid until %s)",
+				app.config.addr, reloader.NotAfter().Format(time.RFC3339))
+			serverErr <- srv.ListenAndServeTLS("", "")
+			return
+		}
+
+		log.Printf("Starting server on %s", app.config.addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	if redirect != nil {
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", redirect.Addr)
+			serverErr <- redirect.ListenAndServe()
+		}()
+	}
+
+	var errs []error
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+		}
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.config.shutdownTimeout)
+	defer cancel()
+
+	// Auch nach einem Fehler oder abgelaufenem Timeout werden Queue und Tracer
+	// noch beendet: die Queue loggt, was liegen bleibt, der Tracer flusht seine Spans
+	if redirect != nil {
+		errs = append(errs, redirect.Shutdown(shutdownCtx))
+	}
+	errs = append(errs, srv.Shutdown(shutdownCtx))
+	errs = append(errs, app.jobs.Shutdown(shutdownCtx))
+	errs = append(errs, app.tracer.Close(shutdownCtx))
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	log.Println("server stopped")
+	return nil
+}
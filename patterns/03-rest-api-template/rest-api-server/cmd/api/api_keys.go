@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/store"
+)
+
+// CreateAPIKeyPayload ist der Body von POST /v1/admin/api-keys
+type CreateAPIKeyPayload struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"` // RFC 3339, leer = apiKeys.defaultTTL
+}
+
+// Validate prüft Name, Scopes und Ablaufdatum
+func (p CreateAPIKeyPayload) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return fmt.Errorf("name is required")
+	case len(p.Name) > 100:
+		return fmt.Errorf("name must not exceed 100 characters")
+	case len(p.Scopes) == 0:
+		return fmt.Errorf("at least one scope is required")
+	case p.ExpiresAt != nil && !p.ExpiresAt.After(time.Now()):
+		return fmt.Errorf("expires_at must be in the future")
+	}
+
+	for _, scope := range p.Scopes {
+		if !store.IsValidScope(scope) {
+			return fmt.Errorf("scopes must be any of %v", store.APIKeyScopes)
+		}
+	}
+
+	return nil
+}
+
+// createdAPIKey ist die Antwort beim Anlegen, nur hier steht der Key im Klartext
+type createdAPIKey struct {
+	*store.APIKey
+	Key string `json:"key"`
+}
+
+// createAPIKeyHandler legt einen API Key an (POST /v1/admin/api-keys). Der Key
+// wird nur in dieser Antwort gezeigt, gespeichert wird sein Hash.
+func (app *application) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateAPIKeyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(app.config.apiKeys.defaultTTL)
+	if payload.ExpiresAt != nil {
+		expiresAt = *payload.ExpiresAt
+	}
+
+	secret, prefix, hash := auth.NewAPIKey()
+	key := &store.APIKey{
+		Name:      strings.TrimSpace(payload.Name),
+		Prefix:    prefix,
+		Scopes:    payload.Scopes,
+		CreatedBy: getAdminFromContext(r),
+	}
+
+	ctx := r.Context()
+	err := app.store.WithTx(ctx, func(s store.Storage) error {
+		if err := s.APIKeys.Create(ctx, key, hash, expiresAt); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "api_key.create", "api_key", key.ID, nil, key)
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusCreated, createdAPIKey{APIKey: key, Key: secret}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listAPIKeysHandler listet alle API Keys ohne die Keys selbst
+// (GET /v1/admin/api-keys?limit=20&offset=0)
+func (app *application) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	keys, err := app.store.APIKeys.List(r.Context(), p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, keys); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// revokeAPIKeyHandler widerruft einen API Key sofort (DELETE /v1/admin/api-keys/{keyID})
+func (app *application) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "keyID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	err = app.store.WithTx(ctx, func(s store.Storage) error {
+		if err := s.APIKeys.Revoke(ctx, id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "api_key.revoke", "api_key", id, map[string]bool{"revoked": false}, map[string]bool{"revoked": true})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
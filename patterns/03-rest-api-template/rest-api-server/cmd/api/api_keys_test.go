@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"valid", `{"name":"nightly export","scopes":["posts:read"]}`, http.StatusCreated},
+		{"with expiry", `{"name":"importer","scopes":["posts:write"],"expires_at":"2999-01-01T00:00:00Z"}`, http.StatusCreated},
+		{"missing name", `{"scopes":["posts:read"]}`, http.StatusBadRequest},
+		{"no scopes", `{"name":"x","scopes":[]}`, http.StatusBadRequest},
+		{"unknown scope", `{"name":"x","scopes":["users:delete"]}`, http.StatusBadRequest},
+		{"expired", `{"name":"x","scopes":["posts:read"],"expires_at":"2000-01-01T00:00:00Z"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := createAPIKey(t, mux, tt.body)
+			checkResponseCode(t, tt.wantCode, rr.Code)
+		})
+	}
+
+	t.Run("key is shown once", func(t *testing.T) {
+		var created struct {
+			ID     int64  `json:"id"`
+			Key    string `json:"key"`
+			Prefix string `json:"prefix"`
+		}
+		decodeData(t, createAPIKey(t, mux, `{"name":"once","scopes":["posts:read"]}`), &created)
+		if !strings.HasPrefix(created.Key, auth.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+			t.Fatalf("Expected a key starting with its prefix, got %+v", created)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/v1/admin/api-keys", nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+		if strings.Contains(rr.Body.String(), created.Key) {
+			t.Errorf("Expected the list not to contain the key, got %s", rr.Body.String())
+		}
+	})
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	post := seedPost(t, app, anna.ID, "Old", "Content")
+
+	var reader, writer createdAPIKey
+	decodeData(t, createAPIKey(t, mux, `{"name":"reader","scopes":["posts:read"]}`), &reader)
+	decodeData(t, createAPIKey(t, mux, `{"name":"writer","scopes":["posts:write"]}`), &writer)
+
+	withKey := func(method, path, key string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("scopes", func(t *testing.T) {
+		checkResponseCode(t, http.StatusOK, withKey(http.MethodGet, "/v1/export/posts", reader.Key))
+		checkResponseCode(t, http.StatusForbidden, withKey(http.MethodGet, "/v1/export/posts", writer.Key))
+		checkResponseCode(t, http.StatusForbidden, withKey(http.MethodPost, "/v1/import/posts", reader.Key))
+		checkResponseCode(t, http.StatusUnauthorized, withKey(http.MethodGet, "/v1/export/posts", auth.APIKeyPrefix+"nope"))
+		checkResponseCode(t, http.StatusUnauthorized, withKey(http.MethodGet, "/v1/export/posts", ""))
+	})
+
+	t.Run("last used", func(t *testing.T) {
+		key, err := app.store.APIKeys.GetByHash(context.Background(), auth.HashToken(reader.Key))
+		if err != nil || key.LastUsedAt == nil {
+			t.Errorf("Expected last_used_at to be set, got %+v, %v", key, err)
+		}
+	})
+
+	t.Run("audit actor", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNoContent, withKey(http.MethodDelete, fmt.Sprintf("/v1/posts/%d", post.ID), writer.Key))
+
+		events, _ := app.store.Audit.List(context.Background(), store.AuditFilter{TargetType: "post", TargetID: post.ID, Limit: 10})
+		if len(events) != 1 || events[0].ActorType != store.AuditActorAPIKey || events[0].ActorID != fmt.Sprint(writer.ID) {
+			t.Errorf("Expected the delete by the api key in the audit log, got %+v", events)
+		}
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/admin/api-keys/%d", reader.ID), nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+
+		checkResponseCode(t, http.StatusUnauthorized, withKey(http.MethodGet, "/v1/export/posts", reader.Key))
+	})
+}
+
+func TestPostRoutesAcceptUsersAndAPIKeys(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	post := seedPost(t, app, anna.ID, "Hello", "Content")
+
+	var reader, writer createdAPIKey
+	decodeData(t, createAPIKey(t, mux, `{"name":"reader","scopes":["posts:read"]}`), &reader)
+	decodeData(t, createAPIKey(t, mux, `{"name":"writer","scopes":["posts:write"]}`), &writer)
+
+	request := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		return executeRequest(req, mux)
+	}
+
+	t.Run("reads", func(t *testing.T) {
+		postPath := fmt.Sprintf("/v1/posts/%d", post.ID)
+
+		checkResponseCode(t, http.StatusOK, request(http.MethodGet, "/v1/posts", reader.Key, "").Code)
+		checkResponseCode(t, http.StatusOK, request(http.MethodGet, postPath, reader.Key, "").Code)
+		checkResponseCode(t, http.StatusForbidden, request(http.MethodGet, postPath, writer.Key, "").Code)
+		checkResponseCode(t, http.StatusUnauthorized, request(http.MethodGet, postPath, auth.APIKeyPrefix+"nope", "").Code)
+
+		// Ohne Credentials bleiben die Routen öffentlich
+		checkResponseCode(t, http.StatusOK, request(http.MethodGet, postPath, "", "").Code)
+	})
+
+	t.Run("create with api key", func(t *testing.T) {
+		body := fmt.Sprintf(`{"title":"From a service","content":"Hello","user_id":%d}`, anna.ID)
+
+		checkResponseCode(t, http.StatusForbidden, request(http.MethodPost, "/v1/posts", reader.Key, body).Code)
+		checkResponseCode(t, http.StatusBadRequest, request(http.MethodPost, "/v1/posts", writer.Key, `{"title":"No author","content":"Hello"}`).Code)
+		checkResponseCode(t, http.StatusBadRequest, request(http.MethodPost, "/v1/posts", writer.Key, `{"title":"Ghost","content":"Hello","user_id":999}`).Code)
+
+		rr := request(http.MethodPost, "/v1/posts", writer.Key, body)
+		checkResponseCode(t, http.StatusCreated, rr.Code)
+
+		var created store.Post
+		decodeData(t, rr, &created)
+		if created.UserID != anna.ID {
+			t.Errorf("Expected the post to belong to user %d, got %d", anna.ID, created.UserID)
+		}
+
+		events, _ := app.store.Audit.List(context.Background(), store.AuditFilter{TargetType: "post", TargetID: created.ID, Limit: 10})
+		if len(events) != 1 || events[0].ActorType != store.AuditActorAPIKey {
+			t.Errorf("Expected the api key as audit actor, got %+v", events)
+		}
+	})
+
+	t.Run("create with token", func(t *testing.T) {
+		ben := seedUser(t, app, "ben")
+
+		req, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(fmt.Sprintf(`{"title":"Mine","content":"Hello","user_id":%d}`, anna.ID)))
+		req.Header.Set("Authorization", bearerToken(t, app, ben))
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req, mux).Code)
+
+		req, _ = http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(`{"title":"Mine","content":"Hello"}`))
+		req.Header.Set("Authorization", bearerToken(t, app, ben))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req, mux).Code)
+
+		checkResponseCode(t, http.StatusUnauthorized, request(http.MethodPost, "/v1/posts", "", `{"title":"Nobody","content":"Hello"}`).Code)
+	})
+}
+
+func createAPIKey(t *testing.T, mux http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/admin/api-keys", strings.NewReader(body))
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	return executeRequest(req, mux)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+func TestHealthCheck(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/health", nil)
+	rr := executeRequest(req, mux)
+
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	if rr.Body.String() != "OK" {
+		t.Errorf("Expected body OK, got %q", rr.Body.String())
+	}
+}
+
+func TestGetPost(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	user := seedUser(t, app, "anna")
+	post := seedPost(t, app, user.ID, "Hello", "World", "go")
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantErr  string
+	}{
+		{"existing post", fmt.Sprintf("/v1/posts/%d", post.ID), http.StatusOK, ""},
+		{"unknown post", "/v1/posts/999", http.StatusNotFound, "not found"},
+		{"invalid id", "/v1/posts/abc", http.StatusBadRequest, "invalid postID"},
+		{"negative id", "/v1/posts/-1", http.StatusBadRequest, "invalid postID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			rr := executeRequest(req, mux)
+
+			checkResponseCode(t, tt.wantCode, rr.Code)
+
+			if tt.wantErr != "" {
+				if got := decodeError(t, rr); got != tt.wantErr {
+					t.Errorf("Expected error %q, got %q", tt.wantErr, got)
+				}
+				return
+			}
+
+			var got store.Post
+			decodeData(t, rr, &got)
+			if got.ID != post.ID || got.Title != "Hello" {
+				t.Errorf("Expected post %d Hello, got %d %s", post.ID, got.ID, got.Title)
+			}
+		})
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	tests := []struct {
+		name     string
+		setAuth  func(*http.Request)
+		wantCode int
+	}{
+		{"missing header", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"wrong scheme", func(r *http.Request) { r.Header.Set("Authorization", "Bearer abc") }, http.StatusUnauthorized},
+		{"malformed base64", func(r *http.Request) { r.Header.Set("Authorization", "Basic !!!") }, http.StatusUnauthorized},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth(testAdminUser, "wrong") }, http.StatusUnauthorized},
+		{"valid credentials", func(r *http.Request) { r.SetBasicAuth(testAdminUser, testAdminPass) }, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/admin/posts/deleted", nil)
+			tt.setAuth(req)
+
+			rr := executeRequest(req, mux)
+			checkResponseCode(t, tt.wantCode, rr.Code)
+
+			if rr.Code == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("Expected WWW-Authenticate header on 401")
+			}
+		})
+	}
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	user := seedUser(t, app, "ben")
+	post := seedPost(t, app, user.ID, "Soon gone", "Content")
+	postPath := fmt.Sprintf("/v1/posts/%d", post.ID)
+	restorePath := fmt.Sprintf("/v1/admin/posts/%d/restore", post.ID)
+
+	steps := []struct {
+		name     string
+		method   string
+		path     string
+		wantCode int
+	}{
+		{"delete post", http.MethodDelete, postPath, http.StatusNoContent},
+		{"deleted post is hidden", http.MethodGet, postPath, http.StatusNotFound},
+		{"delete twice", http.MethodDelete, postPath, http.StatusNotFound},
+		{"restore post", http.MethodPut, restorePath, http.StatusOK},
+		{"restored post is visible", http.MethodGet, postPath, http.StatusOK},
+		{"restore not deleted post", http.MethodPut, restorePath, http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		req, _ := http.NewRequest(step.method, step.path, nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+
+		rr := executeRequest(req, mux)
+		if rr.Code != step.wantCode {
+			t.Fatalf("%s: expected response code %d, got %d", step.name, step.wantCode, rr.Code)
+		}
+	}
+}
+
+func TestListDeletedUsers(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	seedUser(t, app, "clara")
+	gone := seedUser(t, app, "david")
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/users/%d", gone.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/v1/admin/users/deleted", nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	rr := executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var users []store.User
+	decodeData(t, rr, &users)
+	if len(users) != 1 || users[0].ID != gone.ID || users[0].DeletedAt == nil {
+		t.Errorf("Expected only user %d with deleted_at, got %+v", gone.ID, users)
+	}
+}
+
+func TestSearchPosts(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	user := seedUser(t, app, "emma")
+	seedPost(t, app, user.ID, "Learning Go", "Goroutines and channels", "go", "backend")
+	seedPost(t, app, user.ID, "Go testing", "Table driven tests", "go", "testing")
+	seedPost(t, app, user.ID, "Cooking", "Pasta recipes", "food")
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantTotal int
+		wantErr   string
+	}{
+		{"matches title", "?q=go", http.StatusOK, 2, ""},
+		{"tag filter", "?q=go&tag=testing", http.StatusOK, 1, ""},
+		{"exclude term", "?q=go+-channels", http.StatusOK, 1, ""},
+		{"or", "?q=pasta+or+channels", http.StatusOK, 2, ""},
+		{"or binds weaker than and", "?q=go+tests+or+pasta", http.StatusOK, 2, ""},
+		{"no match", "?q=rust", http.StatusOK, 0, ""},
+		{"missing query", "", http.StatusBadRequest, 0, "query parameter q is required"},
+		{"invalid limit", "?q=go&limit=0", http.StatusBadRequest, 0, "limit must be between 1 and 100"},
+		{"invalid user", "?q=go&user_id=x", http.StatusBadRequest, 0, "invalid user_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/search/posts"+tt.query, nil)
+			rr := executeRequest(req, mux)
+
+			checkResponseCode(t, tt.wantCode, rr.Code)
+
+			if tt.wantErr != "" {
+				if got := decodeError(t, rr); got != tt.wantErr {
+					t.Errorf("Expected error %q, got %q", tt.wantErr, got)
+				}
+				return
+			}
+
+			var got searchResponse
+			decodeData(t, rr, &got)
+			if got.Total != tt.wantTotal || len(got.Hits) != tt.wantTotal {
+				t.Errorf("Expected %d hits, got total %d with %d hits", tt.wantTotal, got.Total, len(got.Hits))
+			}
+		})
+	}
+
+	t.Run("offset past the last hit", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/search/posts?q=go&offset=10", nil)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var got searchResponse
+		decodeData(t, rr, &got)
+		if got.Total != 2 || len(got.Hits) != 0 {
+			t.Errorf("Expected total 2 with an empty page, got total %d with %d hits", got.Total, len(got.Hits))
+		}
+	})
+}
+
+func TestUnknownRoute(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/does-not-exist", nil)
+	rr := executeRequest(req, mux)
+
+	checkResponseCode(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRequestIDAndTraceparent(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name          string
+		requestID     string
+		traceparent   string
+		wantRequestID string
+		wantTraceID   string
+	}{
+		{"echoes client ids", "client-42", incoming, "client-42", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"generates missing ids", "", "", "", ""},
+		{"replaces unsafe request id", "x*/ DROP TABLE users", "garbage", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/health", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rr := executeRequest(req, mux)
+
+			requestID := rr.Header().Get("X-Request-ID")
+			if !trace.ValidRequestID(requestID) || (tt.wantRequestID != "" && requestID != tt.wantRequestID) {
+				t.Errorf("Expected request ID %q, got %q", tt.wantRequestID, requestID)
+			}
+
+			sc, ok := trace.ParseTraceparent(rr.Header().Get("traceparent"))
+			if !ok {
+				t.Fatalf("Expected a valid traceparent, got %q", rr.Header().Get("traceparent"))
+			}
+			if traceID := sc.Traceparent()[3:35]; tt.wantTraceID != "" && traceID != tt.wantTraceID {
+				t.Errorf("Expected trace %s to continue, got %s", tt.wantTraceID, traceID)
+			}
+			if sc.Traceparent() == incoming {
+				t.Error("Expected a new span ID for the server span")
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// recordAudit schreibt ein Audit Event für den aktuellen Request über s, also
+// in derselben Transaktion wie die Änderung selbst. before/after sind der
+// Zustand des Ziels vor und nach der Änderung, nil = existiert nicht.
+func (app *application) recordAudit(r *http.Request, s store.Storage, action, targetType string, targetID int64, before, after any) error {
+	changes, err := store.AuditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	actorType, actorID := auditActor(r)
+
+	return s.Audit.Record(r.Context(), &store.AuditEvent{
+		ActorType:  actorType,
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		IP:         clientIP(r),
+		RequestID:  trace.RequestID(r.Context()),
+	})
+}
+
+// auditActor ist der eingeloggte User, der API Key oder sonst der Basic Auth Admin
+func auditActor(r *http.Request) (string, string) {
+	if user := getUserFromContext(r); user != nil {
+		return store.AuditActorUser, strconv.FormatInt(user.ID, 10)
+	}
+	if key := getAPIKeyFromContext(r); key != nil {
+		return store.AuditActorAPIKey, strconv.FormatInt(key.ID, 10)
+	}
+
+	return store.AuditActorAdmin, getAdminFromContext(r)
+}
+
+// clientIP ist die Adresse der TCP-Verbindung ohne Port. X-Forwarded-For wird
+// bewusst ignoriert, der Header lässt sich vom Client beliebig setzen.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// listAuditEventsHandler durchsucht das Audit Log, neueste zuerst
+// (GET /v1/admin/audit?actor_type=user&actor_id=42&target_type=post&target_id=7&from=…&to=…)
+func (app *application) listAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := readAuditFilter(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	events, err := app.store.Audit.List(r.Context(), filter)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, events); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// readAuditFilter liest die Query-Parameter von listAuditEventsHandler.
+// from und to sind RFC 3339, to ist exklusiv.
+func readAuditFilter(r *http.Request) (store.AuditFilter, error) {
+	p, err := readPagination(r)
+	if err != nil {
+		return store.AuditFilter{}, err
+	}
+
+	qs := r.URL.Query()
+	filter := store.AuditFilter{
+		ActorType:  qs.Get("actor_type"),
+		ActorID:    qs.Get("actor_id"),
+		TargetType: qs.Get("target_type"),
+		Limit:      p.Limit,
+		Offset:     p.Offset,
+	}
+
+	switch filter.ActorType {
+	case "", store.AuditActorUser, store.AuditActorAdmin, store.AuditActorAPIKey, store.AuditActorCLI:
+	default:
+		return filter, fmt.Errorf("actor_type must be %q, %q, %q or %q",
+			store.AuditActorUser, store.AuditActorAdmin, store.AuditActorAPIKey, store.AuditActorCLI)
+	}
+
+	if id := qs.Get("target_id"); id != "" {
+		filter.TargetID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil || filter.TargetID < 1 {
+			return filter, fmt.Errorf("target_id must be a positive number")
+		}
+	}
+
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := qs.Get(name); v != "" {
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("from must be before to")
+	}
+
+	return filter, nil
+}
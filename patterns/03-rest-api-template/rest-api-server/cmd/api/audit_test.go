@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestAuditLog(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	post := seedPost(t, app, anna.ID, "Old title", "Content")
+
+	req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/v1/posts/%d", post.ID), strings.NewReader(`{"title":"New title"}`))
+	req.Header.Set("Authorization", bearerToken(t, app, anna))
+	req.Header.Set("X-Request-ID", "audit-test-1")
+	req.RemoteAddr = "203.0.113.7:52100"
+	checkResponseCode(t, http.StatusOK, executeRequest(req, mux).Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/posts/%d", post.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/admin/audit?target_type=post&target_id=%d", post.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	rr := executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var events []store.AuditEvent
+	decodeData(t, rr, &events)
+	if len(events) != 2 {
+		t.Fatalf("Expected update and delete events, got %+v", events)
+	}
+
+	deleted, updated := events[0], events[1]
+	if deleted.Action != "post.delete" || deleted.ActorType != store.AuditActorAdmin || deleted.ActorID != testAdminUser {
+		t.Errorf("Expected the admin delete first, got %+v", deleted)
+	}
+	if string(deleted.Changes["title"].From) != `"New title"` || string(deleted.Changes["title"].To) != "null" {
+		t.Errorf("Expected the deleted post in the diff, got %+v", deleted.Changes)
+	}
+
+	if updated.Action != "post.update" || updated.ActorType != store.AuditActorUser || updated.ActorID != strconv.FormatInt(anna.ID, 10) {
+		t.Errorf("Expected anna's update, got %+v", updated)
+	}
+	if updated.IP != "203.0.113.7" || updated.RequestID != "audit-test-1" {
+		t.Errorf("Expected IP and request ID of the request, got %q and %q", updated.IP, updated.RequestID)
+	}
+	if _, ok := updated.Changes["content"]; ok || string(updated.Changes["title"].From) != `"Old title"` {
+		t.Errorf("Expected only the changed fields in the diff, got %+v", updated.Changes)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/v1/admin/audit?actor_type=user&actor_id="+strconv.FormatInt(anna.ID, 10), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	rr = executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	events = nil
+	decodeData(t, rr, &events)
+	if len(events) != 1 || events[0].ID != updated.ID {
+		t.Errorf("Expected only anna's event, got %+v", events)
+	}
+}
+
+func TestAuditLogValidation(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{"time range", "from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z", http.StatusOK},
+		{"unknown actor type", "actor_type=robot", http.StatusBadRequest},
+		{"invalid target id", "target_id=abc", http.StatusBadRequest},
+		{"invalid from", "from=yesterday", http.StatusBadRequest},
+		{"empty range", "from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/admin/audit?"+tt.query, nil)
+			req.SetBasicAuth(testAdminUser, testAdminPass)
+
+			checkResponseCode(t, tt.wantCode, executeRequest(req, mux).Code)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// CreateUserTokenPayload ist der Body von POST /v1/authentication/token
+type CreateUserTokenPayload struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// createTokenHandler tauscht E-Mail und Passwort gegen ein Access Token und
+// einen Refresh Token. Jeder Login ist eine eigene Session.
+func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateUserTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.Email == "" || payload.Password == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("email and password are required"))
+		return
+	}
+
+	user, err := app.store.Users.GetByEmail(r.Context(), payload.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.unauthorizedErrorResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := user.ComparePassword(payload.Password); err != nil {
+		app.unauthorizedErrorResponse(w, r, err)
+		return
+	}
+
+	// Erst nach dem Passwort prüfen, sonst verrät die Antwort, ob es das Konto gibt
+	if !user.IsActive {
+		app.forbiddenResponse(w, r, fmt.Errorf("account is deactivated"))
+		return
+	}
+
+	tokens, err := app.startSession(r, user)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusCreated, tokens); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// authTokens ist die Antwort von /v1/authentication/token und /refresh
+type authTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // Sekunden bis das Access Token abläuft
+}
+
+// startSession legt nach einem Login eine Session an und stellt das erste
+// Token-Paar aus
+func (app *application) startSession(r *http.Request, user *store.User) (*authTokens, error) {
+	refresh, hash := auth.NewOpaqueToken()
+
+	// Passt in varchar(255), ohne ein UTF-8 Zeichen zu zerschneiden
+	userAgent := r.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = strings.ToValidUTF8(userAgent[:255], "")
+	}
+
+	session := &store.Session{UserID: user.ID, UserAgent: userAgent, IP: clientIP(r)}
+	expiresAt := time.Now().Add(app.config.auth.token.refreshExp)
+	if err := app.store.Sessions.Create(r.Context(), session, hash, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return app.issueTokens(user, session.ID, refresh)
+}
+
+func (app *application) issueTokens(user *store.User, sessionID int64, refresh string) (*authTokens, error) {
+	access, err := app.generateAccessToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authTokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(app.config.auth.token.exp.Seconds()),
+	}, nil
+}
+
+// RefreshTokenPayload ist der Body von /v1/authentication/refresh und /logout
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshTokenHandler tauscht einen Refresh Token gegen ein neues Token-Paar
+// (POST /v1/authentication/refresh). Jeder Refresh Token gilt nur einmal; taucht
+// ein benutzter wieder auf, wird die ganze Session widerrufen.
+func (app *application) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.RefreshToken == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("refresh_token is required"))
+		return
+	}
+
+	ctx := r.Context()
+	refresh, hash := auth.NewOpaqueToken()
+
+	var session *store.Session
+	err := app.store.WithTx(ctx, func(s store.Storage) error {
+		var err error
+		session, err = s.Sessions.Rotate(ctx, auth.HashToken(payload.RefreshToken), hash)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrTokenReused):
+			if err := app.revokeReusedSession(r, session); err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			app.unauthorizedErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound):
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("invalid or expired refresh token"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user, err := app.store.Users.GetByID(ctx, session.UserID)
+	if err != nil {
+		app.unauthorizedErrorResponse(w, r, err)
+		return
+	}
+	if !user.IsActive {
+		if err := app.store.Sessions.Revoke(ctx, session.ID); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		app.unauthorizedErrorResponse(w, r, fmt.Errorf("account is deactivated"))
+		return
+	}
+
+	tokens, err := app.issueTokens(user, session.ID, refresh)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, tokens); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// revokeReusedSession widerruft die Session eines wiederverwendeten Refresh
+// Tokens. Damit sind auch der aktuelle Refresh Token und alle Access Tokens
+// der Session ungültig, egal ob Angreifer oder Client ihn gerade hält.
+func (app *application) revokeReusedSession(r *http.Request, session *store.Session) error {
+	ctx := r.Context()
+	trace.Logf(ctx, "refresh token reuse detected, revoking session %d of user %d", session.ID, session.UserID)
+
+	return app.store.WithTx(ctx, func(s store.Storage) error {
+		if err := s.Sessions.Revoke(ctx, session.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+
+		user, err := s.Users.GetByID(ctx, session.UserID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		// Der Token weist den User aus, also ist er auch der Actor im Audit Log
+		return app.recordAudit(r.WithContext(withUser(ctx, user)), s, "session.reuse_detected", "session", session.ID, nil, nil)
+	})
+}
+
+// logoutHandler widerruft die Session eines Refresh Tokens
+// (POST /v1/authentication/logout). Unbekannte Tokens sind kein Fehler.
+func (app *application) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.RefreshToken == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("refresh_token is required"))
+		return
+	}
+
+	ctx := r.Context()
+
+	session, err := app.store.Sessions.GetByToken(ctx, auth.HashToken(payload.RefreshToken))
+	if err == nil {
+		err = app.store.Sessions.Revoke(ctx, session.ID)
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// generateAccessToken erstellt ein kurzlebiges JWT für user. "ver" ist die
+// TokenVersion des Users; nach Users.RevokeTokens passt sie nicht mehr. "sid"
+// ist die Session, mit ihr wird auch das Access Token widerrufen.
+func (app *application) generateAccessToken(user *store.User, sessionID int64) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": strconv.FormatInt(user.ID, 10),
+		"ver": user.TokenVersion,
+		"exp": time.Now().Add(app.config.auth.token.exp).Unix(),
+		"iat": time.Now().Unix(),
+		"nbf": time.Now().Unix(),
+		"iss": app.config.auth.token.iss,
+		"aud": app.config.auth.token.iss,
+	}
+	if sessionID > 0 {
+		claims["sid"] = sessionID
+	}
+
+	return app.authenticator.GenerateToken(claims)
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/timour/go-api/internal/render"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+const (
+	bulkIdleTimeout    = 30 * time.Second // Read/Write Deadline, wird pro Batch verlängert
+	maxImportLineBytes = 1 << 20          // Max. Länge einer NDJSON-Zeile
+	maxImportErrors    = 100              // Mehr Fehler werden nur noch gezählt
+)
+
+// csvPostColumns ist der Header von CSV-Exporten. Tags stehen als JSON-Array
+// in ihrer Spalte, so bleiben Kommas und Anführungszeichen in Tags erhalten.
+var csvPostColumns = []string{"id", "title", "content", "user_id", "tags", "created_at", "updated_at"}
+
+// postEncoder schreibt Posts in einem Exportformat
+type postEncoder interface {
+	Encode(*store.Post) error
+	Flush() error
+}
+
+type ndjsonPostEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonPostEncoder) Encode(p *store.Post) error { return e.enc.Encode(p) }
+func (e *ndjsonPostEncoder) Flush() error               { return e.w.Flush() }
+
+type csvPostEncoder struct {
+	w *csv.Writer
+}
+
+// newCSVPostEncoder schreibt den Header gleich mit. Er landet erst beim
+// ersten Flush im Response, ein Fehler davor wird also noch als JSON gemeldet.
+func newCSVPostEncoder(w io.Writer) (*csvPostEncoder, error) {
+	e := &csvPostEncoder{w: csv.NewWriter(w)}
+	return e, e.w.Write(csvPostColumns)
+}
+
+func (e *csvPostEncoder) Encode(p *store.Post) error {
+	tags, err := json.Marshal(p.Tags)
+	if err != nil {
+		return err
+	}
+
+	return e.w.Write([]string{
+		strconv.FormatInt(p.ID, 10), render.EscapeCSVCell(p.Title), render.EscapeCSVCell(p.Content), strconv.FormatInt(p.UserID, 10),
+		string(tags), p.CreatedAt, p.UpdatedAt,
+	})
+}
+
+func (e *csvPostEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// exportPostsHandler streamt alle nicht gelöschten Posts in ID-Reihenfolge.
+// Es wird batchweise per Keyset gelesen, nie alles auf einmal.
+// GET /v1/export/posts?format=ndjson|csv
+func (app *application) exportPostsHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+
+	var enc postEncoder
+	switch format {
+	case "ndjson":
+		bw := bufio.NewWriter(w)
+		enc = &ndjsonPostEncoder{w: bw, enc: json.NewEncoder(bw)}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	case "csv":
+		csvEnc, err := newCSVPostEncoder(w)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		enc = csvEnc
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	default:
+		app.badRequestResponse(w, r, fmt.Errorf("format must be ndjson or csv"))
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="posts.%s"`, format))
+
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+	var after int64
+	started := false
+
+	for {
+		posts, err := app.store.Posts.ListAfter(ctx, after, app.config.bulk.batchSize)
+		if err != nil {
+			if !started {
+				app.internalServerError(w, r, err)
+				return
+			}
+			// Status und Teile des Bodys sind schon raus, der Client sieht einen Abbruch
+			trace.Logf(ctx, "export: aborted after post %d: %s", after, err)
+			return
+		}
+
+		started = true
+
+		// Langsame Clients bekommen pro Batch neue Zeit statt eines festen WriteTimeout
+		if err := rc.SetWriteDeadline(time.Now().Add(bulkIdleTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			trace.Logf(ctx, "export: set write deadline: %s", err)
+			return
+		}
+
+		for i := range posts {
+			if err := enc.Encode(&posts[i]); err != nil {
+				return
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return
+		}
+
+		if len(posts) < app.config.bulk.batchSize {
+			return
+		}
+		after = posts[len(posts)-1].ID
+	}
+}
+
+// ImportPostPayload ist eine Zeile des Imports. Weitere Felder (id,
+// created_at, ...) werden ignoriert, damit sich Exporte direkt importieren lassen.
+type ImportPostPayload struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	UserID  int64    `json:"user_id"`
+	Tags    []string `json:"tags"`
+}
+
+// importReport ist die Antwort von POST /v1/import/posts
+type importReport struct {
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []importError `json:"errors"`  // die ersten maxImportErrors, Failed zählt alle
+	Aborted  bool          `json:"aborted"` // Stream konnte nicht zu Ende gelesen werden
+}
+
+type importError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func (rep *importReport) fail(line int, err error) {
+	rep.Failed++
+	if len(rep.Errors) < maxImportErrors {
+		rep.Errors = append(rep.Errors, importError{Line: line, Error: err.Error()})
+	}
+}
+
+// importLine ist eine gelesene Zeile. err betrifft nur diese Zeile.
+type importLine struct {
+	line    int
+	payload ImportPostPayload
+	err     error
+}
+
+// importDecoder liefert die nächste Zeile, io.EOF am Ende. Jeder andere
+// Fehler bedeutet, dass der Stream nicht weiter gelesen werden kann.
+type importDecoder func() (importLine, error)
+
+// importPostsHandler liest NDJSON oder CSV als Stream, validiert jede Zeile und
+// schreibt die gültigen Posts in Batches (Postgres per COPY). Jeder Batch ist
+// eine eigene Transaktion inkl. Audit Event; die Antwort listet fehlerhafte Zeilen.
+// POST /v1/import/posts (Content-Type: application/x-ndjson oder text/csv)
+func (app *application) importPostsHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, app.config.bulk.maxImportBytes)
+
+	var next importDecoder
+	switch mediaType {
+	case "application/x-ndjson":
+		next = ndjsonImportDecoder(r.Body)
+	case "text/csv":
+		next, err = csvImportDecoder(r.Body)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	default:
+		app.unsupportedMediaTypeResponse(w, r, fmt.Errorf("content type must be application/x-ndjson or text/csv"))
+		return
+	}
+
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+	report := &importReport{Errors: []importError{}}
+	users := make(map[int64]error)
+	var batch []importLine
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		posts := make([]*store.Post, len(batch))
+		for i, l := range batch {
+			posts[i] = &store.Post{Title: l.payload.Title, Content: l.payload.Content, UserID: l.payload.UserID, Tags: l.payload.Tags}
+		}
+
+		first, last := batch[0].line, batch[len(batch)-1].line
+		err := app.store.WithTx(ctx, func(s store.Storage) error {
+			if err := s.Posts.CreateBatch(ctx, posts); err != nil {
+				return err
+			}
+			summary := map[string]int{"count": len(posts), "first_line": first, "last_line": last}
+			return app.recordAudit(r, s, "post.import", "post", 0, nil, summary)
+		})
+		if err != nil {
+			// Details nur ins Log, wie bei internalServerError
+			trace.Logf(ctx, "import: batch of lines %d-%d failed: %s", first, last, err)
+			for _, l := range batch {
+				report.fail(l.line, fmt.Errorf("batch of lines %d-%d could not be stored", first, last))
+			}
+		} else {
+			report.Imported += len(posts)
+		}
+
+		batch = batch[:0]
+		_ = rc.SetReadDeadline(time.Now().Add(bulkIdleTimeout))
+	}
+
+	// Der Upload darf länger dauern als der ReadTimeout des Servers
+	_ = rc.SetReadDeadline(time.Now().Add(bulkIdleTimeout))
+
+	for {
+		l, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				err = fmt.Errorf("body exceeds %d bytes", maxErr.Limit)
+			}
+			report.fail(l.line, err)
+			report.Aborted = true
+			break
+		}
+
+		if l.err == nil {
+			l.err = app.validateImport(r, l.payload, users)
+		}
+		if l.err != nil {
+			report.fail(l.line, l.err)
+			continue
+		}
+
+		batch = append(batch, l)
+		if len(batch) >= app.config.bulk.batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	if err := ctx.Err(); err != nil {
+		trace.Logf(ctx, "import: client went away: %s", err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, report); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// validateImport prüft eine Zeile wie POST /v1/posts und dass der Autor
+// existiert. users merkt sich die Lookups, damit jeder User nur einmal geladen wird.
+func (app *application) validateImport(r *http.Request, p ImportPostPayload, users map[int64]error) error {
+	check := CreatePostPayload{Title: p.Title, Content: p.Content, Tags: p.Tags}
+	if err := check.Validate(); err != nil {
+		return err
+	}
+
+	if p.UserID < 1 {
+		return fmt.Errorf("user_id is required")
+	}
+
+	err, seen := users[p.UserID]
+	if !seen {
+		_, err = app.store.Users.GetByID(r.Context(), p.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			err = fmt.Errorf("user %d does not exist", p.UserID)
+		}
+		users[p.UserID] = err
+	}
+
+	return err
+}
+
+// ndjsonImportDecoder liest ein JSON-Objekt pro Zeile, leere Zeilen werden übersprungen
+func ndjsonImportDecoder(body io.Reader) importDecoder {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineBytes)
+	line := 0
+
+	return func() (importLine, error) {
+		for scanner.Scan() {
+			line++
+			data := scanner.Bytes()
+			if len(data) == 0 {
+				continue
+			}
+
+			l := importLine{line: line}
+			if err := json.Unmarshal(data, &l.payload); err != nil {
+				l.err = fmt.Errorf("invalid JSON: %w", err)
+			}
+			return l, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			if errors.Is(err, bufio.ErrTooLong) {
+				err = fmt.Errorf("line exceeds %d bytes", maxImportLineBytes)
+			}
+			return importLine{line: line + 1}, err
+		}
+		return importLine{}, io.EOF
+	}
+}
+
+// csvImportDecoder erwartet eine Header-Zeile mit mindestens title, content und
+// user_id. tags ist optional und ein JSON-Array, andere Spalten werden ignoriert.
+// Das ' vor Formeln aus dem CSV-Export wird wieder entfernt.
+func csvImportDecoder(body io.Reader) (importDecoder, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1 // Spaltenzahl prüfen wir selbst, pro Zeile
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, required := range []string{"title", "content", "user_id"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %q column", required)
+		}
+	}
+
+	return func() (importLine, error) {
+		record, err := reader.Read()
+		var parseErr *csv.ParseError
+		switch {
+		case errors.Is(err, io.EOF):
+			return importLine{}, io.EOF
+		case errors.As(err, &parseErr):
+			return importLine{line: parseErr.StartLine, err: err}, nil
+		case err != nil:
+			line, _ := reader.FieldPos(0)
+			return importLine{line: line + 1}, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		l := importLine{line: line}
+		if len(record) != len(header) {
+			l.err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			return l, nil
+		}
+
+		l.payload.Title = render.UnescapeCSVCell(record[columns["title"]])
+		l.payload.Content = render.UnescapeCSVCell(record[columns["content"]])
+		if l.payload.UserID, err = strconv.ParseInt(record[columns["user_id"]], 10, 64); err != nil {
+			l.err = fmt.Errorf("user_id must be a number")
+			return l, nil
+		}
+		if i, ok := columns["tags"]; ok && record[i] != "" {
+			if err := json.Unmarshal([]byte(record[i]), &l.payload.Tags); err != nil {
+				l.err = fmt.Errorf("tags must be a JSON array of strings")
+			}
+		}
+		return l, nil
+	}, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestExportPosts(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	// Batch-Größe im Test ist 2, drei Posts brauchen also zwei Seiten
+	first := seedPost(t, app, anna.ID, "First", "Content", "go")
+	deleted := seedPost(t, app, anna.ID, "Deleted", "Content")
+	second := seedPost(t, app, anna.ID, "Second", "Content, with \"quotes\"")
+	third := seedPost(t, app, anna.ID, "=Third", "Content", "a,b")
+	if err := app.store.Posts.Delete(context.Background(), deleted.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/export/posts", nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		if ct := rr.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Expected NDJSON content type, got %q", ct)
+		}
+
+		var ids []int64
+		scanner := bufio.NewScanner(rr.Body)
+		for scanner.Scan() {
+			var post store.Post
+			if err := json.Unmarshal(scanner.Bytes(), &post); err != nil {
+				t.Fatalf("Expected one JSON object per line, got %q", scanner.Text())
+			}
+			ids = append(ids, post.ID)
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint([]int64{first.ID, second.ID, third.ID}) {
+			t.Errorf("Expected all live posts in ID order, got %v", ids)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/export/posts?format=csv", nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Expected valid CSV, got %v", err)
+		}
+		if len(records) != 4 || strings.Join(records[0], ",") != strings.Join(csvPostColumns, ",") {
+			t.Fatalf("Expected header and 3 rows, got %v", records)
+		}
+		if records[2][2] != second.Content || records[3][4] != `["a,b"]` {
+			t.Errorf("Expected content and tags to survive quoting, got %v", records[2:])
+		}
+		if records[3][1] != "'"+third.Title {
+			t.Errorf("Expected a formula-like title to be escaped, got %q", records[3][1])
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/export/posts?format=xml", nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		checkResponseCode(t, http.StatusBadRequest, executeRequest(req, mux).Code)
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/export/posts", nil)
+		checkResponseCode(t, http.StatusUnauthorized, executeRequest(req, mux).Code)
+	})
+}
+
+func TestImportPosts(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+
+	importPosts := func(t *testing.T, contentType, body string) (int, importReport) {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodPost, "/v1/import/posts", strings.NewReader(body))
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		req.Header.Set("Content-Type", contentType)
+		rr := executeRequest(req, mux)
+
+		var report importReport
+		if rr.Code == http.StatusOK {
+			decodeData(t, rr, &report)
+		}
+		return rr.Code, report
+	}
+
+	t.Run("ndjson with errors", func(t *testing.T) {
+		lines := []string{
+			fmt.Sprintf(`{"title":"One","content":"x","user_id":%d,"tags":["go"]}`, anna.ID),
+			`{"title":`,
+			fmt.Sprintf(`{"title":"","content":"x","user_id":%d}`, anna.ID),
+			"",
+			fmt.Sprintf(`{"id":99,"title":"Two","content":"x","user_id":%d,"created_at":"2020-01-01T00:00:00Z"}`, anna.ID),
+			`{"title":"Three","content":"x","user_id":4242}`,
+			fmt.Sprintf(`{"title":"Four","content":"x","user_id":%d}`, anna.ID),
+		}
+
+		code, report := importPosts(t, "application/x-ndjson", strings.Join(lines, "\n"))
+		checkResponseCode(t, http.StatusOK, code)
+
+		if report.Imported != 3 || report.Failed != 3 || report.Aborted {
+			t.Fatalf("Expected 3 imported and 3 failed, got %+v", report)
+		}
+		var failed []int
+		for _, e := range report.Errors {
+			failed = append(failed, e.Line)
+		}
+		if fmt.Sprint(failed) != "[2 3 6]" {
+			t.Errorf("Expected lines 2, 3 and 6 to fail, got %+v", report.Errors)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		body := "user_id,title,content,tags\n" +
+			fmt.Sprintf("%d,CSV one,\"multi\nline\",\"[\"\"a,b\"\"]\"\n", anna.ID) +
+			"abc,CSV two,x,\n" +
+			fmt.Sprintf("%d,CSV three,x\n", anna.ID)
+
+		code, report := importPosts(t, "text/csv; charset=utf-8", body)
+		checkResponseCode(t, http.StatusOK, code)
+
+		if report.Imported != 1 || report.Failed != 2 {
+			t.Fatalf("Expected 1 imported and 2 failed, got %+v", report)
+		}
+		if report.Errors[0].Line != 4 || report.Errors[1].Line != 5 {
+			t.Errorf("Expected lines 4 and 5 to fail, got %+v", report.Errors)
+		}
+
+		posts, _ := app.store.Posts.ListByUser(context.Background(), anna.ID, 1, 0)
+		if len(posts) != 1 || posts[0].Content != "multi\nline" || fmt.Sprint(posts[0].Tags) != "[a,b]" {
+			t.Errorf("Expected the CSV post with quoted fields, got %+v", posts)
+		}
+	})
+
+	t.Run("records audit events per batch", func(t *testing.T) {
+		events, err := app.store.Audit.List(context.Background(), store.AuditFilter{TargetType: "post", Limit: 100})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		// NDJSON: 3 gültige Zeilen in Batches zu 2, CSV: 1 Zeile
+		if len(events) != 3 || events[0].Action != "post.import" || string(events[0].Changes["count"].To) != "1" {
+			t.Errorf("Expected 3 import events, got %+v", events)
+		}
+	})
+
+	t.Run("csv export round trip", func(t *testing.T) {
+		ben := seedUser(t, app, "ben")
+		titles := []string{"- bullet list", "@mention", "=SUM(A1)", "'quoted"}
+		for _, title := range titles {
+			seedPost(t, app, ben.ID, title, "+1 from me")
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/v1/export/posts?format=csv", nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		// Nur bens Posts wieder importieren, er hat danach jeden Post doppelt
+		records, _ := csv.NewReader(rr.Body).ReadAll()
+		var buf strings.Builder
+		w := csv.NewWriter(&buf)
+		w.Write(records[0])
+		for _, record := range records[1:] {
+			if record[3] == strconv.FormatInt(ben.ID, 10) {
+				w.Write(record)
+			}
+		}
+		w.Flush()
+
+		code, report := importPosts(t, "text/csv", buf.String())
+		checkResponseCode(t, http.StatusOK, code)
+		if report.Imported != len(titles) {
+			t.Fatalf("Expected %d imported posts, got %+v", len(titles), report)
+		}
+
+		posts, _ := app.store.Posts.ListByUser(context.Background(), ben.ID, 100, 0)
+		count := map[string]int{}
+		for _, p := range posts {
+			if p.Content != "+1 from me" {
+				t.Errorf("Expected the content unchanged, got %q", p.Content)
+			}
+			count[p.Title]++
+		}
+		for _, title := range titles {
+			if count[title] != 2 {
+				t.Errorf("Expected %q twice after the round trip, got %v", title, count)
+			}
+		}
+	})
+
+	t.Run("missing csv column", func(t *testing.T) {
+		code, _ := importPosts(t, "text/csv", "title,content\nA,B\n")
+		checkResponseCode(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		code, _ := importPosts(t, "application/json", "[]")
+		checkResponseCode(t, http.StatusUnsupportedMediaType, code)
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		line := fmt.Sprintf(`{"title":"Big","content":"x","user_id":%d}`, anna.ID)
+		body := strings.Repeat(line+"\n", (64<<10)/len(line)+10)
+
+		code, report := importPosts(t, "application/x-ndjson", body)
+		checkResponseCode(t, http.StatusOK, code)
+
+		if !report.Aborted || report.Imported == 0 {
+			t.Errorf("Expected a partial import that was aborted, got imported=%d aborted=%v", report.Imported, report.Aborted)
+		}
+	})
+}
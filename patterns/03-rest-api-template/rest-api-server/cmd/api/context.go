@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+type userKey string
+
+const principalCtx userKey = "principal"
+
+// principal ist der authentifizierte Aufrufer: ein User per Bearer Token oder
+// ein Dienst per X-API-Key. Genau eines der beiden Felder ist gesetzt.
+type principal struct {
+	user   *store.User
+	apiKey *store.APIKey
+}
+
+// hasScope prüft, ob der Aufrufer scope nutzen darf. User dürfen alles, was
+// ihre Rolle erlaubt, API Keys nur ihre Scopes.
+func (p *principal) hasScope(scope string) bool {
+	if p.apiKey != nil {
+		return p.apiKey.HasScope(scope)
+	}
+	return p.user != nil
+}
+
+// withPrincipal legt den Aufrufer in den Request-Context
+func withPrincipal(ctx context.Context, p *principal) context.Context {
+	return context.WithValue(ctx, principalCtx, p)
+}
+
+// getPrincipalFromContext liefert den Aufrufer, den AuthTokenMiddleware oder
+// APIKeyMiddleware gesetzt hat, sonst nil
+func getPrincipalFromContext(r *http.Request) *principal {
+	p, _ := r.Context().Value(principalCtx).(*principal)
+	return p
+}
+
+// withUser legt den eingeloggten User in den Request-Context
+func withUser(ctx context.Context, user *store.User) context.Context {
+	return withPrincipal(ctx, &principal{user: user})
+}
+
+// getUserFromContext liefert den User, den AuthTokenMiddleware gesetzt hat
+func getUserFromContext(r *http.Request) *store.User {
+	if p := getPrincipalFromContext(r); p != nil {
+		return p.user
+	}
+	return nil
+}
+
+const sessionCtx userKey = "session"
+
+// withSessionID merkt sich die Session des Access Tokens
+func withSessionID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, sessionCtx, id)
+}
+
+// getSessionIDFromContext liefert die Session-ID aus dem Access Token, 0 für
+// Tokens ohne Session
+func getSessionIDFromContext(r *http.Request) int64 {
+	id, _ := r.Context().Value(sessionCtx).(int64)
+	return id
+}
+
+// getAPIKeyFromContext liefert den Key, den APIKeyMiddleware gesetzt hat
+func getAPIKeyFromContext(r *http.Request) *store.APIKey {
+	if p := getPrincipalFromContext(r); p != nil {
+		return p.apiKey
+	}
+	return nil
+}
+
+const adminCtx userKey = "admin"
+
+// withAdmin merkt sich den per Basic Auth angemeldeten Admin
+func withAdmin(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, adminCtx, username)
+}
+
+// getAdminFromContext liefert den Benutzernamen, den BasicAuthMiddleware gesetzt hat
+func getAdminFromContext(r *http.Request) string {
+	username, _ := r.Context().Value(adminCtx).(string)
+	return username
+}
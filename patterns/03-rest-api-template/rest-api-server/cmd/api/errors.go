@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/timour/go-api/internal/trace"
+)
+
+func (app *application) internalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "internal server error: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusInternalServerError, "the server encountered a problem")
+}
+
+func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "bad request error: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusBadRequest, err.Error())
+}
+
+func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "not found error: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusNotFound, "not found")
+}
+
+func (app *application) unauthorizedBasicErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "unauthorized basic error: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+
+	app.writeError(w, r, http.StatusUnauthorized, "unauthorized")
+}
+
+func (app *application) unauthorizedErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "unauthorized error: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusUnauthorized, "unauthorized")
+}
+
+func (app *application) conflictResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "conflict response: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusConflict, err.Error())
+}
+
+func (app *application) unprocessableEntityResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "unprocessable entity: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+}
+
+func (app *application) forbiddenResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "forbidden: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusForbidden, "forbidden")
+}
+
+func (app *application) payloadTooLargeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "payload too large: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusRequestEntityTooLarge, err.Error())
+}
+
+func (app *application) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	trace.Logf(r.Context(), "unsupported media type: %s path: %s error: %s", r.Method, r.URL.Path, err)
+
+	app.writeError(w, r, http.StatusUnsupportedMediaType, err.Error())
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestConditionalGET(t *testing.T) {
+	app := newTestApplication(t)
+	app.config.cache = cacheConfig{feed: "no-cache", post: "public, max-age=10"}
+	mux := app.mount()
+
+	user := seedUser(t, app, "greta")
+	post := seedPost(t, app, user.ID, "Cache me", "If you can")
+	postPath := fmt.Sprintf("/v1/posts/%d", post.ID)
+
+	get := func(path string, headers map[string]string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return executeRequest(req, mux).Result()
+	}
+
+	first := get(postPath, nil)
+	etag := first.Header.Get("ETag")
+
+	checkResponseCode(t, http.StatusOK, first.StatusCode)
+	if etag == "" {
+		t.Fatal("Expected an ETag")
+	}
+	// Reaktionen haben keinen Zeitstempel, der ETag allein entscheidet
+	if got := first.Header.Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified on a post, got %q", got)
+	}
+	if got := first.Header.Get("Cache-Control"); got != "public, max-age=10" {
+		t.Errorf("Expected route Cache-Control policy, got %q", got)
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak matching etag", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"etag in list", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"if-modified-since without last-modified", map[string]string{"If-Modified-Since": future}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := get(postPath, tt.headers)
+			checkResponseCode(t, tt.wantCode, res.StatusCode)
+
+			if res.StatusCode == http.StatusNotModified && res.Header.Get("ETag") != etag {
+				t.Errorf("Expected ETag %s on 304, got %q", etag, res.Header.Get("ETag"))
+			}
+		})
+	}
+
+	// Avatar, Rolle und Deaktivierung ändern einen User ohne Zeitstempel
+	userPath := fmt.Sprintf("/v1/users/%d", user.ID)
+	if got := get(userPath, nil).Header.Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified on a user, got %q", got)
+	}
+	checkResponseCode(t, http.StatusOK, get(userPath, map[string]string{"If-Modified-Since": future}).StatusCode)
+
+	userETag := get(userPath, nil).Header.Get("ETag")
+	if err := app.store.Users.SetRole(context.Background(), user.ID, store.RoleAdmin); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	promoted := get(userPath, map[string]string{"If-None-Match": userETag})
+	checkResponseCode(t, http.StatusOK, promoted.StatusCode)
+
+	if err := app.store.Users.SetActive(context.Background(), user.ID, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	res := get(userPath, map[string]string{"If-None-Match": promoted.Header.Get("ETag")})
+	checkResponseCode(t, http.StatusOK, res.StatusCode)
+	if err := app.store.Users.SetActive(context.Background(), user.ID, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Eine Reaktion ändert den ETag, obwohl UpdatedAt gleich bleibt
+	req, _ := http.NewRequest(http.MethodPut, postPath+"/reactions/like", nil)
+	req.Header.Set("Authorization", bearerToken(t, app, user))
+	checkResponseCode(t, http.StatusOK, executeRequest(req, mux).Code)
+
+	res = get(postPath, map[string]string{"If-None-Match": etag})
+	checkResponseCode(t, http.StatusOK, res.StatusCode)
+	if res.Header.Get("ETag") == etag {
+		t.Errorf("Expected a new ETag after a reaction")
+	}
+
+	// Der Feed bekommt einen ETag aus dem Body-Hash
+	feed := get("/v1/posts", nil)
+	res = get("/v1/posts", map[string]string{"If-None-Match": feed.Header.Get("ETag")})
+	checkResponseCode(t, http.StatusNotModified, res.StatusCode)
+	if got := feed.Header.Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Expected feed Cache-Control no-cache, got %q", got)
+	}
+
+	// Fehler werden nie mit 304 beantwortet
+	res = get("/v1/posts/999", map[string]string{"If-None-Match": "*"})
+	checkResponseCode(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestConditionalGETLastModified(t *testing.T) {
+	app := newTestApplication(t)
+	modified := time.Now().Add(-time.Hour).UTC()
+
+	handler := app.ConditionalGET("")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+	}{
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Add(time.Minute).Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Minute).Format(http.TimeFormat)}, http.StatusOK},
+		{"if-none-match wins", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": time.Now().UTC().Format(http.TimeFormat)}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			checkResponseCode(t, tt.wantCode, executeRequest(req, handler).Code)
+		})
+	}
+}
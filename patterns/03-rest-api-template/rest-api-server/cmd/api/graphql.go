@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/timour/go-api/internal/dataloader"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// errGraphQLInternal ersetzt interne Fehler in der Antwort, Details landen im Log
+var errGraphQLInternal = errors.New("internal server error")
+
+// graphqlRequest ist der Body von POST /v1/graphql
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    map[string]any `json:"extensions"`
+}
+
+type graphqlCtxKey string
+
+const graphqlCtx graphqlCtxKey = "graphql"
+
+// graphqlContext hält alles, was die Resolver eines Requests teilen
+type graphqlContext struct {
+	r        *http.Request
+	users    *dataloader.Loader[int64, *store.User]
+	posts    *dataloader.Loader[userPostsKey, []store.Post]
+	comments *dataloader.Loader[postCommentsKey, []store.Comment]
+}
+
+// userPostsKey sind die neuesten limit Posts eines Users
+type userPostsKey struct {
+	userID int64
+	limit  int
+}
+
+// postCommentsKey sind die ältesten limit Kommentare eines Posts
+type postCommentsKey struct {
+	postID int64
+	limit  int
+}
+
+// newGraphQLContext legt die Loader für einen Request an
+func (app *application) newGraphQLContext(r *http.Request) *graphqlContext {
+	return &graphqlContext{
+		r: r,
+		users: dataloader.New(func(ctx context.Context, ids []int64) (map[int64]*store.User, error) {
+			users, err := app.store.Users.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[int64]*store.User, len(users))
+			for i := range users {
+				byID[users[i].ID] = &users[i]
+			}
+			return byID, nil
+		}),
+		posts: dataloader.New(func(ctx context.Context, keys []userPostsKey) (map[userPostsKey][]store.Post, error) {
+			// Eine Query pro unterschiedlichem limit, meist also genau eine
+			byLimit := make(map[int][]int64)
+			for _, k := range keys {
+				byLimit[k.limit] = append(byLimit[k.limit], k.userID)
+			}
+
+			result := make(map[userPostsKey][]store.Post, len(keys))
+			for limit, userIDs := range byLimit {
+				posts, err := app.store.Posts.ListByUsers(ctx, userIDs, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, p := range posts {
+					k := userPostsKey{userID: p.UserID, limit: limit}
+					result[k] = append(result[k], p)
+				}
+			}
+			return result, nil
+		}),
+		comments: dataloader.New(func(ctx context.Context, keys []postCommentsKey) (map[postCommentsKey][]store.Comment, error) {
+			byLimit := make(map[int][]int64)
+			for _, k := range keys {
+				byLimit[k.limit] = append(byLimit[k.limit], k.postID)
+			}
+
+			result := make(map[postCommentsKey][]store.Comment, len(keys))
+			for limit, postIDs := range byLimit {
+				comments, err := app.store.Comments.GetByPostIDs(ctx, postIDs, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, c := range comments {
+					k := postCommentsKey{postID: c.PostID, limit: limit}
+					result[k] = append(result[k], c)
+				}
+			}
+			return result, nil
+		}),
+	}
+}
+
+func getGraphQLContext(ctx context.Context) *graphqlContext {
+	gc, _ := ctx.Value(graphqlCtx).(*graphqlContext)
+	return gc
+}
+
+// graphqlHandler beantwortet Queries und Mutations (POST /v1/graphql).
+// Fehler beim Parsen, Validieren oder in den Limits gibt es mit 400,
+// ausgeführte Operationen immer mit 200 und ggf. "errors" im Body.
+func (app *application) graphqlHandler() http.HandlerFunc {
+	schema, err := app.graphqlSchema()
+	if err != nil {
+		panic(fmt.Sprintf("graphql: invalid schema: %v", err))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if err := readJSON(w, r, &req); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		if req.Query == "" {
+			app.badRequestResponse(w, r, errors.New("query is required"))
+			return
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+			Body: []byte(req.Query),
+			Name: "GraphQL request",
+		})})
+		if err != nil {
+			app.graphqlErrorResponse(w, r, gqlerrors.FormatErrors(err))
+			return
+		}
+
+		if v := graphql.ValidateDocument(&schema, doc, nil); !v.IsValid {
+			app.graphqlErrorResponse(w, r, v.Errors)
+			return
+		}
+
+		limits := queryLimits{
+			maxDepth:      app.config.graphql.maxDepth,
+			maxComplexity: app.config.graphql.maxComplexity,
+		}
+		if err := limits.check(doc, req.OperationName, req.Variables); err != nil {
+			app.graphqlErrorResponse(w, r, gqlerrors.FormatErrors(err))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), graphqlCtx, app.newGraphQLContext(r))
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       ctx,
+		})
+
+		if err := app.writeDocument(w, r, http.StatusOK, result); err != nil {
+			app.internalServerError(w, r, err)
+		}
+	}
+}
+
+// graphqlErrorResponse schreibt Fehler im GraphQL-Format {"errors": [...]}
+func (app *application) graphqlErrorResponse(w http.ResponseWriter, r *http.Request, errs []gqlerrors.FormattedError) {
+	trace.Logf(r.Context(), "graphql error: %s path: %s", errs[0].Message, r.URL.Path)
+
+	if err := app.writeDocument(w, r, http.StatusBadRequest, &graphql.Result{Errors: errs}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// graphqlInternalError loggt err und liefert stattdessen eine generische Meldung
+func graphqlInternalError(ctx context.Context, err error) error {
+	trace.Logf(ctx, "graphql internal error: %s", err)
+	return errGraphQLInternal
+}
+
+// graphqlSchema beschreibt User, Post und Comment. Die Resolver für Relationen
+// laden über die Loader im Request-Kontext und geben Thunks zurück, damit
+// graphql-go erst alle Keys einer Ebene sammelt und dann eine Query schickt.
+func (app *application) graphqlSchema() (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"username": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"email":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"role":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"avatarUrl": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					user := p.Source.(*store.User)
+					if user.AvatarKey == "" {
+						return nil, nil
+					}
+					return app.signedURL(user.AvatarKey), nil
+				},
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*store.User).Created, nil
+				},
+			},
+		},
+	})
+
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"content": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*store.Comment).CreatedAt, nil
+				},
+			},
+			"author": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return loadUser(p.Context, p.Source.(*store.Comment).UserID), nil
+				},
+			},
+		},
+	})
+
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"content": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"tags":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*store.Post).CreatedAt, nil
+				},
+			},
+			"updatedAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*store.Post).UpdatedAt, nil
+				},
+			},
+			"author": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return loadUser(p.Context, p.Source.(*store.Post).UserID), nil
+				},
+			},
+			"comments": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))),
+				Args: graphql.FieldConfigArgument{
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					limit, err := graphqlLimitArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+
+					key := postCommentsKey{postID: p.Source.(*store.Post).ID, limit: limit}
+					load := getGraphQLContext(p.Context).comments.Load(p.Context, key)
+					return func() (any, error) {
+						comments, err := load()
+						if err != nil {
+							return nil, graphqlInternalError(p.Context, err)
+						}
+						result := make([]*store.Comment, len(comments))
+						for i := range comments {
+							result[i] = &comments[i]
+						}
+						return result, nil
+					}, nil
+				},
+			},
+		},
+	})
+
+	commentType.AddFieldConfig("post", &graphql.Field{
+		Type: postType,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			post, err := app.store.Posts.GetByID(p.Context, p.Source.(*store.Comment).PostID)
+			return graphqlLookup(p.Context, post, err)
+		},
+	})
+
+	userType.AddFieldConfig("posts", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+		Args: graphql.FieldConfigArgument{
+			"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			limit, err := graphqlLimitArg(p.Args)
+			if err != nil {
+				return nil, err
+			}
+
+			key := userPostsKey{userID: p.Source.(*store.User).ID, limit: limit}
+			load := getGraphQLContext(p.Context).posts.Load(p.Context, key)
+			return func() (any, error) {
+				posts, err := load()
+				if err != nil {
+					return nil, graphqlInternalError(p.Context, err)
+				}
+				return postPointers(posts), nil
+			}, nil
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := graphqlIDArg(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					return loadUser(p.Context, id), nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(userType)),
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					raw, _ := p.Args["ids"].([]any)
+					if len(raw) > 100 {
+						return nil, errors.New("at most 100 ids are allowed")
+					}
+
+					// Einzelne Thunks pro ID, graphql-go löst sie gemeinsam auf
+					users := make([]any, len(raw))
+					for i, v := range raw {
+						id, err := graphqlIDArg(v)
+						if err != nil {
+							return nil, err
+						}
+						users[i] = loadUser(p.Context, id)
+					}
+					return users, nil
+				},
+			},
+			"post": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := graphqlIDArg(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					post, err := app.store.Posts.GetByID(p.Context, id)
+					return graphqlLookup(p.Context, post, err)
+				},
+			},
+			"posts": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+				Args: graphql.FieldConfigArgument{
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					limit, err := graphqlLimitArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					offset, _ := p.Args["offset"].(int)
+					if offset < 0 {
+						return nil, errors.New("offset must not be negative")
+					}
+
+					posts, err := app.store.Posts.List(p.Context, limit, offset)
+					if err != nil {
+						return nil, graphqlInternalError(p.Context, err)
+					}
+					return postPointers(posts), nil
+				},
+			},
+		},
+	})
+
+	createPostInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreatePostInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"content": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":    &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createPostInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					r := getGraphQLContext(p.Context).r
+					user := getUserFromContext(r)
+					if user == nil {
+						return nil, errors.New("authentication required")
+					}
+
+					input, _ := p.Args["input"].(map[string]any)
+					payload := CreatePostPayload{UserID: user.ID}
+					payload.Title, _ = input["title"].(string)
+					payload.Content, _ = input["content"].(string)
+					if tags, ok := input["tags"].([]any); ok {
+						payload.Tags = make([]string, len(tags))
+						for i, tag := range tags {
+							payload.Tags[i], _ = tag.(string)
+						}
+					}
+
+					if err := payload.Validate(); err != nil {
+						return nil, err
+					}
+
+					post, err := app.createPost(r, payload)
+					if err != nil {
+						return nil, graphqlInternalError(p.Context, err)
+					}
+					return post, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// loadUser merkt id im User-Loader vor; fehlende User werden zu null
+func loadUser(ctx context.Context, id int64) func() (any, error) {
+	load := getGraphQLContext(ctx).users.Load(ctx, id)
+	return func() (any, error) {
+		user, err := load()
+		if err != nil {
+			return nil, graphqlInternalError(ctx, err)
+		}
+		if user == nil {
+			return nil, nil
+		}
+		return user, nil
+	}
+}
+
+// graphqlLookup macht aus ErrNotFound ein null statt eines Fehlers
+func graphqlLookup(ctx context.Context, post *store.Post, err error) (any, error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, graphqlInternalError(ctx, err)
+	}
+	return post, nil
+}
+
+// graphqlIDArg liest eine ID, die GraphQL immer als String liefert
+func graphqlIDArg(v any) (int64, error) {
+	s, _ := v.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
+
+// graphqlLimitArg prüft limit wie readPagination
+func graphqlLimitArg(args map[string]any) (int, error) {
+	limit, _ := args["limit"].(int)
+	if limit < 1 || limit > 100 {
+		return 0, errors.New("limit must be between 1 and 100")
+	}
+	return limit, nil
+}
+
+func postPointers(posts []store.Post) []*store.Post {
+	result := make([]*store.Post, len(posts))
+	for i := range posts {
+		result[i] = &posts[i]
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListSize schätzt die Länge von Listen ohne limit-Argument, passend
+// zu den Defaults im Schema
+var defaultListSize = map[string]int{
+	"users":    20,
+	"posts":    20,
+	"comments": 10,
+}
+
+// queryLimits lehnt zu tiefe oder zu teure Operationen vor der Ausführung ab
+type queryLimits struct {
+	maxDepth      int // Max. Verschachtelung von Feldern, 0 = kein Limit
+	maxComplexity int // Max. geschätzte Anzahl aufgelöster Felder, 0 = kein Limit
+}
+
+// check misst die ausgewählte Operation in doc. Kosten pro Feld: 1 plus die
+// Kosten der Unterfelder mal der erwarteten Listenlänge (limit, Anzahl ids
+// oder defaultListSize).
+func (l queryLimits) check(doc *ast.Document, operationName string, variables map[string]any) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operations []*ast.OperationDefinition
+
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			operations = append(operations, def)
+		}
+	}
+
+	var op *ast.OperationDefinition
+	for _, o := range operations {
+		if operationName == "" && len(operations) == 1 || o.Name != nil && o.Name.Value == operationName {
+			op = o
+		}
+	}
+	if op == nil {
+		// Das meldet graphql.Execute mit einer passenden Fehlermeldung
+		return nil
+	}
+
+	m := queryMeter{fragments: fragments, variables: variables}
+	depth, complexity := m.measure(op.SelectionSet)
+	if m.err != nil {
+		return m.err
+	}
+
+	if l.maxDepth > 0 && depth > l.maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, l.maxDepth)
+	}
+	if l.maxComplexity > 0 && complexity > l.maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, l.maxComplexity)
+	}
+
+	return nil
+}
+
+type queryMeter struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	err       error // erstes ungültiges limit, das die Schätzung unbrauchbar macht
+}
+
+// measure liefert Tiefe und Kosten eines Selection Sets. Zyklen zwischen
+// Fragmenten hat die Validierung vorher schon abgelehnt.
+func (m *queryMeter) measure(set *ast.SelectionSet) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, sel := range set.Selections {
+		var d, c int
+
+		switch sel := sel.(type) {
+		case *ast.Field:
+			// Introspection (__typename, __schema) zählt nicht
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			childDepth, childCost := m.measure(sel.SelectionSet)
+			d = childDepth + 1
+			c = 1
+			if childCost > 0 {
+				c += m.listSize(sel) * childCost
+			}
+		case *ast.InlineFragment:
+			d, c = m.measure(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if frag, ok := m.fragments[sel.Name.Value]; ok {
+				d, c = m.measure(frag.SelectionSet)
+			}
+		}
+
+		depth = max(depth, d)
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+// listSize schätzt, wie oft die Unterfelder von field aufgelöst werden. Ein
+// limit außerhalb von 1 bis 100 lehnt der Resolver ohnehin ab; als Faktor
+// würde ein negatives limit aber die Kosten der restlichen Query aufheben.
+func (m *queryMeter) listSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case "limit":
+			if n, ok := m.intValue(arg.Value); ok {
+				if n < 1 || n > 100 {
+					if m.err == nil {
+						m.err = fmt.Errorf("limit of %s must be between 1 and 100", field.Name.Value)
+					}
+					return 1
+				}
+				return n
+			}
+		case "ids":
+			if n, ok := m.listLen(arg.Value); ok {
+				return n
+			}
+		}
+	}
+
+	if n, ok := defaultListSize[field.Name.Value]; ok {
+		return n
+	}
+	return 1
+}
+
+func (m *queryMeter) intValue(v ast.Value) (int, bool) {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		// JSON-Zahlen kommen als float64 an
+		if f, ok := m.variables[v.Name.Value].(float64); ok {
+			return int(f), true
+		}
+	}
+	return 0, false
+}
+
+func (m *queryMeter) listLen(v ast.Value) (int, bool) {
+	switch v := v.(type) {
+	case *ast.ListValue:
+		return len(v.Values), true
+	case *ast.Variable:
+		if list, ok := m.variables[v.Name.Value].([]any); ok {
+			return len(list), true
+		}
+	}
+	return 0, false
+}
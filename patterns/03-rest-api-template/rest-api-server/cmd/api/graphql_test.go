@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// graphqlResponse ist die Antwort von /v1/graphql
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+	first := seedPost(t, app, anna.ID, "First", "Content", "go")
+	seedPost(t, app, anna.ID, "Second", "Content")
+	seedPost(t, app, ben.ID, "Ben's post", "Content")
+
+	comment := &store.Comment{PostID: first.ID, UserID: ben.ID, Content: "Nice"}
+	if err := app.store.Comments.Create(context.Background(), comment); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	query := `query Feed($limit: Int) {
+		posts(limit: $limit) {
+			title
+			author { username }
+			comments { content author { username } }
+		}
+		users(ids: ["` + strconv.FormatInt(anna.ID, 10) + `", "4242"]) {
+			username
+			posts(limit: 1) { title }
+		}
+	}`
+	body, _ := json.Marshal(graphqlRequest{Query: query, Variables: map[string]any{"limit": 10}})
+	rr := postGraphQL(t, mux, "", string(body))
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var resp graphqlResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Expected JSON, got %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Expected no errors, got %+v", resp.Errors)
+	}
+
+	var data struct {
+		Posts []struct {
+			Title    string
+			Author   struct{ Username string }
+			Comments []struct {
+				Content string
+				Author  struct{ Username string }
+			}
+		}
+		Users []*struct {
+			Username string
+			Posts    []struct{ Title string }
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("Expected data, got %v", err)
+	}
+
+	if len(data.Posts) != 3 || data.Posts[2].Title != "First" || data.Posts[2].Author.Username != "anna" {
+		t.Fatalf("Expected the feed with authors, got %+v", data.Posts)
+	}
+	if c := data.Posts[2].Comments; len(c) != 1 || c[0].Content != "Nice" || c[0].Author.Username != "ben" {
+		t.Errorf("Expected ben's comment on the first post, got %+v", c)
+	}
+	if data.Posts[0].Author.Username != "ben" || len(data.Posts[0].Comments) != 0 {
+		t.Errorf("Expected ben's post without comments, got %+v", data.Posts[0])
+	}
+
+	if len(data.Users) != 2 || data.Users[1] != nil {
+		t.Fatalf("Expected anna and null for the unknown id, got %+v", data.Users)
+	}
+	if u := data.Users[0]; u.Username != "anna" || len(u.Posts) != 1 || u.Posts[0].Title != "Second" {
+		t.Errorf("Expected anna with her newest post, got %+v", u)
+	}
+
+	// comments(limit) lädt pro Post nur die ältesten limit Kommentare
+	second := &store.Comment{PostID: first.ID, UserID: anna.ID, Content: "Thanks"}
+	if err := app.store.Comments.Create(context.Background(), second); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	query = `{ post(id: "` + strconv.FormatInt(first.ID, 10) + `") { comments(limit: 1) { content } } }`
+	body, _ = json.Marshal(graphqlRequest{Query: query})
+	rr = postGraphQL(t, mux, "", string(body))
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var limited struct {
+		Data struct {
+			Post struct {
+				Comments []struct{ Content string }
+			}
+		}
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&limited); err != nil {
+		t.Fatalf("Expected JSON, got %v", err)
+	}
+	if c := limited.Data.Post.Comments; len(c) != 1 || c[0].Content != "Nice" {
+		t.Errorf("Expected only the oldest comment, got %+v", c)
+	}
+}
+
+func TestGraphQLCreatePost(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	mutation := `{"query":"mutation { createPost(input: {title: \"Hello\", content: \"World\", tags: [\"go\"]}) { id title tags author { username } } }"}`
+
+	t.Run("authenticated", func(t *testing.T) {
+		rr := postGraphQL(t, mux, bearerToken(t, app, anna), mutation)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		body := rr.Body.String()
+		if !strings.Contains(body, `"title":"Hello"`) || !strings.Contains(body, `"username":"anna"`) {
+			t.Fatalf("Expected the created post, got %s", body)
+		}
+
+		posts, _ := app.store.Posts.ListByUser(context.Background(), anna.ID, 10, 0)
+		if len(posts) != 1 || posts[0].Tags[0] != "go" {
+			t.Errorf("Expected the post in the store, got %+v", posts)
+		}
+
+		events, _ := app.store.Audit.List(context.Background(), store.AuditFilter{Limit: 10})
+		if len(events) != 1 || events[0].Action != "post.create" {
+			t.Errorf("Expected a post.create audit event, got %+v", events)
+		}
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		rr := postGraphQL(t, mux, "", mutation)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		if !strings.Contains(rr.Body.String(), "authentication required") {
+			t.Errorf("Expected an authentication error, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		rr := postGraphQL(t, mux, "Bearer nope", mutation)
+		checkResponseCode(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		rr := postGraphQL(t, mux, bearerToken(t, app, anna), `{"query":"mutation { createPost(input: {title: \"\", content: \"x\"}) { id } }"}`)
+		if !strings.Contains(rr.Body.String(), "title is required") {
+			t.Errorf("Expected a validation error, got %s", rr.Body.String())
+		}
+	})
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"within limits", `{"query":"{ posts(limit: 5) { title author { username } } }"}`, http.StatusOK},
+		{"too deep", `{"query":"{ posts { author { posts { author { posts { title } } } } } }"}`, http.StatusBadRequest},
+		{"too deep via fragment", `{"query":"{ posts { ...P } } fragment P on Post { author { posts { author { posts { title } } } } }"}`, http.StatusBadRequest},
+		{"too complex", `{"query":"{ posts(limit: 100) { title comments { content author { username } } } }"}`, http.StatusBadRequest},
+		{"complex via variable", `{"query":"query($n: Int) { posts(limit: $n) { author { posts(limit: $n) { title } } } }","variables":{"n":50}}`, http.StatusBadRequest},
+		// Ein negatives limit darf die Kosten eines anderen Feldes nicht aufheben
+		{"negative limit", `{"query":"{ a: posts(limit: 100) { comments(limit: 100) { author { username } } } b: user(id: \"1\") { posts(limit: -10000000) { title } } }"}`, http.StatusBadRequest},
+		{"negative limit via variable", `{"query":"query($n: Int) { user(id: \"1\") { posts(limit: $n) { title } } }","variables":{"n":-5}}`, http.StatusBadRequest},
+		{"limit too large", `{"query":"{ posts(limit: 101) { title } }"}`, http.StatusBadRequest},
+		{"comments limit counts", `{"query":"{ posts(limit: 100) { comments(limit: 100) { content } } }"}`, http.StatusBadRequest},
+		{"syntax error", `{"query":"{ posts { "}`, http.StatusBadRequest},
+		{"unknown field", `{"query":"{ posts { secret } }"}`, http.StatusBadRequest},
+		{"missing query", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postGraphQL(t, mux, "", tt.body)
+			checkResponseCode(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+func postGraphQL(t *testing.T, mux http.Handler, authorization, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return executeRequest(req, mux)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+// replayedHeaders werden mit der Antwort gespeichert und beim Replay wieder
+// gesetzt. Request-spezifische Header wie X-Request-ID gehören nicht dazu.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified", "Vary", "Cache-Control", "Link"}
+
+// IdempotencyMiddleware sorgt dafür, dass ein Retry mit gleichem Idempotency-Key
+// keinen zweiten Datensatz anlegt, sondern die gespeicherte Antwort zurückbekommt.
+// Muss nach AuthTokenMiddleware bzw. APIKeyMiddleware laufen, damit Keys pro
+// User bzw. API Key gelten.
+func (app *application) IdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			app.badRequestResponse(w, r, fmt.Errorf("%s must not exceed %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1_048_576))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &store.IdempotencyRecord{
+			Scope:       idempotencyScope(r),
+			Key:         key,
+			Fingerprint: requestFingerprint(r, body),
+			ExpiresAt:   time.Now().Add(app.config.idempotency.ttl),
+		}
+
+		err = app.store.Idempotency.Reserve(r.Context(), record)
+		if errors.Is(err, store.ErrConflict) {
+			app.replayIdempotentResponse(w, r, record)
+			return
+		}
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		// Bricht der Client die Verbindung ab, ist r.Context() schon beendet. Der
+		// Key muss trotzdem abgeschlossen oder freigegeben werden, sonst bekommt
+		// jeder Retry bis zum Ablauf des TTL 409.
+		ctx := context.WithoutCancel(r.Context())
+
+		defer func() {
+			if p := recover(); p != nil {
+				app.releaseIdempotencyKey(ctx, record)
+				panic(p)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// Serverfehler nicht speichern: der Client soll es erneut versuchen können
+		if rec.status >= http.StatusInternalServerError {
+			app.releaseIdempotencyKey(ctx, record)
+			return
+		}
+
+		record.StatusCode = rec.status
+		record.Header = make(map[string][]string)
+		for _, name := range replayedHeaders {
+			if values := rec.Header().Values(name); len(values) > 0 {
+				record.Header[name] = values
+			}
+		}
+		record.Body = rec.body.Bytes()
+
+		if err := app.store.Idempotency.Complete(ctx, record); err != nil {
+			trace.Logf(ctx, "store idempotent response for key %q: %s", key, err)
+		}
+	})
+}
+
+// releaseIdempotencyKey gibt einen reservierten Key wieder frei
+func (app *application) releaseIdempotencyKey(ctx context.Context, record *store.IdempotencyRecord) {
+	if err := app.store.Idempotency.Delete(ctx, record.Scope, record.Key); err != nil {
+		trace.Logf(ctx, "release idempotency key %q: %s", record.Key, err)
+	}
+}
+
+// replayIdempotentResponse beantwortet einen Retry für einen bereits bekannten Key
+func (app *application) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, record *store.IdempotencyRecord) {
+	existing, err := app.store.Idempotency.Get(r.Context(), record.Scope, record.Key)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	switch {
+	case existing.Fingerprint != record.Fingerprint:
+		app.unprocessableEntityResponse(w, r, fmt.Errorf("%s was already used with a different request", idempotencyKeyHeader))
+	case !existing.Completed():
+		app.conflictResponse(w, r, fmt.Errorf("a request with this %s is still in progress", idempotencyKeyHeader))
+	default:
+		for name, values := range existing.Header {
+			w.Header()[http.CanonicalHeaderKey(name)] = values
+		}
+		w.Header().Set(idempotencyReplayedHeader, "true")
+		w.WriteHeader(existing.StatusCode)
+		w.Write(existing.Body)
+	}
+}
+
+// idempotencyScope trennt die Keys verschiedener User und API Keys voneinander
+func idempotencyScope(r *http.Request) string {
+	if user := getUserFromContext(r); user != nil {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	if key := getAPIKeyFromContext(r); key != nil {
+		return fmt.Sprintf("api_key:%d", key.ID)
+	}
+	return "anonymous"
+}
+
+// requestFingerprint hasht Methode, Pfad und Body eines Requests
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder schreibt die Antwort an den Client und merkt sie sich gleichzeitig
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestCreatePostIdempotency(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+
+	const body = `{"title":"Hello","content":"World","tags":["go"]}`
+
+	post := func(user *store.User, key, body string) (int, string, http.Header) {
+		req, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(body))
+		req.Header.Set("Authorization", bearerToken(t, app, user))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+
+		rr := executeRequest(req, mux)
+		return rr.Code, rr.Body.String(), rr.Header()
+	}
+
+	code, first, _ := post(anna, "key-1", body)
+	checkResponseCode(t, http.StatusCreated, code)
+
+	// Retry mit gleichem Key liefert dieselbe Antwort, ohne neuen Post
+	code, replay, header := post(anna, "key-1", body)
+	checkResponseCode(t, http.StatusCreated, code)
+	if replay != first {
+		t.Errorf("Expected replayed body %q, got %q", first, replay)
+	}
+	if header.Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("Expected %s header on replay", idempotencyReplayedHeader)
+	}
+
+	// Gleicher Key mit anderem Body ist ein Fehler des Clients
+	code, _, _ = post(anna, "key-1", `{"title":"Other","content":"World"}`)
+	checkResponseCode(t, http.StatusUnprocessableEntity, code)
+
+	// Keys gelten pro User
+	code, _, _ = post(ben, "key-1", body)
+	checkResponseCode(t, http.StatusCreated, code)
+
+	// Ohne Key wird jeder Request ausgeführt
+	code, _, _ = post(anna, "", body)
+	checkResponseCode(t, http.StatusCreated, code)
+
+	// Validierungsfehler werden ebenfalls gespeichert und wiederholt
+	code, _, _ = post(anna, "key-2", `{"title":""}`)
+	checkResponseCode(t, http.StatusBadRequest, code)
+	code, _, header = post(anna, "key-2", `{"title":""}`)
+	checkResponseCode(t, http.StatusBadRequest, code)
+	if header.Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("Expected %s header on replayed error", idempotencyReplayedHeader)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/posts", nil)
+	rr := executeRequest(req, mux)
+
+	var posts []store.Post
+	decodeData(t, rr, &posts)
+	if len(posts) != 3 {
+		t.Errorf("Expected 3 posts to be created, got %d", len(posts))
+	}
+}
+
+type idempotencyStore interface {
+	Get(ctx context.Context, scope, key string) (*store.IdempotencyRecord, error)
+	Reserve(context.Context, *store.IdempotencyRecord) error
+	Complete(context.Context, *store.IdempotencyRecord) error
+	Delete(ctx context.Context, scope, key string) error
+	PurgeExpired(context.Context) (int64, error)
+}
+
+// ctxIdempotency lässt Schreibzugriffe wie ein echter Treiber scheitern,
+// wenn der Context schon beendet ist
+type ctxIdempotency struct {
+	idempotencyStore
+}
+
+func (s ctxIdempotency) Complete(ctx context.Context, record *store.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.idempotencyStore.Complete(ctx, record)
+}
+
+func (s ctxIdempotency) Delete(ctx context.Context, scope, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.idempotencyStore.Delete(ctx, scope, key)
+}
+
+func TestIdempotencyAfterDisconnect(t *testing.T) {
+	app := newTestApplication(t)
+	app.store.Idempotency = ctxIdempotency{app.store.Idempotency}
+
+	anna := seedUser(t, app, "anna")
+	const body = `{"title":"Hello","content":"World"}`
+
+	// Die Middleware wird direkt aufgerufen, der User steht schon im Context
+	newRequest := func(ctx context.Context, key string) *http.Request {
+		req, _ := http.NewRequestWithContext(withUser(ctx, anna), http.MethodPost, "/v1/posts", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, key)
+		return req
+	}
+
+	t.Run("response is stored after the client is gone", func(t *testing.T) {
+		var cancel context.CancelFunc
+		handler := app.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{}}`))
+		}))
+
+		ctx, c := context.WithCancel(context.Background())
+		cancel = c
+		executeRequest(newRequest(ctx, "gone"), handler)
+
+		rr := executeRequest(newRequest(context.Background(), "gone"), handler)
+		checkResponseCode(t, http.StatusCreated, rr.Code)
+		if rr.Header().Get(idempotencyReplayedHeader) != "true" {
+			t.Errorf("Expected the stored response to be replayed")
+		}
+	})
+
+	t.Run("key is released after a panic", func(t *testing.T) {
+		panicking := app.IdempotencyMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}))
+
+		req := newRequest(context.Background(), "panic")
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected the panic to be re-raised")
+				}
+			}()
+			executeRequest(req, panicking)
+		}()
+
+		_, err := app.store.Idempotency.Get(context.Background(), "user:"+strconv.FormatInt(anna.ID, 10), "panic")
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Expected the key to be released, got %v", err)
+		}
+	})
+}
+
+func TestIdempotencyReplaysHeaders(t *testing.T) {
+	app := newTestApplication(t)
+	anna := seedUser(t, app, "anna")
+
+	handler := app.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/v1/posts/42")
+		w.Header().Set("ETag", `"42-1"`)
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Set("X-Internal", "not replayed")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":42}}`))
+	}))
+
+	post := func() *http.Response {
+		req, _ := http.NewRequestWithContext(withUser(context.Background(), anna), http.MethodPost, "/v1/posts", strings.NewReader(`{}`))
+		req.Header.Set(idempotencyKeyHeader, "headers")
+		return executeRequest(req, handler).Result()
+	}
+
+	post()
+	res := post()
+	checkResponseCode(t, http.StatusCreated, res.StatusCode)
+	if res.Header.Get(idempotencyReplayedHeader) != "true" {
+		t.Fatal("Expected the response to be replayed")
+	}
+
+	if got := res.Header.Get("Location"); got != "/v1/posts/42" {
+		t.Errorf("Expected Location to be replayed, got %q", got)
+	}
+	if got := res.Header.Get("ETag"); got != `"42-1"` {
+		t.Errorf("Expected ETag to be replayed, got %q", got)
+	}
+	if got := res.Header.Values("Vary"); len(got) != 2 {
+		t.Errorf("Expected both Vary values to be replayed, got %v", got)
+	}
+	if got := res.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected Content-Type to be replayed, got %q", got)
+	}
+	if got := res.Header.Get("X-Internal"); got != "" {
+		t.Errorf("Expected X-Internal not to be replayed, got %q", got)
+	}
+}
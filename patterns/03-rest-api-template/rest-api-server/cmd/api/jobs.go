@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/timour/go-api/internal/jobs"
+)
+
+// registerJobs verknüpft alle Job-Typen mit ihren Handlern
+func (app *application) registerJobs() {
+	jobs.Handle(app.jobs, purgeJobType, app.purgeDeleted)
+	jobs.Handle(app.jobs, passwordResetJobType, app.sendPasswordReset)
+	// Raw Handler, weil die Zustellung die Nummer des Versuchs protokolliert
+	app.jobs.Register(webhookJobType, app.deliverWebhook)
+}
+
+// listDeadJobsHandler zeigt endgültig fehlgeschlagene Jobs (GET /v1/admin/jobs/dead)
+func (app *application) listDeadJobsHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	dead, err := app.jobs.DeadLetters(r.Context(), p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, dead); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/db"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// ReadYourWritesMiddleware pinnt einen Request an den Primary, sobald er
+// schreibt, damit er seine Änderungen nicht von einer nachhinkenden Replica
+// liest. Schreibende Methoden lesen von Anfang an vom Primary, sonst könnten
+// Prüfungen vor dem Schreiben auf veralteten Daten beruhen.
+func (app *application) ReadYourWritesMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := db.WithPin(r.Context())
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			db.PinPrimary(ctx)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// TraceMiddleware übernimmt X-Request-ID und traceparent vom Client oder erzeugt
+// neue, legt beide in den Context und schickt sie in der Antwort zurück. Der
+// Request wird zum Wurzel-Span; Store-Queries hängen sich als Kinder an.
+func (app *application) TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !trace.ValidRequestID(requestID) {
+			requestID = trace.NewRequestID()
+		}
+
+		remote, _ := trace.ParseTraceparent(r.Header.Get("traceparent"))
+		ctx, span := app.tracer.StartRequest(r.Context(), r.Method+" "+r.URL.Path, remote, requestID)
+		defer span.End()
+
+		// Für den Request-Logger von chi, der die ID mit ausgibt
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, requestID)
+
+		w.Header().Set("X-Request-ID", requestID)
+		w.Header().Set("traceparent", span.Context().Traceparent())
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// Erst nach dem Routing steht das Pattern fest, z.B. /v1/posts/{postID}
+		if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetAttr("http.route", rctx.RoutePattern())
+		}
+		span.SetAttr("http.method", r.Method)
+		span.SetAttr("http.status_code", ww.Status())
+		if ww.Status() >= 500 {
+			span.RecordError(fmt.Errorf("responded with %d", ww.Status()))
+		}
+	})
+}
+
+// AuthTokenMiddleware prüft das Bearer Token und lädt den User in den Context
+func (app *application) AuthTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("authorization header is missing"))
+			return
+		}
+
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("authorization header is malformed"))
+			return
+		}
+
+		jwtToken, err := app.authenticator.ValidateToken(parts[1])
+		if err != nil {
+			app.unauthorizedErrorResponse(w, r, err)
+			return
+		}
+
+		claims, _ := jwtToken.Claims.(jwt.MapClaims)
+
+		userID, err := strconv.ParseInt(fmt.Sprintf("%v", claims["sub"]), 10, 64)
+		if err != nil {
+			app.unauthorizedErrorResponse(w, r, err)
+			return
+		}
+
+		user, err := app.store.Users.GetByID(r.Context(), userID)
+		if err != nil {
+			app.unauthorizedErrorResponse(w, r, err)
+			return
+		}
+
+		// Tokens ohne "ver" stammen von vor den Token-Versionen und zählen als 0
+		version, _ := claims["ver"].(float64)
+		if int(version) != user.TokenVersion {
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("token has been revoked"))
+			return
+		}
+		if !user.IsActive {
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("account is deactivated"))
+			return
+		}
+
+		ctx := withUser(r.Context(), user)
+
+		// Tokens ohne "sid" stammen von vor den Sessions und laufen einfach ab
+		if sid, _ := claims["sid"].(float64); sid > 0 {
+			session, err := app.store.Sessions.Get(ctx, int64(sid))
+			if err != nil || session.UserID != user.ID {
+				app.unauthorizedErrorResponse(w, r, fmt.Errorf("session has been revoked"))
+				return
+			}
+			ctx = withSessionID(ctx, session.ID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OptionalAuthMiddleware lässt Requests ohne Bearer Token anonym durch, z.B.
+// öffentliche Reads mit Basic Auth eines Admins. Ein mitgeschicktes Token muss
+// aber wie bei AuthTokenMiddleware gültig sein.
+func (app *application) OptionalAuthMiddleware(next http.Handler) http.Handler {
+	authenticated := app.AuthTokenMiddleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdminMiddleware lässt nur User mit der Rolle admin durch, z.B. für
+// die Moderation. Muss nach AuthTokenMiddleware laufen.
+func (app *application) RequireAdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := getUserFromContext(r); user == nil || !user.IsAdmin() {
+			app.forbiddenResponse(w, r, fmt.Errorf("admin role required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIKeyMiddleware lässt Dienste mit einem X-API-Key durch, der scope hat, und
+// legt den Key in den Context. Requests ohne Header gehen an fallback, z.B.
+// BasicAuthMiddleware, damit Admins die Route weiter nutzen können, oder
+// AuthTokenMiddleware, damit User und Dienste dieselbe Route teilen.
+func (app *application) APIKeyMiddleware(scope string, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withoutKey := fallback(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("X-API-Key")
+			if header == "" {
+				withoutKey.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			key, err := app.store.APIKeys.GetByHash(ctx, auth.HashToken(header))
+			if err != nil {
+				switch {
+				case errors.Is(err, store.ErrNotFound):
+					app.unauthorizedErrorResponse(w, r, fmt.Errorf("invalid, expired or revoked api key"))
+				default:
+					app.internalServerError(w, r, err)
+				}
+				return
+			}
+
+			caller := &principal{apiKey: key}
+			if !caller.hasScope(scope) {
+				app.forbiddenResponse(w, r, fmt.Errorf("api key lacks scope %q", scope))
+				return
+			}
+
+			// last_used_at ist nur Information, ein Fehler blockiert den Request nicht
+			if err := app.store.APIKeys.Touch(ctx, key.ID); err != nil {
+				trace.Logf(ctx, "touch api key %d: %s", key.ID, err)
+			}
+
+			next.ServeHTTP(w, r.WithContext(withPrincipal(ctx, caller)))
+		})
+	}
+}
+
+// BasicAuthMiddleware schützt Admin-Routen mit HTTP Basic Auth
+func (app *application) BasicAuthMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				app.unauthorizedBasicErrorResponse(w, r, fmt.Errorf("authorization header is missing"))
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Basic" {
+				app.unauthorizedBasicErrorResponse(w, r, fmt.Errorf("authorization header is malformed"))
+				return
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				app.unauthorizedBasicErrorResponse(w, r, err)
+				return
+			}
+
+			username := app.config.auth.basic.user
+			pass := app.config.auth.basic.pass
+
+			creds := strings.SplitN(string(decoded), ":", 2)
+			if len(creds) != 2 ||
+				subtle.ConstantTimeCompare([]byte(creds[0]), []byte(username)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(creds[1]), []byte(pass)) != 1 {
+				app.unauthorizedBasicErrorResponse(w, r, fmt.Errorf("invalid credentials"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withAdmin(r.Context(), creds[0])))
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// ReportPostPayload ist der Body von POST /v1/posts/{postID}/report
+type ReportPostPayload struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// Validate prüft Grund und Länge der Details
+func (p ReportPostPayload) Validate() error {
+	switch {
+	case !store.IsValidReportReason(p.Reason):
+		return fmt.Errorf("reason must be one of %v", store.ReportReasons)
+	case len(p.Details) > 500:
+		return fmt.Errorf("details must not exceed 500 characters")
+	}
+
+	return nil
+}
+
+// reportPostHandler meldet einen fremden Post (POST /v1/posts/{postID}/report).
+// Erreicht der Post moderation.autoHideThreshold offene Meldungen, wird er
+// ausgeblendet, bis ein Moderator entscheidet.
+func (app *application) reportPostHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload ReportPostPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := app.store.Posts.GetByID(ctx, postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user := getUserFromContext(r)
+	if post.UserID == user.ID {
+		app.badRequestResponse(w, r, errors.New("you cannot report your own post"))
+		return
+	}
+
+	report := &store.Report{
+		PostID:     post.ID,
+		ReporterID: user.ID,
+		Reason:     payload.Reason,
+		Details:    payload.Details,
+	}
+
+	err = app.store.WithTx(ctx, func(s store.Storage) error {
+		// Ohne Sperre zählen zwei gleichzeitige Meldungen unter READ COMMITTED
+		// jeweils nur ihre eigene plus die schon committeten, beide bleiben unter
+		// der Schwelle und der Post bliebe sichtbar. Mit Sperre zählt die zweite
+		// Meldung die erste mit.
+		if err := s.Posts.Lock(ctx, post.ID); err != nil {
+			return err
+		}
+		if err := s.Reports.Create(ctx, report); err != nil {
+			return err
+		}
+		if err := app.recordAudit(r, s, "report.create", "post", post.ID, nil, report); err != nil {
+			return err
+		}
+
+		threshold := app.config.moderation.autoHideThreshold
+		if threshold <= 0 {
+			return nil
+		}
+
+		// >= statt ==: nach einer Senkung von MODERATION_AUTO_HIDE_THRESHOLD liegen
+		// schon mehr Meldungen vor. SetHidden behält hidden_at, wenn der Post schon ausgeblendet ist.
+		open, err := s.Reports.CountOpen(ctx, post.ID)
+		if err != nil || open < threshold {
+			return err
+		}
+
+		trace.Logf(ctx, "auto-hiding post %d after %d reports", post.ID, open)
+		if err := s.Posts.SetHidden(ctx, post.ID, true); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "post.auto_hide", "post", post.ID, hiddenState(false), hiddenState(true))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrConflict):
+			app.conflictResponse(w, r, errors.New("you have already reported this post"))
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusCreated, report); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// moderationQueueHandler listet Posts mit offenen Meldungen, die meistgemeldeten
+// zuerst (GET /v1/moderation/queue?limit=20&offset=0)
+func (app *application) moderationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	queue, err := app.store.Reports.Queue(r.Context(), p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, queue); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// moderationResult ist die Antwort auf eine Moderations-Aktion
+type moderationResult struct {
+	PostID   int64  `json:"post_id"`
+	Action   string `json:"action"`
+	Resolved int64  `json:"resolved"` // Anzahl geschlossener Meldungen
+}
+
+// dismissReportsHandler verwirft alle offenen Meldungen eines Posts und blendet
+// ihn wieder ein (PUT /v1/moderation/posts/{postID}/dismiss)
+func (app *application) dismissReportsHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderate(w, r, "dismiss", func(s store.Storage, item *store.ReportedPost) error {
+		if !item.Hidden {
+			return nil
+		}
+		if err := s.Posts.SetHidden(r.Context(), item.PostID, false); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "post.unhide", "post", item.PostID, hiddenState(true), hiddenState(false))
+	})
+}
+
+// hideReportedPostHandler blendet einen gemeldeten Post aus
+// (PUT /v1/moderation/posts/{postID}/hide)
+func (app *application) hideReportedPostHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderate(w, r, "hide", func(s store.Storage, item *store.ReportedPost) error {
+		return app.hidePost(r, s, item)
+	})
+}
+
+// suspendReportedAuthorHandler blendet den Post aus, deaktiviert seinen Autor
+// und widerruft dessen Tokens und Sessions (PUT /v1/moderation/posts/{postID}/suspend-author)
+func (app *application) suspendReportedAuthorHandler(w http.ResponseWriter, r *http.Request) {
+	app.moderate(w, r, "suspend_author", func(s store.Storage, item *store.ReportedPost) error {
+		if err := app.hidePost(r, s, item); err != nil {
+			return err
+		}
+
+		ctx := r.Context()
+		if err := s.Users.SetActive(ctx, item.AuthorID, false); err != nil {
+			return err
+		}
+		if err := s.Users.RevokeTokens(ctx, item.AuthorID); err != nil {
+			return err
+		}
+		if _, err := s.Sessions.RevokeAll(ctx, item.AuthorID); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "user.suspend", "user", item.AuthorID,
+			map[string]bool{"is_active": true}, map[string]bool{"is_active": false})
+	})
+}
+
+// moderate lädt den Queue-Eintrag des Posts, führt act aus und schließt alle
+// offenen Meldungen ab, alles in einer Transaktion. Ohne offene Meldungen 404.
+func (app *application) moderate(w http.ResponseWriter, r *http.Request, action string, act func(store.Storage, *store.ReportedPost) error) {
+	postID, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	status := store.ReportActioned
+	if action == "dismiss" {
+		status = store.ReportDismissed
+	}
+
+	result := moderationResult{PostID: postID, Action: action}
+	err = app.store.WithTx(ctx, func(s store.Storage) error {
+		item, err := s.Reports.GetQueued(ctx, postID)
+		if err != nil {
+			return err
+		}
+
+		if err := act(s, item); err != nil {
+			return err
+		}
+
+		if result.Resolved, err = s.Reports.Resolve(ctx, postID, status, getUserFromContext(r).ID); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "report."+status, "post", postID, nil, map[string]int64{"reports": result.Resolved})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, result); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// hidePost blendet einen Post aus, falls das nicht schon automatisch passiert ist
+func (app *application) hidePost(r *http.Request, s store.Storage, item *store.ReportedPost) error {
+	if item.Hidden {
+		return nil
+	}
+	if err := s.Posts.SetHidden(r.Context(), item.PostID, true); err != nil {
+		return err
+	}
+	return app.recordAudit(r, s, "post.hide", "post", item.PostID, hiddenState(false), hiddenState(true))
+}
+
+// hiddenState ist der Audit-Zustand für Aus- und Einblenden
+func hiddenState(hidden bool) map[string]bool {
+	return map[string]bool{"hidden": hidden}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestReportPost(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+	cleo := seedUser(t, app, "cleo")
+	post := seedPost(t, app, anna.ID, "Buy cheap pills", "Content")
+
+	report := func(user *store.User, postID int64, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/v1/posts/%d/report", postID), strings.NewReader(body))
+		if user != nil {
+			req.Header.Set("Authorization", bearerToken(t, app, user))
+		}
+		return executeRequest(req, mux).Code
+	}
+
+	tests := []struct {
+		name     string
+		user     *store.User
+		postID   int64
+		body     string
+		wantCode int
+	}{
+		{"unauthenticated", nil, post.ID, `{"reason":"spam"}`, http.StatusUnauthorized},
+		{"unknown reason", ben, post.ID, `{"reason":"boring"}`, http.StatusBadRequest},
+		{"details too long", ben, post.ID, `{"reason":"other","details":"` + strings.Repeat("x", 501) + `"}`, http.StatusBadRequest},
+		{"own post", anna, post.ID, `{"reason":"spam"}`, http.StatusBadRequest},
+		{"unknown post", ben, 4242, `{"reason":"spam"}`, http.StatusNotFound},
+		{"valid", ben, post.ID, `{"reason":"spam","details":"link farm"}`, http.StatusCreated},
+		{"duplicate", ben, post.ID, `{"reason":"hate"}`, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkResponseCode(t, tt.wantCode, report(tt.user, tt.postID, tt.body))
+		})
+	}
+
+	t.Run("auto hide at threshold", func(t *testing.T) {
+		// Schwelle im Test ist 2: ben hat schon gemeldet, cleo ist die zweite
+		checkResponseCode(t, http.StatusCreated, report(cleo, post.ID, `{"reason":"spam"}`))
+
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/posts/%d", post.ID), nil)
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req, mux).Code)
+
+		events, _ := app.store.Audit.List(context.Background(), store.AuditFilter{TargetType: "post", TargetID: post.ID, Limit: 10})
+		if len(events) != 3 || events[0].Action != "post.auto_hide" {
+			t.Errorf("Expected two reports and the auto hide in the audit log, got %+v", events)
+		}
+	})
+}
+
+func TestAutoHidePastThreshold(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	ctx := context.Background()
+
+	author := seedUser(t, app, "anna")
+	post := seedPost(t, app, author.ID, "Spam", "Content")
+
+	// Zwei gleichzeitige Meldungen haben beide eine Meldung zu wenig gezählt:
+	// die Schwelle (2) ist schon erreicht, der Post aber noch sichtbar
+	for _, name := range []string{"ben", "cleo"} {
+		r := &store.Report{PostID: post.ID, ReporterID: seedUser(t, app, name).ID, Reason: "spam"}
+		if err := app.store.Reports.Create(ctx, r); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/v1/posts/%d/report", post.ID), strings.NewReader(`{"reason":"spam"}`))
+	req.Header.Set("Authorization", bearerToken(t, app, seedUser(t, app, "dora")))
+	checkResponseCode(t, http.StatusCreated, executeRequest(req, mux).Code)
+
+	if _, err := app.store.Posts.GetByID(ctx, post.ID); err == nil {
+		t.Error("Expected the post to be hidden with the count past the threshold")
+	}
+}
+
+func TestModerationActions(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	ctx := context.Background()
+
+	mod := seedUser(t, app, "mod")
+	if err := app.store.Users.SetRole(ctx, mod.ID, store.RoleAdmin); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	troll := seedUser(t, app, "troll")
+	reporter := seedUser(t, app, "rita")
+
+	spam := seedPost(t, app, troll.ID, "Spam", "Content")
+	fine := seedPost(t, app, troll.ID, "Fine", "Content")
+	rant := seedPost(t, app, troll.ID, "Rant", "Content")
+	for _, p := range []*store.Post{spam, fine, rant} {
+		r := &store.Report{PostID: p.ID, ReporterID: reporter.ID, Reason: "spam"}
+		if err := app.store.Reports.Create(ctx, r); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	// fine wurde automatisch ausgeblendet, dismiss blendet ihn wieder ein
+	if err := app.store.Posts.SetHidden(ctx, fine.ID, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	moderate := func(user *store.User, method, path string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", bearerToken(t, app, user))
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("requires admin role", func(t *testing.T) {
+		checkResponseCode(t, http.StatusForbidden, moderate(reporter, http.MethodGet, "/v1/moderation/queue"))
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/moderation/queue", nil)
+		req.Header.Set("Authorization", bearerToken(t, app, mod))
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var queue []store.ReportedPost
+		decodeData(t, rr, &queue)
+		if len(queue) != 3 || queue[1].PostID != fine.ID || !queue[1].Hidden || queue[1].AuthorID != troll.ID {
+			t.Errorf("Expected three queued posts, got %+v", queue)
+		}
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		checkResponseCode(t, http.StatusOK, moderate(mod, http.MethodPut, fmt.Sprintf("/v1/moderation/posts/%d/dismiss", fine.ID)))
+
+		if _, err := app.store.Posts.GetByID(ctx, fine.ID); err != nil {
+			t.Errorf("Expected the dismissed post to be visible again, got %v", err)
+		}
+		checkResponseCode(t, http.StatusNotFound, moderate(mod, http.MethodPut, fmt.Sprintf("/v1/moderation/posts/%d/dismiss", fine.ID)))
+	})
+
+	t.Run("hide", func(t *testing.T) {
+		checkResponseCode(t, http.StatusOK, moderate(mod, http.MethodPut, fmt.Sprintf("/v1/moderation/posts/%d/hide", rant.ID)))
+
+		if _, err := app.store.Posts.GetByID(ctx, rant.ID); err == nil {
+			t.Errorf("Expected the post to be hidden")
+		}
+	})
+
+	t.Run("suspend author", func(t *testing.T) {
+		token := bearerToken(t, app, troll)
+		checkResponseCode(t, http.StatusOK, moderate(mod, http.MethodPut, fmt.Sprintf("/v1/moderation/posts/%d/suspend-author", spam.ID)))
+
+		req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/v1/posts/%d", fine.ID), strings.NewReader(`{"title":"Back"}`))
+		req.Header.Set("Authorization", token)
+		checkResponseCode(t, http.StatusUnauthorized, executeRequest(req, mux).Code)
+
+		events, _ := app.store.Audit.List(ctx, store.AuditFilter{TargetType: "user", TargetID: troll.ID, Limit: 10})
+		if len(events) != 1 || events[0].Action != "user.suspend" || events[0].ActorID != fmt.Sprint(mod.ID) {
+			t.Errorf("Expected the suspension by the moderator in the audit log, got %+v", events)
+		}
+	})
+
+	t.Run("queue is empty", func(t *testing.T) {
+		queue, _ := app.store.Reports.Queue(ctx, 10, 0)
+		if len(queue) != 0 {
+			t.Errorf("Expected all reports to be resolved, got %+v", queue)
+		}
+	})
+}
+
+func TestDeleteHiddenPost(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	ctx := context.Background()
+
+	user := seedUser(t, app, "anna")
+	post := seedPost(t, app, user.ID, "Hidden", "Content")
+	if err := app.store.Posts.SetHidden(ctx, post.ID, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/posts/%d", post.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+
+	if _, err := app.store.Posts.GetByIDIncludingHidden(ctx, post.ID); err == nil {
+		t.Error("Expected the hidden post to be deleted")
+	}
+	deleted, _ := app.store.Posts.ListDeleted(ctx, 10, 0)
+	if len(deleted) != 1 || deleted[0].ID != post.ID {
+		t.Errorf("Expected the post among the deleted posts, got %+v", deleted)
+	}
+}
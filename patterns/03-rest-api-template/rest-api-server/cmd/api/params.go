@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// readIDParam liest einen numerischen URL-Parameter wie {postID}
+func readIDParam(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+
+	return id, nil
+}
+
+// pagination enthält limit/offset aus der Query (?limit=20&offset=0)
+type pagination struct {
+	Limit  int
+	Offset int
+}
+
+// readPagination liest limit und offset mit Defaults und Obergrenze
+func readPagination(r *http.Request) (pagination, error) {
+	p := pagination{Limit: 20, Offset: 0}
+	qs := r.URL.Query()
+
+	if limit := qs.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > 100 {
+			return p, fmt.Errorf("limit must be between 1 and 100")
+		}
+		p.Limit = l
+	}
+
+	if offset := qs.Get("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil || o < 0 {
+			return p, fmt.Errorf("offset must be a positive number")
+		}
+		p.Offset = o
+	}
+
+	return p, nil
+}
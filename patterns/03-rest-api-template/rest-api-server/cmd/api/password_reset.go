@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/mailer"
+	"github.com/timour/go-api/internal/store"
+)
+
+// passwordResetJobType verschickt die Reset-E-Mail im Hintergrund
+const passwordResetJobType = "password_reset.send"
+
+// RequestPasswordResetPayload ist der Body von POST /v1/users/password-reset
+type RequestPasswordResetPayload struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordPayload ist der Body von PUT /v1/users/password-reset/{token}
+type ResetPasswordPayload struct {
+	Password string `json:"password"`
+}
+
+// Validate prüft die Länge; bcrypt ignoriert alles nach 72 Bytes
+func (p ResetPasswordPayload) Validate() error {
+	switch {
+	case len(p.Password) < 8:
+		return fmt.Errorf("password must be at least 8 characters")
+	case len(p.Password) > 72:
+		return fmt.Errorf("password must not exceed 72 bytes")
+	}
+
+	return nil
+}
+
+// passwordResetJob ist die Payload von passwordResetJobType
+type passwordResetJob struct {
+	Email string `json:"email"`
+}
+
+// requestPasswordResetHandler fordert einen Reset-Link an (POST /v1/users/password-reset).
+// Die Antwort ist immer gleich und der Lookup passiert erst im Job, so verraten
+// weder Inhalt noch Antwortzeit, ob es ein Konto zur E-Mail gibt.
+func (app *application) requestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RequestPasswordResetPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Email = strings.TrimSpace(payload.Email)
+	if !strings.Contains(payload.Email, "@") || len(payload.Email) > 255 {
+		app.badRequestResponse(w, r, fmt.Errorf("a valid email is required"))
+		return
+	}
+
+	if err := app.jobs.Enqueue(r.Context(), passwordResetJobType, passwordResetJob{Email: payload.Email}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	msg := map[string]string{"message": "if an account with this email exists, a reset link has been sent"}
+	if err := app.writeResponse(w, r, http.StatusAccepted, msg); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// sendPasswordReset erstellt einen Reset-Token und verschickt den Link. Für
+// unbekannte oder deaktivierte Konten passiert nichts.
+func (app *application) sendPasswordReset(ctx context.Context, payload passwordResetJob) error {
+	user, err := app.store.Users.GetByEmail(ctx, payload.Email)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, hash := auth.NewOpaqueToken()
+	ttl := app.config.passwordReset.ttl
+	if err := app.store.PasswordResets.Create(ctx, user.ID, hash, time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	log.Printf("password reset requested for user %d", user.ID)
+
+	return app.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nuse this link within %s to choose a new password:\n\n%s%s\n\n"+
+			"If you did not request a reset, you can ignore this email.\n",
+			user.Username, ttl, app.config.passwordReset.url, token),
+	})
+}
+
+// resetPasswordHandler setzt mit einem Reset-Token ein neues Passwort
+// (PUT /v1/users/password-reset/{token}). Der Token gilt genau einmal, danach
+// sind alle Sessions und bisherigen Access Tokens des Users ungültig.
+func (app *application) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// Hashen vor der Transaktion, bcrypt ist absichtlich langsam
+	var updated store.User
+	if err := updated.SetPassword(payload.Password); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	err := app.store.WithTx(ctx, func(s store.Storage) error {
+		userID, err := s.PasswordResets.Consume(ctx, auth.HashToken(chi.URLParam(r, "token")))
+		if err != nil {
+			return err
+		}
+
+		user, err := s.Users.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.Users.UpdatePassword(ctx, user.ID, updated.Passwort); err != nil {
+			return err
+		}
+		if err := s.Users.RevokeTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := s.Sessions.RevokeAll(ctx, user.ID); err != nil {
+			return err
+		}
+
+		// Der Token weist den User aus, also ist er auch der Actor im Audit Log
+		r = r.WithContext(withUser(ctx, user))
+		return app.recordAudit(r, s, "user.password_reset", "user", user.ID, nil, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, errors.New("invalid or expired reset token"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
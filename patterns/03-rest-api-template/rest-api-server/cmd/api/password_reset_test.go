@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/mailer"
+	"github.com/timour/go-api/internal/store"
+)
+
+// recordingMailer merkt sich alle verschickten E-Mails
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	seedUser(t, app, "anna")
+
+	request := func(body string) (int, string) {
+		req, _ := http.NewRequest(http.MethodPost, "/v1/users/password-reset", strings.NewReader(body))
+		rr := executeRequest(req, mux)
+		return rr.Code, rr.Body.String()
+	}
+
+	knownCode, known := request(`{"email":"anna@example.com"}`)
+	unknownCode, unknown := request(`{"email":"nobody@example.com"}`)
+	checkResponseCode(t, http.StatusAccepted, knownCode)
+	if knownCode != unknownCode || known != unknown {
+		t.Errorf("Expected the same response for known and unknown emails, got %q and %q", known, unknown)
+	}
+
+	code, _ := request(`{"email":"not-an-email"}`)
+	checkResponseCode(t, http.StatusBadRequest, code)
+}
+
+func TestResetPassword(t *testing.T) {
+	app := newTestApplication(t)
+	mail := &recordingMailer{}
+	app.mailer = mail
+	mux := app.mount()
+	ctx := context.Background()
+
+	anna := seedUser(t, app, "anna")
+	oldToken := bearerToken(t, app, anna)
+
+	// Der Job läuft im Test direkt statt über die Queue
+	if err := app.sendPasswordReset(ctx, passwordResetJob{Email: "nobody@example.com"}); err != nil || len(mail.sent) != 0 {
+		t.Fatalf("Expected no mail for an unknown email, got %v, %+v", err, mail.sent)
+	}
+	if err := app.sendPasswordReset(ctx, passwordResetJob{Email: anna.Email}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != anna.Email {
+		t.Fatalf("Expected one mail to anna, got %+v", mail.sent)
+	}
+	_, link, _ := strings.Cut(mail.sent[0].Body, app.config.passwordReset.url)
+	token, _, _ := strings.Cut(link, "\n")
+
+	reset := func(token, body string) int {
+		req, _ := http.NewRequest(http.MethodPut, "/v1/users/password-reset/"+token, strings.NewReader(body))
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("too short", func(t *testing.T) {
+		checkResponseCode(t, http.StatusBadRequest, reset(token, `{"password":"short"}`))
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		checkResponseCode(t, http.StatusBadRequest, reset("nope", `{"password":"new-password"}`))
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNoContent, reset(token, `{"password":"new-password"}`))
+
+		login := func(password string) int {
+			body := fmt.Sprintf(`{"email":%q,"password":%q}`, anna.Email, password)
+			req, _ := http.NewRequest(http.MethodPost, "/v1/authentication/token", strings.NewReader(body))
+			return executeRequest(req, mux).Code
+		}
+		checkResponseCode(t, http.StatusUnauthorized, login(testPassword))
+		checkResponseCode(t, http.StatusCreated, login("new-password"))
+
+		req, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(`{"title":"t","content":"c"}`))
+		req.Header.Set("Authorization", oldToken)
+		checkResponseCode(t, http.StatusUnauthorized, executeRequest(req, mux).Code)
+
+		events, _ := app.store.Audit.List(ctx, store.AuditFilter{TargetType: "user", TargetID: anna.ID, Limit: 10})
+		if len(events) != 1 || events[0].Action != "user.password_reset" || events[0].ActorID != fmt.Sprint(anna.ID) {
+			t.Errorf("Expected the reset in the audit log, got %+v", events)
+		}
+	})
+
+	t.Run("single use", func(t *testing.T) {
+		checkResponseCode(t, http.StatusBadRequest, reset(token, `{"password":"another-password"}`))
+	})
+}
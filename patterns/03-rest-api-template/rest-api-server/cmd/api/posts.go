@@ -0,0 +1,294 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/webhooks"
+)
+
+// CreatePostPayload ist der Body von POST /v1/posts
+type CreatePostPayload struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+	UserID  int64    `json:"user_id"` // Autor, nur mit X-API-Key; User posten immer als sie selbst
+}
+
+// Validate prüft Pflichtfelder und Längen
+func (p CreatePostPayload) Validate() error {
+	switch {
+	case p.Title == "" || len(p.Title) > 100:
+		return fmt.Errorf("title is required and must not exceed 100 characters")
+	case p.Content == "" || len(p.Content) > 1000:
+		return fmt.Errorf("content is required and must not exceed 1000 characters")
+	case len(p.Tags) > 10:
+		return fmt.Errorf("a post can have at most 10 tags")
+	}
+
+	for _, tag := range p.Tags {
+		if tag == "" || len(tag) > 100 {
+			return fmt.Errorf("tags must be between 1 and 100 characters")
+		}
+	}
+
+	return nil
+}
+
+// createPostHandler legt einen Post für den eingeloggten User oder per API Key
+// mit Scope posts:write für user_id an (POST /v1/posts)
+func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreatePostPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if user := getUserFromContext(r); user != nil {
+		if payload.UserID != 0 && payload.UserID != user.ID {
+			app.badRequestResponse(w, r, fmt.Errorf("user_id can only be set with an api key"))
+			return
+		}
+		payload.UserID = user.ID
+	} else {
+		if payload.UserID < 1 {
+			app.badRequestResponse(w, r, fmt.Errorf("user_id is required with an api key"))
+			return
+		}
+		if _, err := app.store.Users.GetByID(r.Context(), payload.UserID); err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.badRequestResponse(w, r, fmt.Errorf("user %d does not exist", payload.UserID))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	post, err := app.createPost(r, payload)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusCreated, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// createPost legt einen validierten Post für payload.UserID an, samt Audit
+// Event, Post Stream und Webhooks. Genutzt von REST und GraphQL.
+func (app *application) createPost(r *http.Request, payload CreatePostPayload) (*store.Post, error) {
+	post := &store.Post{
+		Title:   payload.Title,
+		Content: payload.Content,
+		Tags:    payload.Tags,
+		UserID:  payload.UserID,
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+
+	err := app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Posts.Create(r.Context(), post); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "post.create", "post", post.ID, nil, post)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app.postStream.Publish(*post)
+	app.publishEvent(r.Context(), webhooks.PostCreated, post)
+
+	return post, nil
+}
+
+// UpdatePostPayload ist der Body von PATCH /v1/posts/{postID}. Nur gesetzte Felder werden geändert.
+type UpdatePostPayload struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Tags    *[]string `json:"tags"`
+}
+
+// updatePostHandler ändert einen eigenen Post (PATCH /v1/posts/{postID})
+func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload UpdatePostPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post, err := app.store.Posts.GetByID(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	// Admins dürfen zur Moderation jeden Post bearbeiten
+	if user := getUserFromContext(r); post.UserID != user.ID && !user.IsAdmin() {
+		app.forbiddenResponse(w, r, fmt.Errorf("post %d belongs to another user", id))
+		return
+	}
+
+	before := *post
+
+	if payload.Title != nil {
+		post.Title = *payload.Title
+	}
+	if payload.Content != nil {
+		post.Content = *payload.Content
+	}
+	if payload.Tags != nil {
+		post.Tags = *payload.Tags
+	}
+
+	// Gleiche Regeln wie beim Anlegen
+	check := CreatePostPayload{Title: post.Title, Content: post.Content, Tags: post.Tags}
+	if err := check.Validate(); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Posts.Update(r.Context(), post); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "post.update", "post", post.ID, &before, post)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.publishEvent(r.Context(), webhooks.PostUpdated, post)
+
+	if err := app.writeResponse(w, r, http.StatusOK, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPostHandler liefert einen einzelnen Post (GET /v1/posts/{postID})
+func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post, err := app.store.Posts.GetByID(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.attachReactions(r.Context(), []*store.Post{post}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// Reaktionen ändern UpdatedAt nicht, deshalb gehören sie mit in den ETag.
+	// Kein Last-Modified: eine entfernte Reaktion hinterlässt keinen Zeitstempel,
+	// If-Modified-Since würde sonst einen veralteten Stand mit 304 bestätigen.
+	setResourceETag(w, post.ID, post.UpdatedAt, post.Reactions)
+
+	if err := app.writeResponse(w, r, http.StatusOK, post); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listPostsHandler liefert den Feed inkl. Reaction-Counts (GET /v1/posts?limit=20&offset=0)
+func (app *application) listPostsHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	posts, err := app.store.Posts.List(r.Context(), p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	feed := make([]*store.Post, len(posts))
+	for i := range posts {
+		feed[i] = &posts[i]
+	}
+
+	if err := app.attachReactions(r.Context(), feed); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deletePostHandler löscht einen Post per Soft Delete (DELETE /v1/posts/{postID})
+func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		// Auch ausgeblendete Posts müssen sich löschen lassen
+		post, err := s.Posts.GetByIDIncludingHidden(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if err := s.Posts.Delete(r.Context(), id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "post.delete", "post", id, post, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.publishEvent(r.Context(), webhooks.PostDeleted, map[string]int64{"id": id})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// purgeJobType ist der Job, der Soft Deletes endgültig löscht
+const purgeJobType = "soft_delete.purge"
+
+// runPurgeJob reiht in festen Abständen einen Purge-Job in die Job Queue ein
+func (app *application) runPurgeJob(ctx context.Context) {
+	ticker := time.NewTicker(app.config.softDelete.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := app.jobs.Enqueue(ctx, purgeJobType, nil); err != nil {
+				log.Printf("enqueue purge job: %s", err)
+			}
+		}
+	}
+}
+
+// purgeDeleted löscht alle Soft Deletes endgültig, die älter als die
+// konfigurierte Retention sind, sowie abgelaufene Idempotency-Keys,
+// Reset-Tokens, Sessions und erledigte Jobs
+func (app *application) purgeDeleted(ctx context.Context, _ struct{}) error {
+	before := time.Now().Add(-app.config.softDelete.retention)
+
+	posts, err := app.store.Posts.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	users, err := app.store.Users.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	if posts > 0 || users > 0 {
+		log.Printf("purged %d posts and %d users deleted before %s", posts, users, before.Format(time.RFC3339))
+	}
+
+	// Abgelaufene Idempotency-Keys räumen wir gleich mit auf
+	if _, err := app.store.Idempotency.PurgeExpired(ctx); err != nil {
+		return err
+	}
+	if _, err := app.store.PasswordResets.PurgeExpired(ctx); err != nil {
+		return err
+	}
+	if _, err := app.store.Sessions.PurgeExpired(ctx); err != nil {
+		return err
+	}
+
+	// Erledigte Jobs bleiben so lange liegen wie Soft Deletes
+	if _, err := app.jobs.PurgeDone(ctx, before); err != nil {
+		return err
+	}
+
+	return nil
+}
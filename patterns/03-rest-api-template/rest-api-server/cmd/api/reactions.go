@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/timour/go-api/internal/store"
+)
+
+// addReactionHandler reagiert als eingeloggter User auf einen Post
+// PUT /v1/posts/{postID}/reactions/{type}
+func (app *application) addReactionHandler(w http.ResponseWriter, r *http.Request) {
+	app.changeReaction(w, r, "reaction.add", func(ctx context.Context, s store.Storage, userID, postID int64, reaction string) error {
+		return s.Reactions.Add(ctx, userID, postID, reaction)
+	})
+}
+
+// removeReactionHandler nimmt eine Reaktion zurück
+// DELETE /v1/posts/{postID}/reactions/{type}
+func (app *application) removeReactionHandler(w http.ResponseWriter, r *http.Request) {
+	app.changeReaction(w, r, "reaction.remove", func(ctx context.Context, s store.Storage, userID, postID int64, reaction string) error {
+		return s.Reactions.Remove(ctx, userID, postID, reaction)
+	})
+}
+
+// changeReaction validiert Post und Typ, führt change samt Audit Event aus und
+// antwortet mit den neuen Counts
+func (app *application) changeReaction(w http.ResponseWriter, r *http.Request, action string, change func(ctx context.Context, s store.Storage, userID, postID int64, reaction string) error) {
+	postID, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	reaction := chi.URLParam(r, "type")
+	if !store.IsValidReaction(reaction) {
+		app.badRequestResponse(w, r, fmt.Errorf("invalid reaction type %q", reaction))
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := app.store.Posts.GetByID(ctx, postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	user := getUserFromContext(r)
+	err = app.store.WithTx(ctx, func(s store.Storage) error {
+		if err := change(ctx, s, user.ID, post.ID, reaction); err != nil {
+			return err
+		}
+
+		state := map[string]string{"reaction": reaction}
+		if action == "reaction.remove" {
+			return app.recordAudit(r, s, action, "post", post.ID, state, nil)
+		}
+		return app.recordAudit(r, s, action, "post", post.ID, nil, state)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.attachReactions(ctx, []*store.Post{post}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, post.Reactions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// attachReactions lädt die Counts aller posts mit einer Query
+func (app *application) attachReactions(ctx context.Context, posts []*store.Post) error {
+	ids := make([]int64, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	counts, err := app.store.Reactions.CountsForPosts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range posts {
+		p.Reactions = counts[p.ID]
+		if p.Reactions == nil {
+			p.Reactions = store.ReactionCounts{}
+		}
+	}
+
+	return nil
+}
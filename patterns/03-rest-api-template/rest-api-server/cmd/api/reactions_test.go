@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestCreateToken(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	seedUser(t, app, "felix")
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"valid credentials", `{"email":"felix@example.com","password":"password123"}`, http.StatusCreated},
+		{"wrong password", `{"email":"felix@example.com","password":"nope"}`, http.StatusUnauthorized},
+		{"unknown email", `{"email":"nobody@example.com","password":"password123"}`, http.StatusUnauthorized},
+		{"missing password", `{"email":"felix@example.com"}`, http.StatusBadRequest},
+		{"unknown field", `{"email":"felix@example.com","password":"password123","admin":true}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/v1/authentication/token", strings.NewReader(tt.body))
+			rr := executeRequest(req, mux)
+
+			checkResponseCode(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestRevokedAndDeactivatedTokens(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	ctx := context.Background()
+
+	anna := seedUser(t, app, "anna")
+	post := seedPost(t, app, anna.ID, "Mine", "Content")
+
+	patch := func(auth string) int {
+		req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/v1/posts/%d", post.ID), strings.NewReader(`{"title":"New"}`))
+		req.Header.Set("Authorization", auth)
+		return executeRequest(req, mux).Code
+	}
+
+	oldToken := bearerToken(t, app, anna)
+	if err := app.store.Users.RevokeTokens(ctx, anna.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	checkResponseCode(t, http.StatusUnauthorized, patch(oldToken))
+
+	anna, _ = app.store.Users.GetByID(ctx, anna.ID)
+	checkResponseCode(t, http.StatusOK, patch(bearerToken(t, app, anna)))
+
+	if err := app.store.Users.SetActive(ctx, anna.ID, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	checkResponseCode(t, http.StatusUnauthorized, patch(bearerToken(t, app, anna)))
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/authentication/token",
+		strings.NewReader(`{"email":"anna@example.com","password":"password123"}`))
+	checkResponseCode(t, http.StatusForbidden, executeRequest(req, mux).Code)
+}
+
+func TestReactions(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+	post := seedPost(t, app, anna.ID, "React to me", "Content")
+	path := fmt.Sprintf("/v1/posts/%d/reactions", post.ID)
+
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		auth       string
+		wantCode   int
+		wantCounts store.ReactionCounts
+	}{
+		{"requires token", http.MethodPut, path + "/like", "", http.StatusUnauthorized, nil},
+		{"rejects invalid token", http.MethodPut, path + "/like", "Bearer invalid", http.StatusUnauthorized, nil},
+		{"anna likes", http.MethodPut, path + "/like", bearerToken(t, app, anna), http.StatusOK, store.ReactionCounts{"like": 1}},
+		{"like is idempotent", http.MethodPut, path + "/like", bearerToken(t, app, anna), http.StatusOK, store.ReactionCounts{"like": 1}},
+		{"ben likes", http.MethodPut, path + "/like", bearerToken(t, app, ben), http.StatusOK, store.ReactionCounts{"like": 2}},
+		{"ben loves", http.MethodPut, path + "/love", bearerToken(t, app, ben), http.StatusOK, store.ReactionCounts{"like": 2, "love": 1}},
+		{"invalid type", http.MethodPut, path + "/meh", bearerToken(t, app, ben), http.StatusBadRequest, nil},
+		{"unknown post", http.MethodPut, "/v1/posts/999/reactions/like", bearerToken(t, app, ben), http.StatusNotFound, nil},
+		{"anna unlikes", http.MethodDelete, path + "/like", bearerToken(t, app, anna), http.StatusOK, store.ReactionCounts{"like": 1, "love": 1}},
+		{"unlike twice", http.MethodDelete, path + "/like", bearerToken(t, app, anna), http.StatusNotFound, nil},
+	}
+
+	for _, step := range steps {
+		req, _ := http.NewRequest(step.method, step.path, nil)
+		if step.auth != "" {
+			req.Header.Set("Authorization", step.auth)
+		}
+
+		rr := executeRequest(req, mux)
+		if rr.Code != step.wantCode {
+			t.Fatalf("%s: expected response code %d, got %d", step.name, step.wantCode, rr.Code)
+		}
+
+		if step.wantCounts != nil {
+			var counts store.ReactionCounts
+			decodeData(t, rr, &counts)
+			if fmt.Sprint(counts) != fmt.Sprint(step.wantCounts) {
+				t.Fatalf("%s: expected counts %v, got %v", step.name, step.wantCounts, counts)
+			}
+		}
+	}
+
+	// Feed und Einzelansicht enthalten die Counts
+	for _, p := range []string{"/v1/posts", fmt.Sprintf("/v1/posts/%d", post.ID)} {
+		req, _ := http.NewRequest(http.MethodGet, p, nil)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		if !strings.Contains(rr.Body.String(), `"reactions":{"like":1,"love":1}`) {
+			t.Errorf("Expected reaction counts in %s, got %s", p, rr.Body.String())
+		}
+	}
+}
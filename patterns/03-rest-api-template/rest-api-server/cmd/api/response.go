@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/timour/go-api/internal/render"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// newFormats legt die Darstellungen fest, zwischen denen der Accept-Header
+// wählt. JSON bleibt der Default, CSV gibt es nur für Listen.
+func newFormats() *render.Registry {
+	return render.NewRegistry(
+		render.Format{Name: "json", MediaType: "application/json", Encode: render.JSON},
+		render.Format{Name: "msgpack", MediaType: "application/msgpack", Encode: render.MessagePack},
+		render.Format{Name: "msgpack", MediaType: "application/x-msgpack", Encode: render.MessagePack},
+		render.Format{Name: "csv", MediaType: "text/csv", ContentType: "text/csv; charset=utf-8", Encode: render.CSV, ListsOnly: true},
+	)
+}
+
+// writeResponse verpackt erfolgreiche Antworten im Format {"data": ...} in
+// der per Accept gewählten Darstellung. CSV-Listen kommen ohne Envelope.
+func (app *application) writeResponse(w http.ResponseWriter, r *http.Request, status int, data any) error {
+	format, ok := app.formats.Negotiate(r.Header.Get("Accept"), render.IsList(data))
+	if !ok {
+		app.notAcceptableResponse(w, r)
+		return nil
+	}
+
+	if format.ListsOnly {
+		return app.writeFormat(w, format, status, data)
+	}
+
+	type envelope struct {
+		Data any `json:"data"`
+	}
+
+	return app.writeFormat(w, format, status, &envelope{Data: data})
+}
+
+// writeDocument schreibt v ohne Envelope, z.B. GraphQL-Ergebnisse
+func (app *application) writeDocument(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	format, ok := app.formats.Negotiate(r.Header.Get("Accept"), false)
+	if !ok {
+		app.notAcceptableResponse(w, r)
+		return nil
+	}
+
+	return app.writeFormat(w, format, status, v)
+}
+
+// writeError schreibt eine Fehlermeldung im Format {"error": "..."}. Fehler
+// werden nie mit 406 beantwortet, ohne passendes Format gibt es JSON.
+func (app *application) writeError(w http.ResponseWriter, r *http.Request, status int, message string) error {
+	type envelope struct {
+		Error string `json:"error"`
+	}
+
+	format, ok := app.formats.Negotiate(r.Header.Get("Accept"), false)
+	if !ok {
+		format = app.formats.Default()
+	}
+
+	return app.writeFormat(w, format, status, &envelope{Error: message})
+}
+
+func (app *application) writeFormat(w http.ResponseWriter, format render.Format, status int, v any) error {
+	h := w.Header()
+	h.Set("Content-Type", format.Header())
+	h.Add("Vary", "Accept")
+
+	// Handler setzen ETags pro Ressource, jede Darstellung braucht aber ihren eigenen
+	if etag := h.Get("ETag"); etag != "" && format.Name != app.formats.Default().Name {
+		h.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+format.Name+`"`)
+	}
+
+	w.WriteHeader(status)
+	return format.Encode(w, v)
+}
+
+func (app *application) notAcceptableResponse(w http.ResponseWriter, r *http.Request) {
+	trace.Logf(r.Context(), "not acceptable: %s path: %s accept: %s", r.Method, r.URL.Path, r.Header.Get("Accept"))
+
+	app.writeError(w, r, http.StatusNotAcceptable,
+		fmt.Sprintf("acceptable types: %s", strings.Join(app.formats.MediaTypes(), ", ")))
+}
+
+// NegotiateMiddleware lehnt schreibende Requests ohne akzeptables Format ab,
+// bevor der Handler etwas ändert. Lesende Requests prüft erst writeResponse,
+// weil z.B. CSV nur für Listen geht.
+func (app *application) NegotiateMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !app.formats.Acceptable(r.Header.Get("Accept")) {
+			app.notAcceptableResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CompressMiddleware komprimiert Antworten mit brotli oder gzip, je nach
+// Accept-Encoding. Kleine Antworten und Bilder bleiben unkomprimiert.
+func (app *application) CompressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := render.Encoding(r.Header.Get("Accept-Encoding"), render.Encodings...)
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := render.NewCompressWriter(w, encoding, app.config.compression.minBytes)
+		defer func() {
+			if err := cw.Close(); err != nil {
+				trace.Logf(r.Context(), "compress response: %s", err)
+			}
+		}()
+
+		next.ServeHTTP(cw, r)
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	user := seedUser(t, app, "alice")
+	post := seedPost(t, app, user.ID, "Hello, CSV", "Content", "go")
+	seedPost(t, app, user.ID, "Second", "More")
+
+	get := func(path, accept string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		return executeRequest(req, mux).Result()
+	}
+
+	t.Run("json is the default", func(t *testing.T) {
+		res := get("/v1/posts", "")
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+		if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected application/json, got %q", ct)
+		}
+		if !strings.Contains(res.Header.Get("Vary"), "Accept") {
+			t.Errorf("Expected Vary: Accept, got %q", res.Header.Get("Vary"))
+		}
+	})
+
+	t.Run("csv for lists", func(t *testing.T) {
+		res := get("/v1/posts", "text/csv")
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+
+		records, err := csv.NewReader(res.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Expected valid CSV, got %v", err)
+		}
+		if len(records) != 3 || records[0][0] != "id" {
+			t.Fatalf("Expected a header and two rows, got %v", records)
+		}
+		if !strings.Contains(strings.Join(records[1], "|")+strings.Join(records[2], "|"), post.Title) {
+			t.Errorf("Expected %q in the rows, got %v", post.Title, records)
+		}
+	})
+
+	t.Run("csv for a single post is not acceptable", func(t *testing.T) {
+		res := get("/v1/posts/1", "text/csv")
+		checkResponseCode(t, http.StatusNotAcceptable, res.StatusCode)
+		if ct := res.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected the error as JSON, got %q", ct)
+		}
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		res := get("/v1/posts/1", "application/msgpack")
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+
+		var envelope struct {
+			Data struct {
+				ID    int64    `msgpack:"id"`
+				Title string   `msgpack:"title"`
+				Tags  []string `msgpack:"tags"`
+			} `msgpack:"data"`
+		}
+		if err := msgpack.NewDecoder(res.Body).Decode(&envelope); err != nil {
+			t.Fatalf("Expected a msgpack envelope, got %v", err)
+		}
+		if envelope.Data.ID != post.ID || envelope.Data.Title != post.Title || len(envelope.Data.Tags) != 1 {
+			t.Errorf("Expected post %d, got %+v", post.ID, envelope.Data)
+		}
+		if etag := res.Header.Get("ETag"); !strings.HasSuffix(etag, `-msgpack"`) {
+			t.Errorf("Expected a representation specific ETag, got %q", etag)
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		res := get("/v1/posts", "application/xml")
+		checkResponseCode(t, http.StatusNotAcceptable, res.StatusCode)
+	})
+
+	t.Run("writes are rejected before the handler runs", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/v1/posts/1", nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		req.Header.Set("Accept", "application/xml")
+		checkResponseCode(t, http.StatusNotAcceptable, executeRequest(req, mux).Code)
+
+		checkResponseCode(t, http.StatusOK, get("/v1/posts/1", "").StatusCode)
+	})
+}
+
+func TestCompression(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	user := seedUser(t, app, "alice")
+	for i := 0; i < 5; i++ {
+		seedPost(t, app, user.ID, "A post long enough to compress", strings.Repeat("content ", 20))
+	}
+
+	tests := []struct {
+		acceptEncoding string
+		want           string
+		reader         func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"gzip, br", "br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"identity", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/v1/posts", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rr := executeRequest(req, mux)
+			checkResponseCode(t, http.StatusOK, rr.Code)
+
+			if got := rr.Header().Get("Content-Encoding"); got != tt.want {
+				t.Fatalf("Expected Content-Encoding %q, got %q", tt.want, got)
+			}
+			if !strings.Contains(strings.Join(rr.Header().Values("Vary"), ","), "Accept-Encoding") {
+				t.Errorf("Expected Vary: Accept-Encoding, got %v", rr.Header().Values("Vary"))
+			}
+			if tt.reader == nil {
+				return
+			}
+
+			if etag := rr.Header().Get("ETag"); !strings.HasPrefix(etag, "W/") {
+				t.Errorf("Expected a weak ETag, got %q", etag)
+			}
+
+			body, err := tt.reader(rr.Body)
+			if err != nil {
+				t.Fatalf("Expected a %s body, got %v", tt.want, err)
+			}
+			var envelope struct {
+				Data []struct {
+					ID int64 `json:"id"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+				t.Fatalf("Expected a JSON envelope, got %v", err)
+			}
+			if len(envelope.Data) != 5 {
+				t.Errorf("Expected 5 posts, got %d", len(envelope.Data))
+			}
+		})
+	}
+
+	t.Run("small responses stay uncompressed", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/posts/999", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusNotFound, rr.Code)
+		if got := rr.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Expected no Content-Encoding, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// maxSearchQueryLength begrenzt die Länge des Suchbegriffs
+const maxSearchQueryLength = 200
+
+// searchResponse ist die Antwort von GET /v1/search/posts
+type searchResponse struct {
+	*store.SearchResult
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// searchPostsHandler durchsucht Titel und Content aller Posts
+// GET /v1/search/posts?q=golang&tag=backend&user_id=1&limit=20&offset=0
+func (app *application) searchPostsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	q := strings.TrimSpace(qs.Get("q"))
+	if q == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("query parameter q is required"))
+		return
+	}
+	if len(q) > maxSearchQueryLength {
+		app.badRequestResponse(w, r, fmt.Errorf("query parameter q must not exceed %d characters", maxSearchQueryLength))
+		return
+	}
+
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	filter := store.SearchFilter{
+		Tags:   qs["tag"],
+		Limit:  p.Limit,
+		Offset: p.Offset,
+	}
+
+	if userID := qs.Get("user_id"); userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil || id < 1 {
+			app.badRequestResponse(w, r, fmt.Errorf("invalid user_id"))
+			return
+		}
+		filter.UserID = id
+	}
+
+	result, err := app.store.Posts.Search(r.Context(), q, filter)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := searchResponse{SearchResult: result, Limit: p.Limit, Offset: p.Offset}
+	if err := app.writeResponse(w, r, http.StatusOK, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// listSessionsHandler listet die aktiven Sessions des eingeloggten Users, die
+// des anfragenden Tokens mit "current": true (GET /v1/sessions)
+func (app *application) listSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	sessions, err := app.store.Sessions.ListByUser(r.Context(), getUserFromContext(r).ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	current := getSessionIDFromContext(r)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, sessions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// revokeSessionHandler meldet eine eigene Session ab, z.B. ein verlorenes
+// Gerät (DELETE /v1/sessions/{sessionID})
+func (app *application) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "sessionID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	user := getUserFromContext(r)
+
+	err = app.store.WithTx(ctx, func(s store.Storage) error {
+		// Fremde Sessions sehen aus wie unbekannte
+		session, err := s.Sessions.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if session.UserID != user.ID {
+			return store.ErrNotFound
+		}
+
+		if err := s.Sessions.Revoke(ctx, id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "session.revoke", "session", id, nil, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listUserSessionsHandler listet die aktiven Sessions eines Users
+// (GET /v1/admin/users/{userID}/sessions)
+func (app *application) listUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	sessions, err := app.store.Sessions.ListByUser(r.Context(), id)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, sessions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// revokeUserSessionsHandler meldet einen User auf allen Geräten ab
+// (DELETE /v1/admin/users/{userID}/sessions)
+func (app *application) revokeUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	var revoked int64
+	err = app.store.WithTx(ctx, func(s store.Storage) error {
+		if _, err := s.Users.GetByID(ctx, id); err != nil {
+			return err
+		}
+
+		n, err := s.Sessions.RevokeAll(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		revoked = n
+		return app.recordAudit(r, s, "user.sessions_revoke", "user", id, nil, map[string]int64{"sessions": revoked})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, map[string]int64{"revoked": revoked}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
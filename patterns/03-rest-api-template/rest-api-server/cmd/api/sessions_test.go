@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	anna := seedUser(t, app, "anna")
+
+	first := login(t, mux, anna.Email)
+
+	rr := postRefresh(mux, "/v1/authentication/refresh", first.RefreshToken)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+	var second authTokens
+	decodeData(t, rr, &second)
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == "" {
+		t.Fatalf("Expected a new token pair, got %+v", second)
+	}
+	checkResponseCode(t, http.StatusOK, listSessions(mux, second.AccessToken).Code)
+
+	// Der alte Refresh Token wird noch einmal benutzt: die ganze Familie ist tot
+	checkResponseCode(t, http.StatusUnauthorized, postRefresh(mux, "/v1/authentication/refresh", first.RefreshToken).Code)
+	checkResponseCode(t, http.StatusUnauthorized, postRefresh(mux, "/v1/authentication/refresh", second.RefreshToken).Code)
+	checkResponseCode(t, http.StatusUnauthorized, listSessions(mux, second.AccessToken).Code)
+
+	events, _ := app.store.Audit.List(context.Background(), store.AuditFilter{TargetType: "session", Limit: 10})
+	if len(events) != 1 || events[0].Action != "session.reuse_detected" || events[0].ActorID != fmt.Sprint(anna.ID) {
+		t.Errorf("Expected the reuse in the audit log, got %+v", events)
+	}
+
+	t.Run("deactivated user", func(t *testing.T) {
+		tokens := login(t, mux, anna.Email)
+		if err := app.store.Users.SetActive(context.Background(), anna.ID, false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		checkResponseCode(t, http.StatusUnauthorized, postRefresh(mux, "/v1/authentication/refresh", tokens.RefreshToken).Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		checkResponseCode(t, http.StatusUnauthorized, postRefresh(mux, "/v1/authentication/refresh", "nope").Code)
+		checkResponseCode(t, http.StatusBadRequest, postRefresh(mux, "/v1/authentication/refresh", "").Code)
+	})
+}
+
+func TestSessions(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+
+	laptop := login(t, mux, anna.Email)
+	phone := login(t, mux, anna.Email)
+	bens := login(t, mux, ben.Email)
+
+	rr := listSessions(mux, laptop.AccessToken)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+	var sessions []store.Session
+	decodeData(t, rr, &sessions)
+	if len(sessions) != 2 || sessions[0].Current || !sessions[1].Current || sessions[0].UserAgent != "go-test" {
+		t.Fatalf("Expected two sessions with the laptop as current, got %+v", sessions)
+	}
+	phoneID := sessions[0].ID
+
+	revoke := func(token string, id int64) int {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/sessions/%d", id), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("revoke own session", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNotFound, revoke(bens.AccessToken, phoneID))
+		checkResponseCode(t, http.StatusNoContent, revoke(laptop.AccessToken, phoneID))
+
+		checkResponseCode(t, http.StatusUnauthorized, listSessions(mux, phone.AccessToken).Code)
+		checkResponseCode(t, http.StatusUnauthorized, postRefresh(mux, "/v1/authentication/refresh", phone.RefreshToken).Code)
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNoContent, postRefresh(mux, "/v1/authentication/logout", laptop.RefreshToken).Code)
+		checkResponseCode(t, http.StatusNoContent, postRefresh(mux, "/v1/authentication/logout", laptop.RefreshToken).Code)
+		checkResponseCode(t, http.StatusUnauthorized, listSessions(mux, laptop.AccessToken).Code)
+	})
+
+	t.Run("admin revokes all", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/admin/users/%d/sessions", ben.ID), nil)
+		req.SetBasicAuth(testAdminUser, testAdminPass)
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var result map[string]int64
+		decodeData(t, rr, &result)
+		if result["revoked"] != 1 {
+			t.Errorf("Expected one revoked session, got %v", result)
+		}
+		checkResponseCode(t, http.StatusUnauthorized, listSessions(mux, bens.AccessToken).Code)
+	})
+}
+
+// login meldet sich mit dem Test-Passwort an und liefert das Token-Paar
+func login(t *testing.T, mux http.Handler, email string) authTokens {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"email":%q,"password":%q}`, email, testPassword)
+	req, _ := http.NewRequest(http.MethodPost, "/v1/authentication/token", strings.NewReader(body))
+	req.Header.Set("User-Agent", "go-test")
+	rr := executeRequest(req, mux)
+	checkResponseCode(t, http.StatusCreated, rr.Code)
+
+	var tokens authTokens
+	decodeData(t, rr, &tokens)
+	return tokens
+}
+
+func postRefresh(mux http.Handler, path, refreshToken string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"refresh_token":%q}`, refreshToken)
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	return executeRequest(req, mux)
+}
+
+func listSessions(mux http.Handler, accessToken string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, "/v1/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return executeRequest(req, mux)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/stream"
+)
+
+// streamRetry sagt dem Browser, nach wie vielen Millisekunden er sich neu verbinden soll
+const streamRetry = 3000
+
+// postFilter entscheidet, ob ein neuer Post an einen Stream-Client geht
+type postFilter struct {
+	tags   []string // alle Tags müssen vorkommen
+	userID int64    // 0 = alle Autoren
+}
+
+func (f postFilter) match(post store.Post) bool {
+	if f.userID != 0 && post.UserID != f.userID {
+		return false
+	}
+
+	for _, want := range f.tags {
+		found := false
+		for _, tag := range post.Tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// streamPostsHandler schickt neue Posts als Server-Sent Events
+// GET /v1/posts/stream?tag=go&user_id=1 (Header Last-Event-ID für Resume)
+func (app *application) streamPostsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	filter := postFilter{tags: qs["tag"]}
+
+	if userID := qs.Get("user_id"); userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil || id < 1 {
+			app.badRequestResponse(w, r, fmt.Errorf("invalid user_id"))
+			return
+		}
+		filter.userID = id
+	}
+
+	var lastID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			app.badRequestResponse(w, r, fmt.Errorf("invalid Last-Event-ID"))
+			return
+		}
+		lastID = id
+	}
+
+	// Der Stream läuft länger als der WriteTimeout des Servers
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	sub, replay := app.postStream.Subscribe(lastID, filter.match)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx soll nicht puffern
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	for _, msg := range replay {
+		if err := writePostEvent(w, msg); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(app.config.stream.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			// Zu langsam oder Server fährt herunter: Client verbindet sich neu
+			// und holt verpasste Posts per Last-Event-ID nach
+			if !ok {
+				return
+			}
+			if err := writePostEvent(w, msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			// Kommentarzeile hält Proxies und Load Balancer davon ab, die Verbindung zu schließen
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writePostEvent schreibt ein Event im SSE-Format
+func writePostEvent(w http.ResponseWriter, msg stream.Message[store.Post]) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: post\ndata: %s\n\n", msg.ID, data)
+	return err
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// sseEvent ist ein geparstes Server-Sent Event
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+	retry string
+}
+
+// openStream verbindet sich mit dem Stream und wartet auf die einleitende retry-Zeile,
+// danach ist die Subscription sicher registriert
+func openStream(t *testing.T, ctx context.Context, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected stream to open, got %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+
+	checkResponseCode(t, http.StatusOK, res.StatusCode)
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+
+	body := bufio.NewReader(res.Body)
+	if event := readEvent(t, body); event.retry == "" {
+		t.Fatalf("Expected retry preamble, got %+v", event)
+	}
+
+	return body
+}
+
+// readEvent liest bis zur nächsten Leerzeile
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected event, got %v", err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return event
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			event.data = value
+		case "retry":
+			event.retry = value
+		}
+	}
+}
+
+func TestStreamPosts(t *testing.T) {
+	app := newTestApplication(t)
+	server := httptest.NewServer(app.mount())
+	defer server.Close()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	goPosts := openStream(t, ctx, server.URL+"/v1/posts/stream?tag=go", "")
+	benPosts := openStream(t, ctx, server.URL+"/v1/posts/stream?user_id="+strconv.FormatInt(ben.ID, 10), "")
+
+	mux := app.mount()
+	createPost := func(user *store.User, body string) {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(body))
+		req.Header.Set("Authorization", bearerToken(t, app, user))
+		checkResponseCode(t, http.StatusCreated, executeRequest(req, mux).Code)
+	}
+
+	createPost(anna, `{"title":"Rust","content":"Not go","tags":["rust"]}`)
+	createPost(anna, `{"title":"Go","content":"Channels","tags":["go"]}`)
+	createPost(ben, `{"title":"Ben","content":"Hello","tags":["go"]}`)
+
+	for _, want := range []string{"Go", "Ben"} {
+		event := readEvent(t, goPosts)
+
+		var post store.Post
+		if err := json.Unmarshal([]byte(event.data), &post); err != nil {
+			t.Fatalf("Expected post JSON, got %q", event.data)
+		}
+		if event.event != "post" || post.Title != want {
+			t.Errorf("Expected post %q on tag stream, got %+v", want, event)
+		}
+	}
+
+	if event := readEvent(t, benPosts); !strings.Contains(event.data, `"title":"Ben"`) || event.id != "3" {
+		t.Errorf("Expected only Ben's post with id 3 on author stream, got %+v", event)
+	}
+
+	// Reconnect mit Last-Event-ID holt verpasste Posts aus dem Replay-Puffer
+	resumed := openStream(t, ctx, server.URL+"/v1/posts/stream", "1")
+	for _, wantID := range []string{"2", "3"} {
+		if event := readEvent(t, resumed); event.id != wantID {
+			t.Errorf("Expected replayed event %s, got %+v", wantID, event)
+		}
+	}
+}
+
+func TestStreamPostsValidation(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/posts/stream?user_id=abc", nil)
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(req, mux).Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/v1/posts/stream", nil)
+	req.Header.Set("Last-Event-ID", "x")
+	checkResponseCode(t, http.StatusBadRequest, executeRequest(req, mux).Code)
+}
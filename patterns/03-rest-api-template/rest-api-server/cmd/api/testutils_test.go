@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/auth"
+	"github.com/timour/go-api/internal/blob"
+	"github.com/timour/go-api/internal/jobs"
+	"github.com/timour/go-api/internal/mailer"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/stream"
+	"github.com/timour/go-api/internal/trace"
+	"github.com/timour/go-api/internal/webhooks"
+)
+
+// Zugangsdaten für die Basic-Auth geschützten Routen in Tests
+const (
+	testAdminUser = "admin"
+	testAdminPass = "secret"
+	testPassword  = "password123"
+)
+
+// newTestApplication baut eine application mit In-Memory Store
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	storage := store.NewMemoryStorage()
+
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error creating blob store, got %v", err)
+	}
+
+	app := &application{
+		config: config{
+			addr: ":0",
+			auth: authConfig{
+				basic: basicConfig{user: testAdminUser, pass: testAdminPass},
+				token: tokenConfig{secret: "test-secret", exp: time.Hour, refreshExp: 24 * time.Hour, iss: "test"},
+			},
+			idempotency: idempotencyConfig{ttl: time.Hour},
+			webhooks:    webhooksConfig{timeout: 5 * time.Second, maxFailures: 3},
+			stream:      streamConfig{replaySize: 10, bufferSize: 10, heartbeat: time.Minute},
+			bulk:        bulkConfig{batchSize: 2, maxImportBytes: 64 << 10},
+			graphql:     graphqlConfig{maxDepth: 5, maxComplexity: 500},
+			moderation:  moderationConfig{autoHideThreshold: 2},
+			apiKeys:     apiKeysConfig{defaultTTL: time.Hour},
+			compression: compressionConfig{minBytes: 64},
+			passwordReset: passwordResetConfig{
+				ttl: time.Hour,
+				url: "http://localhost/reset-password?token=",
+			},
+			uploads: uploadsConfig{
+				maxBytes:       64 << 10,
+				avatarMaxBytes: 16 << 10,
+				maxDimension:   64,
+				urlTTL:         time.Minute,
+			},
+		},
+		store:         storage,
+		authenticator: auth.NewJWTAuthenticator("test-secret", "test", "test"),
+		jobs:          jobs.NewQueue(jobs.NewMemoryBackend(100), jobs.Config{Workers: 1}),
+		webhooks:      webhooks.NewDispatcher(storage.Webhooks, 5*time.Second, 3),
+		postStream:    stream.NewBroadcaster[store.Post](10, 10),
+		blobs:         blobs,
+		blobSigner:    blob.NewSigner("test-url-secret"),
+		tracer:        trace.NewRecorder(nil, 10, time.Second),
+		mailer:        mailer.LogMailer{},
+		formats:       newFormats(),
+	}
+	app.registerJobs()
+
+	return app
+}
+
+// executeRequest schickt req durch den kompletten Router
+func executeRequest(req *http.Request, mux http.Handler) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+func checkResponseCode(t *testing.T, expected, actual int) {
+	t.Helper()
+
+	if expected != actual {
+		t.Errorf("Expected response code %d, got %d", expected, actual)
+	}
+}
+
+// decodeData entpackt eine {"data": ...} Antwort in v
+func decodeData(t *testing.T, rr *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: v}
+
+	if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+		t.Fatalf("Expected JSON data envelope, got %q: %v", rr.Body.String(), err)
+	}
+}
+
+// decodeError liest die Meldung aus einer {"error": ...} Antwort
+func decodeError(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var envelope struct {
+		Error string `json:"error"`
+	}
+
+	if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+		t.Fatalf("Expected JSON error envelope, got %q: %v", rr.Body.String(), err)
+	}
+
+	return envelope.Error
+}
+
+// seedUser legt einen User direkt im Store an
+func seedUser(t *testing.T, app *application, username string) *store.User {
+	t.Helper()
+
+	user := &store.User{Username: username, Email: username + "@example.com"}
+	if err := user.SetPassword(testPassword); err != nil {
+		t.Fatalf("Expected no error hashing password, got %v", err)
+	}
+	if err := app.store.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error creating user, got %v", err)
+	}
+
+	return user
+}
+
+// seedPost legt einen Post direkt im Store an
+func seedPost(t *testing.T, app *application, userID int64, title, content string, tags ...string) *store.Post {
+	t.Helper()
+
+	post := &store.Post{Title: title, Content: content, UserID: userID, Tags: tags}
+	if err := app.store.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("Expected no error creating post, got %v", err)
+	}
+
+	return post
+}
+
+// bearerToken erstellt ein gültiges Access Token für user
+func bearerToken(t *testing.T, app *application, user *store.User) string {
+	t.Helper()
+
+	token, err := app.generateAccessToken(user, 0)
+	if err != nil {
+		t.Fatalf("Expected no error generating token, got %v", err)
+	}
+
+	return "Bearer " + token
+}
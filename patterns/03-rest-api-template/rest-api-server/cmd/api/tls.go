@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/timour/go-api/internal/certs"
+)
+
+// loadCertificates lädt TLS_CERT_FILE/TLS_KEY_FILE. Ohne beide läuft der Server
+// mit HTTP (nil). In der Entwicklung werden fehlende Dateien selbstsigniert erzeugt.
+func (app *application) loadCertificates() (*certs.Reloader, error) {
+	cfg := app.config.tls
+	if cfg.certFile == "" && cfg.keyFile == "" {
+		return nil, nil
+	}
+	if cfg.certFile == "" || cfg.keyFile == "" {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if app.config.env == "development" && !fileExists(cfg.certFile) && !fileExists(cfg.keyFile) {
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if err := certs.GenerateSelfSigned(cfg.certFile, cfg.keyFile, hosts, cfg.devCertValidity); err != nil {
+			return nil, fmt.Errorf("generate development certificate: %w", err)
+		}
+		log.Printf("generated self-signed development certificate %s for %v", cfg.certFile, hosts)
+	}
+
+	return certs.NewReloader(cfg.certFile, cfg.keyFile)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// reloadCertificatesOnSIGHUP lädt die Zertifikate bei jedem SIGHUP neu,
+// z.B. nachdem certbot sie erneuert hat (kill -HUP <pid>)
+func (app *application) reloadCertificatesOnSIGHUP(ctx context.Context, reloader *certs.Reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := reloader.Reload(); err != nil {
+				log.Printf("certificate reload failed, keeping the current one: %s", err)
+				continue
+			}
+			log.Printf("certificate reloaded, valid until %s", reloader.NotAfter().Format(time.RFC3339))
+		}
+	}
+}
+
+// redirectToHTTPS leitet alle Requests auf dieselbe URL per HTTPS um. httpsAddr
+// ist die TLS-Adresse (ADDR); Port 443 taucht in der URL nicht auf.
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if net.ParseIP(host) != nil && net.ParseIP(host).To4() == nil {
+			host = "[" + host + "]"
+		}
+
+		// 308 statt 301, damit auch POST & Co. ihre Methode und den Body behalten
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
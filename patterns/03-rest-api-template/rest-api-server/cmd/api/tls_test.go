@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		host      string
+		target    string
+		want      string
+	}{
+		{"keeps path and query", ":8443", "example.com", "/v1/posts?limit=5", "https://example.com:8443/v1/posts?limit=5"},
+		{"drops the http port", ":8443", "localhost:8080", "/v1/health", "https://localhost:8443/v1/health"},
+		{"default https port", ":443", "example.com:80", "/", "https://example.com/"},
+		{"ipv6 host", ":443", "[::1]:8080", "/", "https://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, tt.target, nil)
+			req.Host = tt.host
+			rr := executeRequest(req, redirectToHTTPS(tt.httpsAddr))
+
+			checkResponseCode(t, http.StatusPermanentRedirect, rr.Code)
+			if got := rr.Header().Get("Location"); got != tt.want {
+				t.Errorf("Expected Location %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // Decoder für image.DecodeConfig
+	_ "image/jpeg" // Decoder für image.DecodeConfig
+	_ "image/png"  // Decoder für image.DecodeConfig
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/timour/go-api/internal/blob"
+	"github.com/timour/go-api/internal/store"
+)
+
+// Erlaubte Content-Types (per Sniffing ermittelt, nicht aus dem Request) und ihre Dateiendung
+var (
+	imageTypes = map[string]string{
+		"image/png":  ".png",
+		"image/jpeg": ".jpg",
+		"image/gif":  ".gif",
+	}
+	attachmentTypes = map[string]string{
+		"image/png":       ".png",
+		"image/jpeg":      ".jpg",
+		"image/gif":       ".gif",
+		"application/pdf": ".pdf",
+	}
+)
+
+var (
+	errFileTooLarge    = errors.New("file is too large")
+	errUnsupportedType = errors.New("unsupported file type")
+	errMissingFile     = errors.New(`multipart field "file" is required`)
+)
+
+// upload ist eine geprüfte Datei aus einem multipart/form-data Request
+type upload struct {
+	filename    string
+	contentType string
+	ext         string
+	data        []byte
+	width       int
+	height      int
+}
+
+// readUpload liest das Feld "file", prüft Größe und Content-Type und bei
+// Bildern die Abmessungen. Fehler sind errFileTooLarge, errUnsupportedType
+// oder ein Validierungsfehler (400/422).
+func (app *application) readUpload(w http.ResponseWriter, r *http.Request, maxBytes int64, allowed map[string]string) (*upload, error) {
+	// Etwas Luft für die multipart Header und Boundaries
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, errFileTooLarge
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, errMissingFile
+			}
+			return nil, err
+		}
+
+		if part.FormName() != "file" {
+			continue
+		}
+
+		// Ein Byte mehr lesen, um zu erkennen, ob die Datei das Limit überschreitet
+		data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, errFileTooLarge
+			}
+			return nil, err
+		}
+		if int64(len(data)) > maxBytes {
+			return nil, errFileTooLarge
+		}
+		if len(data) == 0 {
+			return nil, errMissingFile
+		}
+
+		return app.checkUpload(part.FileName(), data, allowed)
+	}
+}
+
+func (app *application) checkUpload(filename string, data []byte, allowed map[string]string) (*upload, error) {
+	u := &upload{
+		contentType: http.DetectContentType(data),
+		data:        data,
+	}
+
+	ext, ok := allowed[u.contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedType, u.contentType)
+	}
+	u.ext = ext
+
+	u.filename = filepath.Base(filepath.Clean("/" + filename))
+	if u.filename == "/" || u.filename == "." {
+		u.filename = "file" + ext
+	}
+	if len(u.filename) > 255 {
+		u.filename = u.filename[:255]
+	}
+
+	if _, isImage := imageTypes[u.contentType]; isImage {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+
+		limit := app.config.uploads.maxDimension
+		if cfg.Width > limit || cfg.Height > limit {
+			return nil, fmt.Errorf("image must not exceed %dx%d pixels, got %dx%d", limit, limit, cfg.Width, cfg.Height)
+		}
+		u.width, u.height = cfg.Width, cfg.Height
+	}
+
+	return u, nil
+}
+
+// uploadErrorResponse bildet die Fehler von readUpload auf Status-Codes ab
+func (app *application) uploadErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errFileTooLarge):
+		app.payloadTooLargeResponse(w, r, err)
+	case errors.Is(err, errUnsupportedType):
+		app.unsupportedMediaTypeResponse(w, r, err)
+	case errors.Is(err, errMissingFile), errors.Is(err, http.ErrNotMultipart):
+		app.badRequestResponse(w, r, err)
+	default:
+		app.unprocessableEntityResponse(w, r, err)
+	}
+}
+
+// newBlobKey erzeugt einen zufälligen Key wie "attachments/12/3f9c….png"
+func newBlobKey(prefix string, id int64, ext string) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return fmt.Sprintf("%s/%d/%s%s", prefix, id, hex.EncodeToString(b), ext)
+}
+
+// signedURL liefert eine ablaufende Download-URL für key. Die Ablaufzeit wird auf
+// volle TTL-Fenster gerundet, damit die URL (und damit ETags) eine Weile stabil
+// bleibt; gültig ist sie mindestens eine und höchstens zwei TTLs.
+func (app *application) signedURL(key string) string {
+	ttl := app.config.uploads.urlTTL
+	expires := time.Now().Truncate(ttl).Add(2 * ttl)
+
+	qs := url.Values{}
+	qs.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	qs.Set("signature", app.blobSigner.Sign(key, expires))
+
+	return "/v1/files/" + key + "?" + qs.Encode()
+}
+
+// uploadAttachmentHandler hängt eine Datei an einen eigenen Post
+// POST /v1/posts/{postID}/attachments (multipart/form-data, Feld "file")
+func (app *application) uploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	post, err := app.store.Posts.GetByID(r.Context(), postID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if post.UserID != getUserFromContext(r).ID {
+		app.forbiddenResponse(w, r, fmt.Errorf("post %d belongs to another user", postID))
+		return
+	}
+
+	u, err := app.readUpload(w, r, app.config.uploads.maxBytes, attachmentTypes)
+	if err != nil {
+		app.uploadErrorResponse(w, r, err)
+		return
+	}
+
+	attachment := &store.Attachment{
+		PostID:      postID,
+		Key:         newBlobKey("attachments", postID, u.ext),
+		Filename:    u.filename,
+		ContentType: u.contentType,
+		Size:        int64(len(u.data)),
+		Width:       u.width,
+		Height:      u.height,
+	}
+
+	if err := app.blobs.Put(r.Context(), attachment.Key, bytes.NewReader(u.data)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Attachments.Create(r.Context(), attachment); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "attachment.create", "attachment", attachment.ID, nil, attachment)
+	})
+	if err != nil {
+		// Ohne Row findet niemand den Blob mehr
+		_ = app.blobs.Delete(r.Context(), attachment.Key)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	attachment.URL = app.signedURL(attachment.Key)
+
+	if err := app.writeResponse(w, r, http.StatusCreated, attachment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listAttachmentsHandler liefert die Attachments eines Posts mit signierten URLs
+// GET /v1/posts/{postID}/attachments
+func (app *application) listAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := readIDParam(r, "postID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if _, err := app.store.Posts.GetByID(r.Context(), postID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	attachments, err := app.store.Attachments.ListByPost(r.Context(), postID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for i := range attachments {
+		attachments[i].URL = app.signedURL(attachments[i].Key)
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, attachments); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// uploadAvatarHandler setzt den Avatar des eingeloggten Users (PUT /v1/users/{userID}/avatar)
+func (app *application) uploadAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if user.ID != userID {
+		app.forbiddenResponse(w, r, fmt.Errorf("user %d cannot change the avatar of user %d", user.ID, userID))
+		return
+	}
+
+	u, err := app.readUpload(w, r, app.config.uploads.avatarMaxBytes, imageTypes)
+	if err != nil {
+		app.uploadErrorResponse(w, r, err)
+		return
+	}
+
+	key := newBlobKey("avatars", userID, u.ext)
+	if err := app.blobs.Put(r.Context(), key, bytes.NewReader(u.data)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Users.SetAvatar(r.Context(), userID, key); err != nil {
+			return err
+		}
+		// AvatarKey ist json:"-", deshalb als eigenes Feld
+		before := map[string]string{"avatar_key": user.AvatarKey}
+		return app.recordAudit(r, s, "user.avatar", "user", userID, before, map[string]string{"avatar_key": key})
+	})
+	if err != nil {
+		_ = app.blobs.Delete(r.Context(), key)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// Der alte Avatar wird nicht mehr referenziert
+	if user.AvatarKey != "" {
+		_ = app.blobs.Delete(r.Context(), user.AvatarKey)
+	}
+
+	user.AvatarKey = key
+	user.AvatarURL = app.signedURL(key)
+
+	if err := app.writeResponse(w, r, http.StatusOK, user); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// serveFileHandler liefert einen Blob aus, wenn die URL gültig signiert und
+// noch nicht abgelaufen ist (GET /v1/files/*?expires=…&signature=…)
+func (app *application) serveFileHandler(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	qs := r.URL.Query()
+
+	if !blob.ValidKey(key) || !app.blobSigner.Verify(key, qs.Get("expires"), qs.Get("signature"), time.Now()) {
+		app.forbiddenResponse(w, r, fmt.Errorf("invalid or expired signature for %q", key))
+		return
+	}
+
+	f, err := app.blobs.Open(r.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	defer f.Close()
+
+	// Blobs ändern sich nie, die URL ist aber nur begrenzt gültig
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(app.config.uploads.urlTTL.Seconds())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// ServeContent leitet den Content-Type aus der Endung ab und unterstützt Range Requests
+	http.ServeContent(w, r, filepath.Base(key), time.Time{}, f)
+}
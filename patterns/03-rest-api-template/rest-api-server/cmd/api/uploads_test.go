@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// pngImage erzeugt ein PNG mit den gewünschten Abmessungen
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Expected no error encoding png, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newUploadRequest baut einen multipart Request mit dem Feld "file"
+func newUploadRequest(t *testing.T, method, path, filename string, data []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUploadAttachment(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+	post := seedPost(t, app, anna.ID, "With files", "Content")
+	path := fmt.Sprintf("/v1/posts/%d/attachments", post.ID)
+
+	tests := []struct {
+		name     string
+		user     *store.User
+		filename string
+		data     []byte
+		wantCode int
+	}{
+		{"png", anna, "photo.png", pngImage(t, 32, 16), http.StatusCreated},
+		{"pdf", anna, "paper.pdf", []byte("%PDF-1.4\n%fake pdf"), http.StatusCreated},
+		{"not the author", ben, "photo.png", pngImage(t, 8, 8), http.StatusForbidden},
+		{"sniffed, not trusted by name", anna, "script.png", []byte("<html><script>alert(1)</script></html>"), http.StatusUnsupportedMediaType},
+		{"too large", anna, "big.pdf", append([]byte("%PDF-1.4\n"), make([]byte, 64<<10)...), http.StatusRequestEntityTooLarge},
+		{"image too wide", anna, "wide.png", pngImage(t, 65, 10), http.StatusUnprocessableEntity},
+		{"corrupt image", anna, "broken.png", pngImage(t, 8, 8)[:30], http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newUploadRequest(t, http.MethodPost, path, tt.filename, tt.data)
+			req.Header.Set("Authorization", bearerToken(t, app, tt.user))
+			rr := executeRequest(req, mux)
+
+			checkResponseCode(t, tt.wantCode, rr.Code)
+		})
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	rr := executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var attachments []store.Attachment
+	decodeData(t, rr, &attachments)
+	if len(attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(attachments))
+	}
+
+	photo := attachments[0]
+	if photo.ContentType != "image/png" || photo.Width != 32 || photo.Height != 16 || photo.Filename != "photo.png" {
+		t.Errorf("Expected png metadata, got %+v", photo)
+	}
+
+	// Signierte URL liefert die Datei aus
+	req, _ = http.NewRequest(http.MethodGet, photo.URL, nil)
+	rr = executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+	if ct := rr.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Expected image/png, got %q", ct)
+	}
+	if !bytes.Equal(rr.Body.Bytes(), pngImage(t, 32, 16)) {
+		t.Error("Expected downloaded file to match the upload")
+	}
+
+	// Manipulierte oder abgelaufene URLs werden abgelehnt
+	photoPath, photoQuery, _ := strings.Cut(photo.URL, "?")
+	pdfPath, _, _ := strings.Cut(attachments[1].URL, "?")
+	expired := time.Unix(1, 0)
+
+	for _, url := range []string{
+		photoPath,
+		photoPath + "?" + strings.Replace(photoQuery, "signature=", "signature=00", 1),
+		pdfPath + "?" + photoQuery,
+		fmt.Sprintf("%s?expires=%d&signature=%s", photoPath, expired.Unix(),
+			app.blobSigner.Sign(strings.TrimPrefix(photoPath, "/v1/files/"), expired)),
+	} {
+		req, _ = http.NewRequest(http.MethodGet, url, nil)
+		checkResponseCode(t, http.StatusForbidden, executeRequest(req, mux).Code)
+	}
+}
+
+func TestUploadAvatar(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+	path := fmt.Sprintf("/v1/users/%d/avatar", anna.ID)
+
+	req := newUploadRequest(t, http.MethodPut, path, "me.png", pngImage(t, 16, 16))
+	req.Header.Set("Authorization", bearerToken(t, app, ben))
+	checkResponseCode(t, http.StatusForbidden, executeRequest(req, mux).Code)
+
+	req = newUploadRequest(t, http.MethodPut, path, "me.pdf", []byte("%PDF-1.4\n"))
+	req.Header.Set("Authorization", bearerToken(t, app, anna))
+	checkResponseCode(t, http.StatusUnsupportedMediaType, executeRequest(req, mux).Code)
+
+	req = newUploadRequest(t, http.MethodPut, path, "me.png", pngImage(t, 16, 16))
+	req.Header.Set("Authorization", bearerToken(t, app, anna))
+	checkResponseCode(t, http.StatusOK, executeRequest(req, mux).Code)
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/users/%d", anna.ID), nil)
+	rr := executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var user store.User
+	decodeData(t, rr, &user)
+	if !strings.HasPrefix(user.AvatarURL, fmt.Sprintf("/v1/files/avatars/%d/", anna.ID)) {
+		t.Fatalf("Expected signed avatar URL, got %q", user.AvatarURL)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, user.AvatarURL, nil)
+	checkResponseCode(t, http.StatusOK, executeRequest(req, mux).Code)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/webhooks"
+)
+
+// getUserHandler liefert einen einzelnen User (GET /v1/users/{userID})
+func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user, err := app.store.Users.GetByID(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if user.AvatarKey != "" {
+		user.AvatarURL = app.signedURL(user.AvatarKey)
+	}
+
+	// Die signierte URL wechselt mit jedem TTL-Fenster und gehört deshalb in den ETag,
+	// Rolle und Aktiv-Flag ändern sich ohne Zeitstempel
+	setResourceETag(w, user.ID, user.Username, user.Email, user.Created, user.AvatarURL, user.Role, user.IsActive)
+
+	if err := app.writeResponse(w, r, http.StatusOK, user); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteUserHandler löscht einen User per Soft Delete (DELETE /v1/users/{userID})
+func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "userID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		user, err := s.Users.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if err := s.Users.Delete(r.Context(), id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "user.delete", "user", id, user, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.publishEvent(r.Context(), webhooks.UserDeleted, map[string]int64{"id": id})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/timour/go-api/internal/jobs"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+	"github.com/timour/go-api/internal/webhooks"
+)
+
+// webhookJobType stellt ein Event an genau einen Webhook zu. Retries mit
+// Backoff und die Dead-Letter-Liste kommen von der Job Queue.
+const webhookJobType = "webhook.deliver"
+
+// webhookJob ist der Payload eines Zustell-Jobs. Body ist das fertig
+// serialisierte Event, damit Retries und Replays byte-gleich signiert werden.
+type webhookJob struct {
+	WebhookID int64           `json:"webhook_id"`
+	EventID   string          `json:"event_id"`
+	Event     string          `json:"event"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// publishEvent reiht für jeden aktiven Webhook, der eventType abonniert hat,
+// einen Zustell-Job ein. Fehler werden nur geloggt: der eigentliche Request
+// war bereits erfolgreich und soll nicht an Integratoren scheitern.
+func (app *application) publishEvent(ctx context.Context, eventType string, data any) {
+	hooks, err := app.store.Webhooks.ListActiveForEvent(ctx, eventType)
+	if err != nil {
+		trace.Logf(ctx, "webhooks: list subscribers for %s: %s", eventType, err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	event, err := webhooks.NewEvent(eventType, data)
+	if err != nil {
+		trace.Logf(ctx, "webhooks: build %s event: %s", eventType, err)
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		trace.Logf(ctx, "webhooks: encode %s event: %s", eventType, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		job := webhookJob{WebhookID: hook.ID, EventID: event.ID, Event: eventType, Body: body}
+		if err := app.jobs.Enqueue(ctx, webhookJobType, job); err != nil {
+			trace.Logf(ctx, "webhooks: enqueue %s for webhook %d: %s", eventType, hook.ID, err)
+		}
+	}
+}
+
+// deliverWebhook ist der Job Handler für webhookJobType
+func (app *application) deliverWebhook(ctx context.Context, job *jobs.Job) error {
+	var payload webhookJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+
+	hook, err := app.store.Webhooks.GetByID(ctx, payload.WebhookID)
+	if err != nil {
+		// Webhook wurde inzwischen gelöscht: nichts mehr zu tun
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Deaktivierte Webhooks bekommen nichts mehr, auch keine ausstehenden Retries
+	if !hook.Active {
+		return nil
+	}
+
+	return app.webhooks.Deliver(ctx, hook, payload.EventID, payload.Event, payload.Body, job.Attempts)
+}
+
+// CreateWebhookPayload ist der Body von POST /v1/admin/webhooks
+type CreateWebhookPayload struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// Validate prüft URL und Event-Typen
+func (p CreateWebhookPayload) Validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	if len(p.Secret) > 0 && len(p.Secret) < 16 {
+		return fmt.Errorf("secret must be at least 16 characters")
+	}
+
+	if len(p.Events) == 0 {
+		return fmt.Errorf("at least one event is required, valid events: %v", webhooks.EventTypes)
+	}
+
+	for _, event := range p.Events {
+		if !webhooks.IsValidEvent(event) {
+			return fmt.Errorf("invalid event %q, valid events: %v", event, webhooks.EventTypes)
+		}
+	}
+
+	return nil
+}
+
+// createdWebhook enthält das Secret. Es wird nur beim Anlegen einmal ausgeliefert.
+type createdWebhook struct {
+	*store.Webhook
+	Secret string `json:"secret"`
+}
+
+// createWebhookHandler legt eine Subscription an (POST /v1/admin/webhooks)
+func (app *application) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateWebhookPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	hook := &store.Webhook{
+		URL:    payload.URL,
+		Secret: payload.Secret,
+		Events: payload.Events,
+	}
+	if hook.Secret == "" {
+		hook.Secret = webhooks.NewSecret()
+	}
+
+	err := app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := s.Webhooks.Create(r.Context(), hook); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "webhook.create", "webhook", hook.ID, nil, hook)
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusCreated, createdWebhook{Webhook: hook, Secret: hook.Secret}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listWebhooksHandler zeigt alle Subscriptions (GET /v1/admin/webhooks)
+func (app *application) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := app.store.Webhooks.List(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, hooks); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteWebhookHandler löscht eine Subscription inkl. Deliveries (DELETE /v1/admin/webhooks/{webhookID})
+func (app *application) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "webhookID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		hook, err := s.Webhooks.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if err := s.Webhooks.Delete(r.Context(), id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "webhook.delete", "webhook", id, hook, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// enableWebhookHandler aktiviert einen automatisch deaktivierten Webhook wieder
+// und setzt den Fehlerzähler zurück (PUT /v1/admin/webhooks/{webhookID}/enable)
+func (app *application) enableWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "webhookID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var hook *store.Webhook
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		before, err := s.Webhooks.GetByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if err := s.Webhooks.Enable(r.Context(), id); err != nil {
+			return err
+		}
+		if hook, err = s.Webhooks.GetByID(r.Context(), id); err != nil {
+			return err
+		}
+		return app.recordAudit(r, s, "webhook.enable", "webhook", id, before, hook)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, hook); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// listWebhookDeliveriesHandler zeigt die Zustellversuche eines Webhooks, neueste zuerst
+// (GET /v1/admin/webhooks/{webhookID}/deliveries?limit=20&offset=0)
+func (app *application) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "webhookID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	p, err := readPagination(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if _, err := app.store.Webhooks.GetByID(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	deliveries, err := app.store.Webhooks.ListDeliveries(r.Context(), id, p.Limit, p.Offset)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusOK, deliveries); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// replayWebhookDeliveryHandler stellt den Payload einer Delivery erneut zu, mit
+// derselben Event-ID, damit Empfänger Duplikate erkennen können
+// (POST /v1/admin/webhooks/deliveries/{deliveryID}/replay)
+func (app *application) replayWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r, "deliveryID")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	delivery, err := app.store.Webhooks.GetDelivery(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	hook, err := app.store.Webhooks.GetByID(r.Context(), delivery.WebhookID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !hook.Active {
+		app.conflictResponse(w, r, fmt.Errorf("webhook %d is disabled, enable it before replaying", hook.ID))
+		return
+	}
+
+	job := webhookJob{
+		WebhookID: delivery.WebhookID,
+		EventID:   delivery.EventID,
+		Event:     delivery.Event,
+		Body:      delivery.Payload,
+	}
+	// Erst das Audit Event, damit ein fehlgeschlagenes Enqueue es zurückrollt
+	err = app.store.WithTx(r.Context(), func(s store.Storage) error {
+		if err := app.recordAudit(r, s, "webhook.replay", "webhook_delivery", delivery.ID, nil, nil); err != nil {
+			return err
+		}
+		return app.jobs.Enqueue(r.Context(), webhookJobType, job)
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeResponse(w, r, http.StatusAccepted, delivery); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/jobs"
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/webhooks"
+)
+
+// webhookReceiver ist ein Test-Endpoint, der Requests mitschreibt und mit status antwortet
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.requests = append(rcv.requests, receivedWebhook{header: r.Header.Clone(), body: body})
+	w.WriteHeader(rcv.status)
+}
+
+func (rcv *webhookReceiver) received() []receivedWebhook {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return append([]receivedWebhook(nil), rcv.requests...)
+}
+
+// withFastJobs ersetzt die Job Queue durch eine mit kurzen Backoffs und startet sie
+func withFastJobs(t *testing.T, app *application) {
+	t.Helper()
+
+	app.jobs = jobs.NewQueue(jobs.NewMemoryBackend(100), jobs.Config{
+		Workers:      1,
+		MaxAttempts:  3,
+		PollInterval: 5 * time.Millisecond,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+	})
+	app.registerJobs()
+	app.jobs.Start()
+}
+
+// drainJobs wartet, bis alle eingereihten Jobs inkl. Retries abgearbeitet sind
+func drainJobs(t *testing.T, app *application) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := app.jobs.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected jobs to drain, got %v", err)
+	}
+}
+
+func createTestWebhook(t *testing.T, mux http.Handler, url string, events ...string) createdWebhook {
+	t.Helper()
+
+	body, _ := json.Marshal(CreateWebhookPayload{URL: url, Events: events})
+	req, _ := http.NewRequest(http.MethodPost, "/v1/admin/webhooks", strings.NewReader(string(body)))
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	rr := executeRequest(req, mux)
+
+	checkResponseCode(t, http.StatusCreated, rr.Code)
+
+	var hook createdWebhook
+	decodeData(t, rr, &hook)
+	return hook
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	app := newTestApplication(t)
+	withFastJobs(t, app)
+	mux := app.mount()
+
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	hook := createTestWebhook(t, mux, server.URL, webhooks.PostCreated, webhooks.PostUpdated)
+	if len(hook.Secret) != 64 {
+		t.Fatalf("Expected generated secret to be returned once, got %q", hook.Secret)
+	}
+
+	anna := seedUser(t, app, "anna")
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(`{"title":"Hello","content":"World"}`))
+	req.Header.Set("Authorization", bearerToken(t, app, anna))
+	checkResponseCode(t, http.StatusCreated, executeRequest(req, mux).Code)
+
+	// post.deleted ist nicht abonniert
+	seedPost(t, app, anna.ID, "Other", "Post")
+	req, _ = http.NewRequest(http.MethodDelete, "/v1/posts/2", nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+
+	drainJobs(t, app)
+
+	got := receiver.received()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(got))
+	}
+
+	if err := webhooks.Verify(hook.Secret, got[0].header.Get(webhooks.SignatureHeader), got[0].body, time.Minute); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+	if err := webhooks.Verify("wrong-secret", got[0].header.Get(webhooks.SignatureHeader), got[0].body, time.Minute); err == nil {
+		t.Error("Expected signature check with wrong secret to fail")
+	}
+
+	var event webhooks.Event
+	if err := json.Unmarshal(got[0].body, &event); err != nil {
+		t.Fatalf("Expected JSON event, got %v", err)
+	}
+	if event.Type != webhooks.PostCreated || got[0].header.Get(webhooks.EventIDHeader) != event.ID {
+		t.Errorf("Expected post.created with matching event id header, got %+v", event)
+	}
+
+	deliveries, _ := app.store.Webhooks.ListDeliveries(context.Background(), hook.ID, 10, 0)
+	if len(deliveries) != 1 || !deliveries[0].Success || deliveries[0].StatusCode != http.StatusOK {
+		t.Errorf("Expected 1 successful recorded delivery, got %+v", deliveries)
+	}
+}
+
+func TestWebhookAutoDisableAndReplay(t *testing.T) {
+	app := newTestApplication(t)
+	withFastJobs(t, app)
+	mux := app.mount()
+
+	receiver := &webhookReceiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	hook := createTestWebhook(t, mux, server.URL, webhooks.UserDeleted)
+	anna := seedUser(t, app, "anna")
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/users/%d", anna.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+
+	drainJobs(t, app)
+
+	// 3 Versuche, danach ist maxFailures (3) erreicht und der Webhook deaktiviert
+	if n := len(receiver.received()); n != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", n)
+	}
+
+	stored, _ := app.store.Webhooks.GetByID(context.Background(), hook.ID)
+	if stored.Active || stored.DisabledAt == nil {
+		t.Fatalf("Expected webhook to be disabled, got %+v", stored)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/v1/admin/webhooks/%d/deliveries", hook.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	rr := executeRequest(req, mux)
+	checkResponseCode(t, http.StatusOK, rr.Code)
+
+	var deliveries []store.WebhookDelivery
+	decodeData(t, rr, &deliveries)
+	if len(deliveries) != 3 || deliveries[0].Attempt != 3 || deliveries[0].Success {
+		t.Fatalf("Expected 3 failed deliveries, newest first, got %+v", deliveries)
+	}
+
+	replayPath := fmt.Sprintf("/v1/admin/webhooks/deliveries/%d/replay", deliveries[0].ID)
+
+	// Replay auf einen deaktivierten Webhook wird abgelehnt
+	req, _ = http.NewRequest(http.MethodPost, replayPath, nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusConflict, executeRequest(req, mux).Code)
+
+	// Endpoint repariert: wieder aktivieren und erneut zustellen
+	receiver.mu.Lock()
+	receiver.status = http.StatusNoContent
+	receiver.mu.Unlock()
+
+	withFastJobs(t, app)
+
+	req, _ = http.NewRequest(http.MethodPut, fmt.Sprintf("/v1/admin/webhooks/%d/enable", hook.ID), nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusOK, executeRequest(req, mux).Code)
+
+	req, _ = http.NewRequest(http.MethodPost, replayPath, nil)
+	req.SetBasicAuth(testAdminUser, testAdminPass)
+	checkResponseCode(t, http.StatusAccepted, executeRequest(req, mux).Code)
+
+	drainJobs(t, app)
+
+	got := receiver.received()
+	if len(got) != 4 || string(got[3].body) != string(got[0].body) {
+		t.Fatalf("Expected replay to resend the original payload, got %d requests", len(got))
+	}
+
+	stored, _ = app.store.Webhooks.GetByID(context.Background(), hook.ID)
+	if !stored.Active || stored.FailureCount != 0 {
+		t.Errorf("Expected webhook to be active with reset failure count, got %+v", stored)
+	}
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"valid", `{"url":"https://example.com/hook","events":["post.created"]}`, http.StatusCreated},
+		{"relative url", `{"url":"/hook","events":["post.created"]}`, http.StatusBadRequest},
+		{"unsupported scheme", `{"url":"ftp://example.com","events":["post.created"]}`, http.StatusBadRequest},
+		{"no events", `{"url":"https://example.com/hook","events":[]}`, http.StatusBadRequest},
+		{"unknown event", `{"url":"https://example.com/hook","events":["post.liked"]}`, http.StatusBadRequest},
+		{"short secret", `{"url":"https://example.com/hook","secret":"abc","events":["post.created"]}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/v1/admin/webhooks", strings.NewReader(tt.body))
+			req.SetBasicAuth(testAdminUser, testAdminPass)
+			rr := executeRequest(req, mux)
+
+			checkResponseCode(t, tt.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.mount()
+
+	anna := seedUser(t, app, "anna")
+	ben := seedUser(t, app, "ben")
+	mod := seedUser(t, app, "mod")
+	post := seedPost(t, app, anna.ID, "Original", "Content", "go")
+	path := fmt.Sprintf("/v1/posts/%d", post.ID)
+
+	if err := app.store.Users.SetRole(context.Background(), mod.ID, store.RoleAdmin); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		auth     string
+		body     string
+		wantCode int
+	}{
+		{"requires token", "", `{"title":"New"}`, http.StatusUnauthorized},
+		{"only the author", bearerToken(t, app, ben), `{"title":"New"}`, http.StatusForbidden},
+		{"invalid title", bearerToken(t, app, anna), `{"title":""}`, http.StatusBadRequest},
+		{"admins may moderate", bearerToken(t, app, mod), `{"content":"Moderated"}`, http.StatusOK},
+		{"partial update", bearerToken(t, app, anna), `{"title":"New"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPatch, path, strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rr := executeRequest(req, mux)
+
+			checkResponseCode(t, tt.wantCode, rr.Code)
+		})
+	}
+
+	updated, _ := app.store.Posts.GetByID(context.Background(), post.ID)
+	if updated.Title != "New" || updated.Content != "Moderated" || len(updated.Tags) != 1 {
+		t.Errorf("Expected title and content to change, got %+v", updated)
+	}
+}
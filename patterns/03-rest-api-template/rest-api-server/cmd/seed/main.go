@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+
+	"github.com/timour/go-api/internal/env"
+	"github.com/timour/go-api/internal/store"
+)
+
+// seedConfig steuert, wie viele Datensätze erzeugt werden
+type seedConfig struct {
+	seed     int64
+	users    int
+	posts    int
+	comments int
+	follows  int
+}
+
+func main() {
+	// 1️⃣ Flags: gleicher -seed = gleicher Datensatz (z.B. für Bug-Reproduktionen)
+	var cfg seedConfig
+	flag.Int64Var(&cfg.seed, "seed", 1, "random seed, the same seed always generates the same data")
+	flag.IntVar(&cfg.users, "users", 100, "number of users")
+	flag.IntVar(&cfg.posts, "posts", 500, "number of posts")
+	flag.IntVar(&cfg.comments, "comments", 1000, "number of comments")
+	flag.IntVar(&cfg.follows, "follows", 500, "number of follow edges")
+	flag.Parse()
+
+	if cfg.users < 2 {
+		log.Fatal("-users must be at least 2")
+	}
+
+	// 2️⃣ Store wie in cmd/api über STORAGE_DRIVER und DB_ADDR öffnen
+	driver := env.GetString("STORAGE_DRIVER", "postgres")
+	storage, conn, err := store.Open(driver, store.Config{
+		Addr:         env.GetString("DB_ADDR", store.DefaultAddr(driver)),
+		MaxOpenConns: 3,
+		MaxIdleConns: 3,
+		MaxIdleTime:  "15m",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if conn != nil {
+		defer conn.Close()
+	}
+	defer storage.Close()
+
+	// 3️⃣ Alles in einer Transaktion: entweder komplett oder gar nicht
+	err = storage.WithTx(context.Background(), func(s store.Storage) error {
+		return seed(context.Background(), s, cfg)
+	})
+	if err != nil {
+		log.Fatalf("seeding failed, nothing was written: %s", err)
+	}
+
+	log.Printf("seeded %d users, %d posts, %d comments and %d follows (seed %d), password for all users: %s",
+		cfg.users, cfg.posts, cfg.comments, cfg.follows, cfg.seed, seedPassword)
+}
+
+// seed erzeugt alle Datensätze deterministisch aus cfg.seed
+func seed(ctx context.Context, s store.Storage, cfg seedConfig) error {
+	rng := rand.New(rand.NewSource(cfg.seed))
+
+	users, err := generateUsers(rng, cfg.users)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := s.Users.Create(ctx, user); err != nil {
+			return fmt.Errorf("create user %s: %w", user.Username, err)
+		}
+	}
+
+	posts := generatePosts(rng, cfg.posts, users)
+	for _, post := range posts {
+		if err := s.Posts.Create(ctx, post); err != nil {
+			return fmt.Errorf("create post: %w", err)
+		}
+	}
+
+	if len(posts) > 0 {
+		for _, comment := range generateComments(rng, cfg.comments, users, posts) {
+			if err := s.Comments.Create(ctx, comment); err != nil {
+				return fmt.Errorf("create comment: %w", err)
+			}
+		}
+	}
+
+	for _, edge := range generateFollows(rng, cfg.follows, users) {
+		if err := s.Followers.Follow(ctx, edge[0], edge[1]); err != nil {
+			return fmt.Errorf("follow %d -> %d: %w", edge[0], edge[1], err)
+		}
+	}
+
+	return nil
+}
+
+// seedPassword ist das Passwort aller generierten User
+const seedPassword = "password123"
+
+func generateUsers(rng *rand.Rand, n int) ([]*store.User, error) {
+	// bcrypt ist absichtlich langsam: einmal hashen und für alle User verwenden
+	var hashed store.User
+	if err := hashed.SetPassword(seedPassword); err != nil {
+		return nil, err
+	}
+
+	users := make([]*store.User, n)
+	for i := range users {
+		first := pick(rng, firstNames)
+		last := pick(rng, lastNames)
+		username := fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), i)
+
+		users[i] = &store.User{
+			Username: username,
+			Email:    username + "@example.com",
+			Passwort: hashed.Passwort,
+		}
+	}
+	return users, nil
+}
+
+func generatePosts(rng *rand.Rand, n int, users []*store.User) []*store.Post {
+	posts := make([]*store.Post, n)
+	for i := range posts {
+		posts[i] = &store.Post{
+			Title:   fmt.Sprintf("%s %s", pick(rng, titlePrefixes), pick(rng, topics)),
+			Content: paragraph(rng, 3+rng.Intn(5)),
+			UserID:  users[rng.Intn(len(users))].ID,
+			Tags:    pickTags(rng, 1+rng.Intn(3)),
+		}
+	}
+	return posts
+}
+
+func generateComments(rng *rand.Rand, n int, users []*store.User, posts []*store.Post) []*store.Comment {
+	comments := make([]*store.Comment, n)
+	for i := range comments {
+		comments[i] = &store.Comment{
+			PostID:  posts[rng.Intn(len(posts))].ID,
+			UserID:  users[rng.Intn(len(users))].ID,
+			Content: pick(rng, commentTexts),
+		}
+	}
+	return comments
+}
+
+// generateFollows liefert eindeutige {followerID, userID} Paare ohne Self-Follows
+func generateFollows(rng *rand.Rand, n int, users []*store.User) [][2]int64 {
+	possible := len(users) * (len(users) - 1)
+	if n > possible {
+		n = possible
+	}
+
+	seen := make(map[[2]int64]bool, n)
+	edges := make([][2]int64, 0, n)
+	for len(edges) < n {
+		follower := users[rng.Intn(len(users))].ID
+		user := users[rng.Intn(len(users))].ID
+
+		edge := [2]int64{follower, user}
+		if follower == user || seen[edge] {
+			continue
+		}
+
+		seen[edge] = true
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
+func pick(rng *rand.Rand, list []string) string {
+	return list[rng.Intn(len(list))]
+}
+
+// pickTags wählt n verschiedene Tags
+func pickTags(rng *rand.Rand, n int) []string {
+	tags := make([]string, 0, n)
+	for _, i := range rng.Perm(len(tagNames))[:n] {
+		tags = append(tags, tagNames[i])
+	}
+	return tags
+}
+
+func paragraph(rng *rand.Rand, sentences int) string {
+	parts := make([]string, sentences)
+	for i := range parts {
+		parts[i] = pick(rng, sentenceTexts)
+	}
+	return strings.Join(parts, " ")
+}
+
+var firstNames = []string{
+	"Anna", "Ben", "Clara", "David", "Emma", "Felix", "Greta", "Hannah", "Jonas", "Lea",
+	"Lukas", "Mia", "Noah", "Paul", "Sophie", "Tim", "Laura", "Max", "Nina", "Elias",
+}
+
+var lastNames = []string{
+	"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker",
+	"Schulz", "Hoffmann", "Koch", "Richter", "Klein", "Wolf", "Neumann", "Braun",
+}
+
+var titlePrefixes = []string{
+	"Getting started with", "Deep dive into", "Why I love", "Common mistakes with",
+	"Testing", "Scaling", "Debugging", "A practical guide to", "Lessons learned from",
+}
+
+var topics = []string{
+	"goroutines", "channels", "PostgreSQL indexes", "REST APIs", "the chi router",
+	"connection pools", "Docker Compose", "context timeouts", "error handling",
+	"interfaces", "database migrations", "Kubernetes deployments", "JSON encoding",
+}
+
+var tagNames = []string{
+	"go", "backend", "database", "devops", "testing", "performance", "security",
+	"beginner", "api", "docker",
+}
+
+var sentenceTexts = []string{
+	"This took me way longer to figure out than I expected.",
+	"The standard library already covers most of what you need.",
+	"Always measure before you optimize anything.",
+	"Small interfaces make testing a lot easier.",
+	"Our p99 latency dropped by half after this change.",
+	"Make sure every query runs with a context and a timeout.",
+	"I ran into a subtle race condition along the way.",
+	"The documentation is good, but the examples are better.",
+	"Keep your handlers thin and move logic into the store.",
+	"Connection pool settings matter more than you think.",
+	"Migrations should always be reversible.",
+	"Here is the minimal example that reproduces the issue.",
+}
+
+var commentTexts = []string{
+	"Great post, thanks for sharing!",
+	"This saved me hours of debugging.",
+	"Have you tried benchmarking this with more data?",
+	"I disagree, but interesting perspective.",
+	"Could you share the full example on GitHub?",
+	"We hit the exact same problem in production.",
+	"Nice write-up, bookmarked.",
+	"What Go version did you use for this?",
+}
@@ -0,0 +1,9 @@
+package auth
+
+import "github.com/golang-jwt/jwt/v5"
+
+// Authenticator erstellt und prüft Access Tokens
+type Authenticator interface {
+	GenerateToken(claims jwt.Claims) (string, error)
+	ValidateToken(token string) (*jwt.Token, error)
+}
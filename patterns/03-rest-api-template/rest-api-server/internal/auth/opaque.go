@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken erzeugt ein zufälliges Token für Links und Header. Speichern
+// darf man nur den Hash, das Token selbst sieht nur der Empfänger.
+func NewOpaqueToken() (token, hash string) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token)
+}
+
+// HashToken liefert den SHA-256 eines Tokens als Hex (64 Zeichen). Die Tokens
+// haben 256 Bit Zufall, ein langsamer Hash wie bcrypt ist deshalb unnötig.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix steht vor jedem API Key, so finden Secret Scanner geleakte Keys
+const APIKeyPrefix = "gak_"
+
+// NewAPIKey erzeugt einen API Key. prefix sind die ersten Zeichen des Keys und
+// dürfen angezeigt werden, gespeichert wird sonst nur hash.
+func NewAPIKey() (key, prefix, hash string) {
+	token, _ := NewOpaqueToken()
+
+	key = APIKeyPrefix + token
+	return key, key[:len(APIKeyPrefix)+8], HashToken(key)
+}
@@ -0,0 +1,79 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store speichert Dateien unter einem Key wie "attachments/12/3f9c.png".
+// Die Anwendung kennt nur dieses Interface, damit später z.B. S3 dazukommen kann.
+type Store interface {
+	// Put schreibt r unter key; ein bestehender Blob wird überschrieben
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open liefert den Inhalt; ErrNotFound wenn es key nicht gibt
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete entfernt key; ein fehlender Blob ist kein Fehler
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey erlaubt nur relative Pfade aus [a-z0-9._-] Segmenten, damit kein
+// Key aus dem Storage-Verzeichnis ausbrechen kann
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 255 {
+		return false
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, c := range segment {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Signer erzeugt und prüft ablaufende Download-URLs (HMAC-SHA256 über Key und Ablaufzeit)
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign liefert die Signatur für key, gültig bis expires
+func (s *Signer) Sign(key string, expires time.Time) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify prüft Signatur und Ablaufzeit (Unix-Sekunden als String aus der URL)
+func (s *Signer) Verify(key, expires, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+
+	expected := s.Sign(key, time.Unix(unix, 0))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,61 @@
+package certs
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	if _, err := NewReloader(certFile, keyFile); err == nil {
+		t.Fatal("Expected missing files to be an error")
+	}
+
+	if err := GenerateSelfSigned(certFile, keyFile, []string{"localhost", "127.0.0.1"}, time.Hour); err != nil {
+		t.Fatalf("Expected no error generating, got %v", err)
+	}
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Expected no error loading, got %v", err)
+	}
+
+	first, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+	if err := first.Leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("Expected certificate for 127.0.0.1, got %v", err)
+	}
+	if err := first.Leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("Expected certificate for localhost, got %v", err)
+	}
+
+	if err := GenerateSelfSigned(certFile, keyFile, []string{"localhost"}, 2*time.Hour); err != nil {
+		t.Fatalf("Expected no error generating, got %v", err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Expected no error reloading, got %v", err)
+	}
+
+	second, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+	if second.Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) == 0 {
+		t.Error("Expected Reload to swap the certificate")
+	}
+	if !r.NotAfter().After(first.Leaf.NotAfter) {
+		t.Errorf("Expected the new expiry, got %s", r.NotAfter())
+	}
+
+	// Ein kaputtes Paar ersetzt das aktive Zertifikat nicht
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	r.keyFile = certFile
+	if err := r.Reload(); err == nil {
+		t.Error("Expected a broken key pair to fail")
+	}
+	if current, _ := r.GetCertificate(&tls.ClientHelloInfo{}); current.Leaf.SerialNumber.Cmp(second.Leaf.SerialNumber) != 0 {
+		t.Error("Expected the old certificate to stay active after a failed reload")
+	}
+}
@@ -0,0 +1,75 @@
+// Package certs lädt TLS-Zertifikate neu, ohne den Server neu zu starten,
+// und erzeugt selbstsignierte Zertifikate für die Entwicklung.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Reloader hält das aktuelle Zertifikat aus certFile/keyFile. Reload tauscht es
+// atomar aus; laufende Verbindungen behalten ihr altes Zertifikat.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewReloader lädt das Zertifikat einmal; Fehler hier verhindern den Start
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload liest die Dateien neu. Bei einem Fehler bleibt das alte Zertifikat aktiv.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+
+	// Leaf parsen, damit NotAfter fürs Logging bereitsteht (seit Go 1.23 macht das LoadX509KeyPair selbst)
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("parse certificate: %w", err)
+		}
+	}
+
+	r.cert.Store(&cert)
+	return nil
+}
+
+// NotAfter ist das Ablaufdatum des aktuellen Zertifikats
+func (r *Reloader) NotAfter() time.Time {
+	return r.cert.Load().Leaf.NotAfter
+}
+
+// GetCertificate ist für tls.Config.GetCertificate gedacht
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// ServerConfig liefert moderne Defaults: TLS 1.2+, nur ECDHE mit AEAD-Ciphers
+// (TLS 1.3 Suites sind in Go ohnehin fest) und das Zertifikat aus r
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		GetCertificate: r.GetCertificate,
+	}
+}
@@ -0,0 +1,76 @@
+// Package dataloader bündelt die Einzel-Lookups eines Requests zu Batch-Queries
+// und vermeidet so N+1 Queries, z.B. in GraphQL Resolvern. Ein Loader lebt nur
+// für einen Request und cacht, was er einmal geladen hat.
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc lädt alle keys auf einmal. Fehlende Keys bekommen den Nullwert von V.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+type result[V any] struct {
+	value V
+	err   error
+}
+
+// Loader sammelt Keys per Load und lädt sie erst, wenn das erste Ergebnis
+// gebraucht wird. Alle bis dahin vorgemerkten Keys gehen in denselben Batch.
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]result[V]
+}
+
+// New erstellt einen Loader für einen Request
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:   batch,
+		queued:  make(map[K]bool),
+		results: make(map[K]result[V]),
+	}
+}
+
+// Load merkt key vor und liefert eine Funktion, die das Ergebnis holt. Wer
+// erst für viele Keys Load aufruft und dann die Funktionen, bekommt eine Query.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if _, done := l.results[key]; !done && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, done := l.results[key]; !done {
+			l.dispatch(ctx)
+		}
+
+		r := l.results[key]
+		return r.value, r.err
+	}
+}
+
+// dispatch lädt alle vorgemerkten Keys. Aufrufer hält den Lock.
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	clear(l.queued)
+
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.results[key] = result[V]{err: err}
+			continue
+		}
+		l.results[key] = result[V]{value: values[key]}
+	}
+}
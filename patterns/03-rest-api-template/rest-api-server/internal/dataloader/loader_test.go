@@ -0,0 +1,65 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLoaderBatchesAndCaches(t *testing.T) {
+	ctx := context.Background()
+	var batches [][]int
+
+	l := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+		values := make(map[int]string)
+		for _, k := range keys {
+			if k != 404 {
+				values[k] = fmt.Sprint("v", k)
+			}
+		}
+		return values, nil
+	})
+
+	one, two, again, missing := l.Load(ctx, 1), l.Load(ctx, 2), l.Load(ctx, 1), l.Load(ctx, 404)
+
+	if v, err := two(); v != "v2" || err != nil {
+		t.Fatalf("Expected v2, got %q, %v", v, err)
+	}
+	if v, _ := one(); v != "v1" {
+		t.Errorf("Expected v1, got %q", v)
+	}
+	if v, _ := again(); v != "v1" {
+		t.Errorf("Expected v1 for the duplicate key, got %q", v)
+	}
+	if v, err := missing(); v != "" || err != nil {
+		t.Errorf("Expected the zero value for a missing key, got %q, %v", v, err)
+	}
+
+	// Bereits geladene Keys kommen aus dem Cache, neue gehen in einen neuen Batch
+	if v, _ := l.Load(ctx, 1)(); v != "v1" {
+		t.Errorf("Expected v1 from the cache, got %q", v)
+	}
+	if v, _ := l.Load(ctx, 3)(); v != "v3" {
+		t.Errorf("Expected v3, got %q", v)
+	}
+
+	if fmt.Sprint(batches) != "[[1 2 404] [3]]" {
+		t.Errorf("Expected two batches, got %v", batches)
+	}
+}
+
+func TestLoaderBatchError(t *testing.T) {
+	boom := errors.New("boom")
+	l := New(func(ctx context.Context, keys []string) (map[string]int, error) {
+		return nil, boom
+	})
+
+	a, b := l.Load(context.Background(), "a"), l.Load(context.Background(), "b")
+	for _, load := range []func() (int, error){a, b} {
+		if _, err := load(); !errors.Is(err, boom) {
+			t.Errorf("Expected every key of the batch to fail, got %v", err)
+		}
+	}
+}
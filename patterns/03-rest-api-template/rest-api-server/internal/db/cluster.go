@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cluster ist ein Primary mit beliebig vielen Read Replicas. Schreiben geht
+// immer an den Primary, Lesen reihum an die gesunden Replicas. Ohne gesunde
+// Replica liest auch der Primary.
+type Cluster struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type replica struct {
+	name    string // für Logs, ohne Passwort
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// NewCluster öffnet Primary und Replicas mit denselben Pool-Settings. Ist der
+// Primary nicht erreichbar, schlägt das fehl; eine nicht erreichbare Replica
+// startet dagegen als ungesund und wird vom Health Check wieder aufgenommen.
+func NewCluster(driver, primaryAddr string, replicaAddrs []string, maxOpenConns, maxIdleConns int, maxIdleTime string, healthInterval time.Duration) (*Cluster, error) {
+	primary, err := Open(driver, primaryAddr, maxOpenConns, maxIdleConns, maxIdleTime)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{primary: primary, stop: make(chan struct{})}
+	for i, addr := range replicaAddrs {
+		conn, err := open(driver, addr, maxOpenConns, maxIdleConns, maxIdleTime)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		r := &replica{name: replicaName(addr, i), db: conn}
+		r.healthy.Store(ping(conn, 5*time.Second) == nil)
+		c.replicas = append(c.replicas, r)
+	}
+
+	if len(c.replicas) > 0 && healthInterval > 0 {
+		c.wg.Add(1)
+		go c.healthCheck(healthInterval)
+	}
+
+	return c, nil
+}
+
+// Primary liefert den Pool des Primary, z.B. für Transaktionen und Migrationen
+func (c *Cluster) Primary() *sql.DB {
+	return c.primary
+}
+
+// Reader liefert eine gesunde Replica oder den Primary, wenn der Request
+// gepinnt ist (siehe WithPin) oder keine Replica gesund ist
+func (c *Cluster) Reader(ctx context.Context) *sql.DB {
+	if Pinned(ctx) || len(c.replicas) == 0 {
+		return c.primary
+	}
+
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return c.primary
+}
+
+// Writer liefert den Primary und pinnt den Request, damit er seine eigenen
+// Änderungen auch danach wieder liest
+func (c *Cluster) Writer(ctx context.Context) *sql.DB {
+	PinPrimary(ctx)
+	return c.primary
+}
+
+// HealthyReplicas zählt die Replicas, auf die gerade gelesen wird
+func (c *Cluster) HealthyReplicas() int {
+	n := 0
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// Close beendet den Health Check und schließt alle Pools
+func (c *Cluster) Close() error {
+	select {
+	case <-c.stop:
+		return nil
+	default:
+		close(c.stop)
+	}
+	c.wg.Wait()
+
+	errs := []error{c.primary.Close()}
+	for _, r := range c.replicas {
+		errs = append(errs, r.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// healthCheck pingt alle Replicas im Abstand interval. Zustandswechsel
+// werden geloggt, damit ein Ausfall nicht unbemerkt auf den Primary umleitet.
+func (c *Cluster) healthCheck(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, r := range c.replicas {
+			err := ping(r.db, interval)
+			healthy := err == nil
+			if r.healthy.Swap(healthy) == healthy {
+				continue
+			}
+
+			if healthy {
+				log.Printf("db: replica %s is healthy again", r.name)
+			} else {
+				log.Printf("db: replica %s is unhealthy, reads fall back: %s", r.name, err)
+			}
+		}
+	}
+}
+
+type pinKey struct{}
+
+// WithPin gibt dem Request einen Merker für Read-your-writes mit: sobald er
+// über Writer schreibt, lesen alle folgenden Reader-Aufrufe vom Primary
+func WithPin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinKey{}, new(atomic.Bool))
+}
+
+// PinPrimary pinnt den Request an den Primary; ohne WithPin passiert nichts
+func PinPrimary(ctx context.Context) {
+	if pinned, ok := ctx.Value(pinKey{}).(*atomic.Bool); ok {
+		pinned.Store(true)
+	}
+}
+
+// Pinned meldet, ob der Request nur noch vom Primary liest
+func Pinned(ctx context.Context) bool {
+	pinned, ok := ctx.Value(pinKey{}).(*atomic.Bool)
+	return ok && pinned.Load()
+}
+
+// replicaName liefert die Adresse ohne Passwort bzw. bei key=value DSNs die Nummer
+func replicaName(addr string, i int) string {
+	if u, err := url.Parse(addr); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+func ping(db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
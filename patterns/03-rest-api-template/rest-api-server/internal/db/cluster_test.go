@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestCluster(t *testing.T, replicas int, healthInterval time.Duration) *Cluster {
+	t.Helper()
+
+	dir := t.TempDir()
+	addrs := make([]string, replicas)
+	for i := range addrs {
+		addrs[i] = filepath.Join(dir, "replica"+string(rune('a'+i))+".db")
+	}
+
+	c, err := NewCluster("sqlite", filepath.Join(dir, "primary.db"), addrs, 2, 2, "1m", healthInterval)
+	if err != nil {
+		t.Fatalf("Expected no error opening the cluster, got %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+func TestClusterReader(t *testing.T) {
+	c := newTestCluster(t, 2, 0)
+
+	seen := map[*sql.DB]int{}
+	for i := 0; i < 10; i++ {
+		seen[c.Reader(context.Background())]++
+	}
+
+	if seen[c.Primary()] != 0 {
+		t.Errorf("Expected reads to avoid the primary, got %d", seen[c.Primary()])
+	}
+	for _, r := range c.replicas {
+		if seen[r.db] != 5 {
+			t.Errorf("Expected reads spread evenly, got %v", seen)
+		}
+	}
+}
+
+func TestClusterReadYourWrites(t *testing.T) {
+	c := newTestCluster(t, 1, 0)
+
+	ctx := WithPin(context.Background())
+	if c.Reader(ctx) == c.Primary() {
+		t.Fatal("Expected an unpinned read to use the replica")
+	}
+
+	if c.Writer(ctx) != c.Primary() {
+		t.Fatal("Expected writes to use the primary")
+	}
+	if !Pinned(ctx) || c.Reader(ctx) != c.Primary() {
+		t.Error("Expected reads after a write to use the primary")
+	}
+
+	// Ohne WithPin gibt es nichts zu pinnen
+	plain := context.Background()
+	c.Writer(plain)
+	if c.Reader(plain) == c.Primary() {
+		t.Error("Expected a context without pin to keep reading from the replica")
+	}
+}
+
+func TestClusterHealthCheck(t *testing.T) {
+	c := newTestCluster(t, 2, 10*time.Millisecond)
+
+	broken := c.replicas[0]
+	broken.db.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for broken.healthy.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the closed replica to be marked unhealthy")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if c.HealthyReplicas() != 1 {
+		t.Errorf("Expected 1 healthy replica, got %d", c.HealthyReplicas())
+	}
+	for i := 0; i < 4; i++ {
+		if c.Reader(context.Background()) != c.replicas[1].db {
+			t.Fatal("Expected reads to skip the unhealthy replica")
+		}
+	}
+
+	// Ohne gesunde Replica liest der Primary
+	c.replicas[1].healthy.Store(false)
+	c.replicas[1].db.Close()
+	if c.Reader(context.Background()) != c.Primary() {
+		t.Error("Expected reads to fall back to the primary")
+	}
+}
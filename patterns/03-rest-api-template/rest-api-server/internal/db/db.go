@@ -1,32 +1,47 @@
 package db
 
 import (
-	"context"
 	"database/sql"
 	"time"
 )
 
+// New öffnet einen PostgreSQL Connection Pool
 func New(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", addr)
+	return Open("postgres", addr, maxOpenConns, maxIdleConns, maxIdleTime)
+}
+
+// Open öffnet einen Connection Pool für einen beliebigen database/sql Treiber
+// und prüft per Ping, ob die Datenbank erreichbar ist
+func Open(driver, addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.DB, error) {
+	db, err := open(driver, addr, maxOpenConns, maxIdleConns, maxIdleTime)
 	if err != nil {
 		return nil, err
 	}
 
-	db.SetMaxOpenConns(maxOpenConns)
-	db.SetMaxIdleConns(maxIdleConns)
+	if err = ping(db, 5*time.Second); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	duration, err := time.ParseDuration(maxIdleTime)
+	return db, nil
+}
+
+// open legt den Pool an, ohne die Datenbank zu kontaktieren
+func open(driver, addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.DB, error) {
+	db, err := sql.Open(driver, addr)
 	if err != nil {
 		return nil, err
 	}
-	db.SetConnMaxIdleTime(duration)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
 
-	if err = db.PingContext(ctx); err != nil {
+	duration, err := time.ParseDuration(maxIdleTime)
+	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	db.SetConnMaxIdleTime(duration)
 
 	return db, nil
 }
@@ -3,6 +3,8 @@ package env
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // GetString holt Environment Variable mit Fallback
@@ -28,3 +30,30 @@ func GetInt(key string, fallback int) int {
 
 	return intVal
 }
+
+// GetDuration holt Environment Variable als Duration (z.B. "15m") mit Fallback
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return fallback
+	}
+
+	return duration
+}
+
+// GetList holt eine kommagetrennte Environment Variable als Liste, leere Einträge fallen weg
+func GetList(key string) []string {
+	var list []string
+	for _, val := range strings.Split(os.Getenv(key), ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			list = append(list, val)
+		}
+	}
+
+	return list
+}
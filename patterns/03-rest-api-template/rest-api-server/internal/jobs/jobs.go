@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNoJob       = errors.New("no job available")
+	ErrQueueFull   = errors.New("job queue is full")
+	ErrQueueClosed = errors.New("job queue is shut down")
+	ErrUnknownType = errors.New("no handler registered for job type")
+)
+
+// Job ist eine Aufgabe, die im Hintergrund von einem Worker ausgeführt wird
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Backend speichert Jobs. Es gibt ein In-Memory und ein Postgres Backend.
+type Backend interface {
+	// Push speichert einen neuen Job
+	Push(context.Context, *Job) error
+	// Pop reserviert den nächsten fälligen Job und erhöht Attempts; ErrNoJob wenn keiner fällig ist
+	Pop(context.Context) (*Job, error)
+	// Complete markiert einen Job als erledigt
+	Complete(context.Context, *Job) error
+	// Retry plant einen fehlgeschlagenen Job für runAt neu ein
+	Retry(ctx context.Context, job *Job, runAt time.Time, err error) error
+	// Bury verschiebt einen Job endgültig in die Dead-Letter-Liste
+	Bury(ctx context.Context, job *Job, err error) error
+	// DeadLetters listet die begrabenen Jobs, die neuesten zuerst
+	DeadLetters(ctx context.Context, limit, offset int) ([]Job, error)
+	// PurgeDone löscht erledigte Jobs, die vor before abgeschlossen wurden
+	PurgeDone(ctx context.Context, before time.Time) (int64, error)
+	// Pending zählt Jobs, die noch nicht erledigt oder begraben sind
+	Pending(context.Context) (int, error)
+}
+
+// HandlerFunc führt einen Job aus. Ein Fehler führt zu einem Retry mit Backoff.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Handle registriert einen typisierten Handler: der Payload wird vorher in T dekodiert
+func Handle[T any](q *Queue, jobType string, fn func(ctx context.Context, payload T) error) {
+	q.Register(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+		if len(job.Payload) > 0 {
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return err
+			}
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Backoff berechnet die Wartezeit vor dem nächsten Versuch: base * 2^(attempt-1), max. limit
+func Backoff(base, limit time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= limit {
+			return limit
+		}
+	}
+
+	if d > limit {
+		return limit
+	}
+	return d
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryBackend hält Jobs im Speicher. Die Kapazität ist begrenzt, damit ein
+// Rückstau nicht den ganzen Prozess-Speicher frisst.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	capacity int
+	nextID   int64
+	pending  []*Job // wartende Jobs, sortiert nach RunAt
+	running  int
+	dead     []Job
+}
+
+func NewMemoryBackend(capacity int) *MemoryBackend {
+	return &MemoryBackend{capacity: capacity}
+}
+
+func (b *MemoryBackend) Push(ctx context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.capacity > 0 && len(b.pending)+b.running >= b.capacity {
+		return ErrQueueFull
+	}
+
+	b.nextID++
+	job.ID = b.nextID
+	b.insert(job)
+
+	return nil
+}
+
+// insert hält pending nach RunAt sortiert. Aufrufer hält den Lock.
+func (b *MemoryBackend) insert(job *Job) {
+	i := sort.Search(len(b.pending), func(i int) bool {
+		return b.pending[i].RunAt.After(job.RunAt)
+	})
+
+	b.pending = append(b.pending, nil)
+	copy(b.pending[i+1:], b.pending[i:])
+	b.pending[i] = job
+}
+
+func (b *MemoryBackend) Pop(ctx context.Context) (*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 || b.pending[0].RunAt.After(time.Now()) {
+		return nil, ErrNoJob
+	}
+
+	job := b.pending[0]
+	b.pending = b.pending[1:]
+	b.running++
+	job.Attempts++
+
+	return job, nil
+}
+
+func (b *MemoryBackend) Complete(ctx context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.running--
+	return nil
+}
+
+func (b *MemoryBackend) Retry(ctx context.Context, job *Job, runAt time.Time, err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.running--
+	job.RunAt = runAt
+	job.LastError = err.Error()
+	b.insert(job)
+
+	return nil
+}
+
+func (b *MemoryBackend) Bury(ctx context.Context, job *Job, err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.running--
+	job.LastError = err.Error()
+	b.dead = append(b.dead, *job)
+
+	return nil
+}
+
+func (b *MemoryBackend) DeadLetters(ctx context.Context, limit, offset int) ([]Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	jobs := []Job{}
+	for i := len(b.dead) - 1 - offset; i >= 0 && len(jobs) < limit; i-- {
+		jobs = append(jobs, b.dead[i])
+	}
+
+	return jobs, nil
+}
+
+// PurgeDone hat nichts zu tun: erledigte Jobs werden im Speicher nicht aufbewahrt
+func (b *MemoryBackend) PurgeDone(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (b *MemoryBackend) Pending(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.pending) + b.running, nil
+}
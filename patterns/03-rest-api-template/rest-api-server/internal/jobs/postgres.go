@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// PostgresBackend speichert Jobs dauerhaft in der Tabelle "jobs".
+// Mehrere Worker (auch aus mehreren API-Instanzen) holen sich Jobs per
+// FOR UPDATE SKIP LOCKED, ohne sich gegenseitig zu blockieren.
+type PostgresBackend struct {
+	db *sql.DB
+	// staleAfter: "running" Jobs, die länger laufen (z.B. nach einem Crash), werden neu vergeben
+	staleAfter time.Duration
+}
+
+func NewPostgresBackend(db *sql.DB, staleAfter time.Duration) *PostgresBackend {
+	return &PostgresBackend{db: db, staleAfter: staleAfter}
+}
+
+func (b *PostgresBackend) Push(ctx context.Context, job *Job) error {
+	query := `
+	INSERT INTO jobs (type, payload, max_attempts, run_at)
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at
+	`
+
+	return b.db.QueryRowContext(ctx, query, job.Type, []byte(job.Payload), job.MaxAttempts, job.RunAt).
+		Scan(&job.ID, &job.CreatedAt)
+}
+
+func (b *PostgresBackend) Pop(ctx context.Context) (*Job, error) {
+	query := `
+	UPDATE jobs
+	SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+	WHERE id = (
+		SELECT id FROM jobs
+		WHERE (status = 'pending' AND run_at <= NOW())
+			OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $1))
+		ORDER BY run_at, id
+		FOR UPDATE SKIP LOCKED
+		LIMIT 1
+	)
+	RETURNING id, type, payload, attempts, max_attempts, run_at, COALESCE(last_error, ''), created_at
+	`
+
+	var job Job
+	var payload []byte
+	err := b.db.QueryRowContext(ctx, query, b.staleAfter.Seconds()).Scan(
+		&job.ID, &job.Type, &payload, &job.Attempts, &job.MaxAttempts,
+		&job.RunAt, &job.LastError, &job.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoJob
+		}
+		return nil, err
+	}
+	job.Payload = payload
+
+	return &job, nil
+}
+
+func (b *PostgresBackend) Complete(ctx context.Context, job *Job) error {
+	query := `UPDATE jobs SET status = 'done', locked_at = NULL, updated_at = NOW() WHERE id = $1`
+
+	_, err := b.db.ExecContext(ctx, query, job.ID)
+	return err
+}
+
+func (b *PostgresBackend) Retry(ctx context.Context, job *Job, runAt time.Time, jobErr error) error {
+	query := `
+	UPDATE jobs
+	SET status = 'pending', run_at = $2, last_error = $3, locked_at = NULL, updated_at = NOW()
+	WHERE id = $1
+	`
+
+	_, err := b.db.ExecContext(ctx, query, job.ID, runAt, jobErr.Error())
+	return err
+}
+
+func (b *PostgresBackend) Bury(ctx context.Context, job *Job, jobErr error) error {
+	query := `
+	UPDATE jobs
+	SET status = 'dead', last_error = $2, locked_at = NULL, updated_at = NOW()
+	WHERE id = $1
+	`
+
+	_, err := b.db.ExecContext(ctx, query, job.ID, jobErr.Error())
+	return err
+}
+
+func (b *PostgresBackend) DeadLetters(ctx context.Context, limit, offset int) ([]Job, error) {
+	query := `
+	SELECT id, type, payload, attempts, max_attempts, run_at, COALESCE(last_error, ''), created_at
+	FROM jobs
+	WHERE status = 'dead'
+	ORDER BY updated_at DESC, id DESC
+	LIMIT $1 OFFSET $2
+	`
+
+	rows, err := b.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		var job Job
+		var payload []byte
+		err := rows.Scan(&job.ID, &job.Type, &payload, &job.Attempts, &job.MaxAttempts,
+			&job.RunAt, &job.LastError, &job.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		job.Payload = payload
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (b *PostgresBackend) PurgeDone(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM jobs WHERE status = 'done' AND updated_at < $1`
+
+	res, err := b.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Pending zählt nur Jobs, die jetzt fällig sind oder gerade laufen.
+// Spätere Retries überleben einen Neustart ohnehin in der Tabelle.
+func (b *PostgresBackend) Pending(ctx context.Context) (int, error) {
+	query := `
+	SELECT COUNT(*) FROM jobs
+	WHERE status = 'running' OR (status = 'pending' AND run_at <= NOW())
+	`
+
+	var count int
+	err := b.db.QueryRowContext(ctx, query).Scan(&count)
+	return count, err
+}
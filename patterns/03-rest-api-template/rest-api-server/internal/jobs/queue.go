@@ -0,0 +1,269 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Config steuert den Worker Pool
+type Config struct {
+	Workers      int           // Anzahl paralleler Worker
+	MaxAttempts  int           // Versuche, bevor ein Job in die Dead-Letter-Liste kommt
+	PollInterval time.Duration // Wie oft idle Worker nach neuen Jobs schauen
+	BackoffBase  time.Duration // Wartezeit vor dem ersten Retry
+	BackoffMax   time.Duration // Obergrenze für die Wartezeit
+	JobTimeout   time.Duration // Maximale Laufzeit eines einzelnen Jobs
+}
+
+// Queue verteilt Jobs aus einem Backend auf einen festen Pool von Workern
+type Queue struct {
+	backend  Backend
+	config   Config
+	handlers map[string]HandlerFunc
+
+	mu      sync.RWMutex
+	closed  bool
+	wake    chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	started bool
+}
+
+func NewQueue(backend Backend, config Config) *Queue {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.BackoffBase <= 0 {
+		config.BackoffBase = time.Second
+	}
+	if config.BackoffMax < config.BackoffBase {
+		config.BackoffMax = config.BackoffBase
+	}
+	if config.JobTimeout <= 0 {
+		config.JobTimeout = time.Minute
+	}
+
+	return &Queue{
+		backend:  backend,
+		config:   config,
+		handlers: make(map[string]HandlerFunc),
+		wake:     make(chan struct{}, config.Workers),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register verknüpft einen Job-Typ mit seinem Handler. Muss vor Start aufgerufen werden.
+func (q *Queue) Register(jobType string, fn HandlerFunc) {
+	q.handlers[jobType] = fn
+}
+
+// Enqueue nimmt einen neuen Job an. Nach Shutdown gibt es ErrQueueClosed.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any) error {
+	return q.EnqueueAt(ctx, jobType, payload, time.Now())
+}
+
+// EnqueueAt plant einen Job für einen späteren Zeitpunkt ein
+func (q *Queue) EnqueueAt(ctx context.Context, jobType string, payload any, runAt time.Time) error {
+	if _, ok := q.handlers[jobType]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	job := &Job{
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: q.config.MaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := q.backend.Push(ctx, job); err != nil {
+		return err
+	}
+
+	// Einen idle Worker wecken, ohne zu blockieren
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Start startet die Worker
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return
+	}
+	q.started = true
+
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+}
+
+// Shutdown nimmt keine neuen Jobs mehr an und arbeitet alle bereits
+// angenommenen Jobs ab. Läuft ctx ab, bevor die Queue leer ist, warten die
+// Worker nur noch auf die gerade laufenden Jobs. Beim Postgres Backend bleiben
+// übrige Jobs in der Tabelle und laufen beim nächsten Start weiter.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	started := q.started
+	q.mu.Unlock()
+
+	if started {
+		q.drain(ctx)
+	}
+
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain wartet, bis das Backend keine offenen Jobs mehr hat oder ctx abläuft
+func (q *Queue) drain(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		pending, err := q.backend.Pending(ctx)
+		if err != nil || pending == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("jobs: shutdown deadline reached with %d jobs left", pending)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Solange es Arbeit gibt, nicht schlafen
+		for q.runNext() {
+			select {
+			case <-q.stop:
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext holt einen Job und führt ihn aus. false = nichts zu tun.
+func (q *Queue) runNext() bool {
+	ctx := context.Background()
+
+	job, err := q.backend.Pop(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrNoJob) {
+			log.Printf("jobs: pop: %s", err)
+		}
+		return false
+	}
+
+	err = q.execute(job)
+	switch {
+	case err == nil:
+		if err := q.backend.Complete(ctx, job); err != nil {
+			log.Printf("jobs: complete %s #%d: %s", job.Type, job.ID, err)
+		}
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("jobs: %s #%d failed after %d attempts, moving to dead letters: %s", job.Type, job.ID, job.Attempts, err)
+		if err := q.backend.Bury(ctx, job, err); err != nil {
+			log.Printf("jobs: bury %s #%d: %s", job.Type, job.ID, err)
+		}
+	default:
+		runAt := time.Now().Add(Backoff(q.config.BackoffBase, q.config.BackoffMax, job.Attempts))
+		if err := q.backend.Retry(ctx, job, runAt, err); err != nil {
+			log.Printf("jobs: retry %s #%d: %s", job.Type, job.ID, err)
+		}
+	}
+
+	return true
+}
+
+// execute ruft den Handler mit Timeout auf und fängt Panics ab
+func (q *Queue) execute(job *Job) (err error) {
+	handler, ok := q.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, job.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.config.JobTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// DeadLetters listet Jobs, die endgültig fehlgeschlagen sind
+func (q *Queue) DeadLetters(ctx context.Context, limit, offset int) ([]Job, error) {
+	return q.backend.DeadLetters(ctx, limit, offset)
+}
+
+// PurgeDone löscht erledigte Jobs, die vor before abgeschlossen wurden
+func (q *Queue) PurgeDone(ctx context.Context, before time.Time) (int64, error) {
+	return q.backend.PurgeDone(ctx, before)
+}
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestQueue(capacity int) *Queue {
+	return NewQueue(NewMemoryBackend(capacity), Config{
+		Workers:      2,
+		MaxAttempts:  3,
+		PollInterval: 5 * time.Millisecond,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+	})
+}
+
+func TestTypedHandler(t *testing.T) {
+	q := newTestQueue(10)
+
+	type email struct {
+		To string `json:"to"`
+	}
+
+	var mu sync.Mutex
+	var got []string
+	Handle(q, "email", func(ctx context.Context, e email) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, e.To)
+		return nil
+	})
+
+	q.Start()
+	if err := q.Enqueue(context.Background(), "email", email{To: "anna@example.com"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error on shutdown, got %v", err)
+	}
+
+	if len(got) != 1 || got[0] != "anna@example.com" {
+		t.Errorf("Expected payload to be decoded, got %v", got)
+	}
+}
+
+func TestRetryThenSucceed(t *testing.T) {
+	q := newTestQueue(10)
+
+	var calls atomic.Int32
+	q.Register("flaky", func(ctx context.Context, job *Job) error {
+		if calls.Add(1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	q.Start()
+	q.Enqueue(context.Background(), "flaky", nil)
+	q.Shutdown(context.Background())
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+
+	dead, _ := q.DeadLetters(context.Background(), 10, 0)
+	if len(dead) != 0 {
+		t.Errorf("Expected no dead letters, got %d", len(dead))
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	q := newTestQueue(10)
+
+	q.Register("broken", func(ctx context.Context, job *Job) error {
+		return errors.New("always fails")
+	})
+	q.Register("panics", func(ctx context.Context, job *Job) error {
+		panic("boom")
+	})
+
+	q.Start()
+	q.Enqueue(context.Background(), "broken", nil)
+	q.Enqueue(context.Background(), "panics", nil)
+	q.Shutdown(context.Background())
+
+	dead, _ := q.DeadLetters(context.Background(), 10, 0)
+	if len(dead) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(dead))
+	}
+
+	for _, job := range dead {
+		if job.Attempts != 3 || job.LastError == "" {
+			t.Errorf("Expected 3 attempts and an error for %s, got %d attempts and %q", job.Type, job.Attempts, job.LastError)
+		}
+	}
+
+	page, _ := q.DeadLetters(context.Background(), 10, 1)
+	if len(page) != 1 || page[0].ID != dead[1].ID {
+		t.Errorf("Expected the offset to skip the newest dead letter, got %+v", page)
+	}
+}
+
+func TestShutdownDrainsAcceptedJobs(t *testing.T) {
+	q := newTestQueue(100)
+
+	var done atomic.Int32
+	q.Register("slow", func(ctx context.Context, job *Job) error {
+		time.Sleep(2 * time.Millisecond)
+		done.Add(1)
+		return nil
+	})
+
+	q.Start()
+	for i := 0; i < 20; i++ {
+		if err := q.Enqueue(context.Background(), "slow", nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error on shutdown, got %v", err)
+	}
+
+	if done.Load() != 20 {
+		t.Errorf("Expected all 20 accepted jobs to run, got %d", done.Load())
+	}
+
+	if err := q.Enqueue(context.Background(), "slow", nil); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed after shutdown, got %v", err)
+	}
+}
+
+func TestEnqueueErrors(t *testing.T) {
+	q := newTestQueue(1)
+	q.Register("noop", func(ctx context.Context, job *Job) error { return nil })
+
+	if err := q.Enqueue(context.Background(), "unknown", nil); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+
+	q.Enqueue(context.Background(), "noop", nil)
+	if err := q.Enqueue(context.Background(), "noop", nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{10, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(time.Second, 30*time.Second, tt.attempt); got != tt.want {
+			t.Errorf("Backoff(attempt %d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
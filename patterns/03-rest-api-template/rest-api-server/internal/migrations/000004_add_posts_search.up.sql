@@ -0,0 +1,9 @@
+-- Volltextsuche: Titel wiegt mehr (A) als Content (B)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(content, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search ON posts USING GIN (search);
+CREATE INDEX IF NOT EXISTS idx_posts_tags ON posts USING GIN (tags);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS post_reactions (
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id bigint NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    type varchar(20) NOT NULL CHECK (type IN ('like', 'love', 'laugh', 'wow', 'sad', 'angry')),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, post_id, type)
+);
+
+-- Aggregation der Counts pro Post
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_id ON post_reactions (post_id, type);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope varchar(100) NOT NULL,
+    key varchar(255) NOT NULL,
+    fingerprint char(64) NOT NULL,
+    status_code int NOT NULL DEFAULT 0, -- 0 = Request läuft noch
+    content_type text NOT NULL DEFAULT '',
+    response_body bytea,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    expires_at timestamp(0) with time zone NOT NULL,
+
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id bigserial PRIMARY KEY,
+    type varchar(100) NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}',
+    status varchar(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'dead')),
+    attempts int NOT NULL DEFAULT 0,
+    max_attempts int NOT NULL DEFAULT 5,
+    run_at timestamp with time zone NOT NULL DEFAULT NOW(),
+    locked_at timestamp with time zone,
+    last_error text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+-- Worker suchen nur nach offenen Jobs
+CREATE INDEX IF NOT EXISTS idx_jobs_runnable ON jobs (run_at, id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_dead ON jobs (updated_at) WHERE status = 'dead';
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id bigserial PRIMARY KEY,
+    url text NOT NULL,
+    secret varchar(255) NOT NULL,
+    events varchar(50)[] NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    failure_count int NOT NULL DEFAULT 0, -- aufeinanderfolgende Fehlversuche
+    disabled_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    webhook_id bigint NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id varchar(64) NOT NULL,
+    event varchar(50) NOT NULL,
+    payload jsonb NOT NULL,
+    attempt int NOT NULL,
+    status_code int NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    duration_ms bigint NOT NULL DEFAULT 0,
+    success boolean NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id DESC);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS post_attachments (
+    id bigserial PRIMARY KEY,
+    post_id bigint NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    blob_key varchar(255) NOT NULL UNIQUE, -- Key im blob.Store
+    filename varchar(255) NOT NULL,
+    content_type varchar(100) NOT NULL, -- per Content Sniffing ermittelt
+    size_bytes bigint NOT NULL,
+    width int NOT NULL DEFAULT 0, -- 0 bei Nicht-Bildern
+    height int NOT NULL DEFAULT 0,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_attachments_post_id ON post_attachments (post_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key varchar(255);
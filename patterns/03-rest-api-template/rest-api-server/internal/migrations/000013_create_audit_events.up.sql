@@ -0,0 +1,17 @@
+-- Bewusst ohne Foreign Keys: der Trail muss Purges von Usern und Posts überleben
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigserial PRIMARY KEY,
+    actor_type varchar(16) NOT NULL CHECK (actor_type IN ('user', 'admin')),
+    actor_id varchar(255) NOT NULL, -- User-ID bzw. Basic Auth Benutzername
+    action varchar(64) NOT NULL,
+    target_type varchar(32) NOT NULL,
+    target_id bigint NOT NULL,
+    changes jsonb NOT NULL DEFAULT '{}', -- {"feld": {"from": …, "to": …}}
+    ip varchar(64) NOT NULL DEFAULT '',
+    request_id varchar(128) NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_type, actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events (target_type, target_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
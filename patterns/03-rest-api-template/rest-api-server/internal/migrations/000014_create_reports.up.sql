@@ -0,0 +1,20 @@
+-- Von Moderatoren ausgeblendete Posts sind für alle anderen wie gelöscht
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS hidden_at timestamp(0) with time zone;
+
+CREATE TABLE IF NOT EXISTS reports (
+    id bigserial PRIMARY KEY,
+    post_id bigint NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    reporter_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason varchar(32) NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'nudity', 'misinformation', 'other')),
+    details text NOT NULL DEFAULT '',
+    status varchar(16) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    resolved_at timestamp(0) with time zone,
+    resolved_by bigint REFERENCES users (id) ON DELETE SET NULL,
+
+    -- Jeder User meldet einen Post höchstens einmal
+    UNIQUE (post_id, reporter_id)
+);
+
+-- Moderations-Queue: nur offene Meldungen
+CREATE INDEX IF NOT EXISTS idx_reports_open ON reports (post_id) WHERE status = 'open';
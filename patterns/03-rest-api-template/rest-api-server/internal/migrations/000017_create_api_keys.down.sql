@@ -0,0 +1,7 @@
+-- Die alte Constraint lässt keine Events von API Keys zu
+DELETE FROM audit_events WHERE actor_type = 'api_key';
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS audit_events_actor_type_check;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_actor_type_check
+    CHECK (actor_type IN ('user', 'admin'));
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    prefix varchar(16) NOT NULL, -- Anfang des Keys, zum Wiedererkennen in Listen und Logs
+    key_hash char(64) NOT NULL UNIQUE, -- SHA-256, den Key selbst sieht man nur beim Anlegen
+    scopes text[] NOT NULL DEFAULT '{}',
+    created_by varchar(255) NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    expires_at timestamp(0) with time zone NOT NULL,
+    last_used_at timestamp(0) with time zone,
+    revoked_at timestamp(0) with time zone
+);
+
+-- API Keys tauchen als eigener Actor im Audit Log auf
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS audit_events_actor_type_check;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_actor_type_check
+    CHECK (actor_type IN ('user', 'admin', 'api_key'));
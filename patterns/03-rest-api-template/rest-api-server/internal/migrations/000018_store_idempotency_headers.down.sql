@@ -0,0 +1,5 @@
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS content_type text NOT NULL DEFAULT '';
+
+UPDATE idempotency_keys SET content_type = COALESCE(response_headers->'Content-Type'->>0, '');
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS response_headers;
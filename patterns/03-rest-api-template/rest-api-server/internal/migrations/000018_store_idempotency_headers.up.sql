@@ -0,0 +1,8 @@
+-- Statt nur des Content-Type werden alle für den Replay relevanten Header gespeichert
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers jsonb NOT NULL DEFAULT '{}';
+
+UPDATE idempotency_keys
+SET response_headers = jsonb_build_object('Content-Type', jsonb_build_array(content_type))
+WHERE content_type <> '';
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS content_type;
@@ -0,0 +1,2 @@
+-- Der Purge löscht erledigte Jobs nach updated_at
+CREATE INDEX IF NOT EXISTS idx_jobs_done ON jobs (updated_at) WHERE status = 'done';
@@ -0,0 +1,5 @@
+-- Die alte Constraint lässt keine Events der CLI zu
+DELETE FROM audit_events WHERE actor_type = 'cli';
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS audit_events_actor_type_check;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_actor_type_check
+    CHECK (actor_type IN ('user', 'admin', 'api_key'));
@@ -0,0 +1,4 @@
+-- Änderungen über cmd/admin tauchen als eigener Actor im Audit Log auf
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS audit_events_actor_type_check;
+ALTER TABLE audit_events ADD CONSTRAINT audit_events_actor_type_check
+    CHECK (actor_type IN ('user', 'admin', 'api_key', 'cli'));
@@ -0,0 +1,10 @@
+// Package migrations enthält die SQL-Migrationen. Die Postgres-Dateien liegen
+// direkt in diesem Verzeichnis, die SQLite-Varianten mit denselben Versionen in sqlite/.
+package migrations
+
+import "embed"
+
+// SQLite wird vom SQLite Store beim Start angewendet (store.MigrateSQLite)
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
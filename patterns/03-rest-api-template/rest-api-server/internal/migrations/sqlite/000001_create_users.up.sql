@@ -0,0 +1,9 @@
+-- SQLite-Variante von ../000001_create_users.up.sql
+-- Zeitstempel sind TEXT im RFC 3339 Format mit fester Breite (UTC), damit sie sich als Strings sortieren lassen
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT UNIQUE NOT NULL,
+    email TEXT UNIQUE NOT NULL COLLATE NOCASE, -- statt citext
+    password TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
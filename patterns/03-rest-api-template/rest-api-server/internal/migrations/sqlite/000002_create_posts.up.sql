@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS posts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tags TEXT NOT NULL DEFAULT '[]', -- JSON Array statt varchar[]
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN deleted_at TEXT;
+ALTER TABLE posts ADD COLUMN deleted_at TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at) WHERE deleted_at IS NOT NULL;
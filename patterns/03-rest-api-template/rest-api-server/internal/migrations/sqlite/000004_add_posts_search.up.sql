@@ -0,0 +1,3 @@
+-- Kein tsvector in SQLite: der SQLite Store rankt Suchtreffer in Go (wie der Memory Store).
+-- Die Migration bleibt, damit die Versionsnummern mit Postgres übereinstimmen.
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at DESC, id DESC);
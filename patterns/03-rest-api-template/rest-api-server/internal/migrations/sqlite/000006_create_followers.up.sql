@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS followers (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    follower_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TEXT NOT NULL,
+
+    PRIMARY KEY (user_id, follower_id)
+);
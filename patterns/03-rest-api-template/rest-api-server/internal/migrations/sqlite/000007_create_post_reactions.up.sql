@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS post_reactions (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('like', 'love', 'laugh', 'wow', 'sad', 'angry')),
+    created_at TEXT NOT NULL,
+
+    PRIMARY KEY (user_id, post_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_id ON post_reactions (post_id, type);
@@ -0,0 +1,3 @@
+-- Die dauerhafte Job Queue braucht FOR UPDATE SKIP LOCKED und gibt es nur mit Postgres.
+-- Mit SQLite läuft JOBS_BACKEND=memory; die Migration hält nur die Versionsnummern gleich.
+SELECT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN avatar_key;
+
+DROP TABLE IF EXISTS post_attachments;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN token_version;
+ALTER TABLE users DROP COLUMN is_active;
+ALTER TABLE users DROP COLUMN role;
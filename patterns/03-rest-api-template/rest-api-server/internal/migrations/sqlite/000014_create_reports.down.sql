@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE posts DROP COLUMN hidden_at;
@@ -0,0 +1,17 @@
+ALTER TABLE posts ADD COLUMN hidden_at TEXT;
+
+CREATE TABLE IF NOT EXISTS reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    reporter_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'nudity', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+    created_at TEXT NOT NULL,
+    resolved_at TEXT,
+    resolved_by INTEGER REFERENCES users (id) ON DELETE SET NULL,
+
+    UNIQUE (post_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_open ON reports (post_id) WHERE status = 'open';
@@ -0,0 +1,6 @@
+ALTER TABLE idempotency_keys ADD COLUMN content_type TEXT NOT NULL DEFAULT '';
+
+UPDATE idempotency_keys
+SET content_type = COALESCE(json_extract(response_headers, '$."Content-Type"[0]'), '');
+
+ALTER TABLE idempotency_keys DROP COLUMN response_headers;
@@ -0,0 +1,7 @@
+ALTER TABLE idempotency_keys ADD COLUMN response_headers TEXT NOT NULL DEFAULT '{}'; -- JSON Objekt
+
+UPDATE idempotency_keys
+SET response_headers = json_object('Content-Type', json_array(content_type))
+WHERE content_type <> '';
+
+ALTER TABLE idempotency_keys DROP COLUMN content_type;
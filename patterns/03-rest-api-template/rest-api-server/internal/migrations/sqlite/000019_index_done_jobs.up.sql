@@ -0,0 +1,2 @@
+-- Die jobs-Tabelle gibt es nur mit Postgres; die Migration hält nur die Versionsnummern gleich.
+SELECT 1;
@@ -0,0 +1,187 @@
+package render
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Angebotene Kompressionen, bei gleichem q-Wert gewinnt brotli
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// Encodings sind die Kompressionen in Reihenfolge der Server-Präferenz
+var Encodings = []string{EncodingBrotli, EncodingGzip}
+
+// brotliLevel ist ein Kompromiss aus Rate und CPU für dynamische Antworten
+const brotliLevel = 5
+
+// Compressible meldet, ob sich Kompression für contentType lohnt. Bilder sind
+// schon komprimiert, Event-Streams würden von Proxies gepuffert.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch mediaType {
+	case "application/json", "application/msgpack", "application/x-msgpack",
+		"application/x-ndjson", "application/javascript", "image/svg+xml":
+		return true
+	case "text/event-stream":
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/")
+}
+
+// CompressWriter komprimiert eine Antwort mit gzip oder brotli. Der Anfang des
+// Bodys wird gepuffert, bis feststeht, dass sich Kompression lohnt: ein
+// passender Content-Type und mindestens minSize Bytes oder ein Flush.
+// Close muss nach dem Handler aufgerufen werden.
+type CompressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status      int
+	wroteHeader bool // WriteHeader des Handlers gesehen
+	decided     bool // Header sind an den Client geschrieben
+	buf         []byte
+	cw          io.WriteCloser // nil = unkomprimiert
+}
+
+func NewCompressWriter(w http.ResponseWriter, encoding string, minSize int) *CompressWriter {
+	return &CompressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+}
+
+func (c *CompressWriter) WriteHeader(status int) {
+	if c.wroteHeader {
+		return
+	}
+	if status < 200 {
+		// 1xx Informational geht direkt durch
+		c.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	c.status = status
+	c.wroteHeader = true
+
+	h := c.Header()
+	if status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent ||
+		h.Get("Content-Encoding") != "" || !Compressible(h.Get("Content-Type")) {
+		c.decide(false)
+	}
+}
+
+func (c *CompressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if c.decided {
+		if c.cw != nil {
+			return c.cw.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+
+	c.buf = append(c.buf, p...)
+	if len(c.buf) >= c.minSize {
+		if err := c.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// FlushError wird von http.ResponseController benutzt
+func (c *CompressWriter) FlushError() error {
+	// Wie bei net/http schickt ein Flush die Header, notfalls mit 200
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.decided {
+		if err := c.start(true); err != nil {
+			return err
+		}
+	}
+
+	if fl, ok := c.cw.(interface{ Flush() error }); ok {
+		if err := fl.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+func (c *CompressWriter) Flush() {
+	_ = c.FlushError()
+}
+
+func (c *CompressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Close schreibt den Rest des Puffers bzw. schließt den Compressor. Kleine
+// Antworten gehen unkomprimiert raus.
+func (c *CompressWriter) Close() error {
+	if !c.wroteHeader {
+		// Handler hat nichts geschrieben (z.B. Panic), nichts zu tun
+		return nil
+	}
+	if !c.decided {
+		return c.start(false)
+	}
+	if c.cw != nil {
+		return c.cw.Close()
+	}
+	return nil
+}
+
+// start schreibt die Header und den Puffer, komprimiert oder nicht
+func (c *CompressWriter) start(compress bool) error {
+	c.decide(compress)
+
+	buf := c.buf
+	c.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if c.cw != nil {
+		_, err := c.cw.Write(buf)
+		return err
+	}
+	_, err := c.ResponseWriter.Write(buf)
+	return err
+}
+
+func (c *CompressWriter) decide(compress bool) {
+	c.decided = true
+
+	if compress {
+		h := c.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", c.encoding)
+		// Die komprimierte Darstellung ist nicht Byte-gleich, der ETag wird schwach
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		switch c.encoding {
+		case EncodingBrotli:
+			c.cw = brotli.NewWriterLevel(c.ResponseWriter, brotliLevel)
+		default:
+			c.cw = gzip.NewWriter(c.ResponseWriter)
+		}
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+}
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// column ist ein Feld der CSV-Ausgabe samt Pfad durch eingebettete Structs
+type column struct {
+	name  string
+	index []int
+}
+
+// CSV schreibt eine Liste von Structs mit einer Kopfzeile aus den JSON-Namen
+// der Felder. Eingebettete Structs werden wie bei encoding/json aufgelöst,
+// verschachtelte Werte (Slices, Maps, Structs) stehen als JSON in der Zelle,
+// nil bleibt leer. Texte werden mit EscapeCSVCell gegen Formeln geschützt.
+func CSV(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	if !IsList(v) {
+		return fmt.Errorf("render: csv needs a list of structs, got %T", v)
+	}
+
+	columns := structColumns(structType(rv.Type().Elem()), nil)
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for i := 0; i < rv.Len(); i++ {
+		elem := reflect.Indirect(rv.Index(i))
+		for j, c := range columns {
+			cell, err := csvCell(elem, c.index)
+			if err != nil {
+				return err
+			}
+			record[j] = cell
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// EscapeCSVCell stellt Texten, die mit =, +, - oder @ beginnen, ein ' voran,
+// damit Tabellenkalkulationen sie nicht als Formel ausführen (CSV Injection).
+// Führende ' zählen dabei nicht mit, so bleibt UnescapeCSVCell verlustfrei.
+func EscapeCSVCell(s string) string {
+	if formulaLike(s) {
+		return "'" + s
+	}
+	return s
+}
+
+// UnescapeCSVCell macht EscapeCSVCell rückgängig, z.B. beim Import eines Exports
+func UnescapeCSVCell(s string) string {
+	if strings.HasPrefix(s, "'") && formulaLike(s) {
+		return s[1:]
+	}
+	return s
+}
+
+// formulaLike meldet, ob s nach führenden ' mit =, +, - oder @ beginnt
+func formulaLike(s string) bool {
+	s = strings.TrimLeft(s, "'")
+	return s != "" && strings.ContainsRune("=+-@", rune(s[0]))
+}
+
+// structType liefert den Struct-Typ hinter t (auch über einen Pointer) oder nil
+func structType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// structColumns sammelt die exportierten Felder von t in Reihenfolge
+func structColumns(t reflect.Type, prefix []int) []column {
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int{}, prefix...), i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			if embedded := structType(f.Type); embedded != nil {
+				columns = append(columns, structColumns(embedded, index)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		columns = append(columns, column{name: name, index: index})
+	}
+
+	return columns
+}
+
+func csvCell(elem reflect.Value, index []int) (string, error) {
+	field, err := elem.FieldByIndexErr(index)
+	if err != nil {
+		// nil-Pointer auf einen eingebetteten Struct
+		return "", nil
+	}
+
+	for field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return "", nil
+		}
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return EscapeCSVCell(field.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits()), nil
+	case reflect.Slice, reflect.Map:
+		if field.IsNil() {
+			return "", nil
+		}
+	}
+
+	b, err := json.Marshal(field.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
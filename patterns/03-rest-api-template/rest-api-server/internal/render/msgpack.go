@@ -0,0 +1,62 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func init() {
+	// json.RawMessage (Payloads, Audit-Diffs) wäre sonst ein Binärstring
+	msgpack.Register(json.RawMessage(nil), encodeRawJSON, nil)
+}
+
+// MessagePack kodiert v mit denselben Feldnamen wie JSON
+func MessagePack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	return enc.Encode(v)
+}
+
+// encodeRawJSON dekodiert eingebettetes JSON und kodiert es als MessagePack
+func encodeRawJSON(enc *msgpack.Encoder, v reflect.Value) error {
+	raw := v.Bytes()
+	if len(raw) == 0 {
+		return enc.EncodeNil()
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+
+	return enc.Encode(fromJSONNumbers(value))
+}
+
+// fromJSONNumbers ersetzt json.Number durch int64 bzw. float64
+func fromJSONNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = fromJSONNumbers(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = fromJSONNumbers(v[k])
+		}
+	}
+	return v
+}
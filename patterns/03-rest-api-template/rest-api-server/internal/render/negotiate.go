@@ -0,0 +1,92 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+)
+
+// preference ist ein Eintrag aus Accept oder Accept-Encoding,
+// z.B. "text/*;q=0.5" oder "gzip"
+type preference struct {
+	value string
+	q     float64
+}
+
+// parsePreferences zerlegt einen Header in Werte mit q-Wert (Default 1).
+// Ungültige q-Werte werden ignoriert, andere Parameter ebenso.
+func parsePreferences(header string) []preference {
+	var prefs []preference
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, raw, ok := strings.Cut(param, "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil && f >= 0 && f <= 1 {
+				q = f
+			}
+		}
+
+		prefs = append(prefs, preference{value: value, q: q})
+	}
+
+	return prefs
+}
+
+// mediaQuality liefert den q-Wert des spezifischsten Bereichs, der mediaType
+// abdeckt ("text/csv" vor "text/*" vor "*/*"), oder 0, wenn keiner passt
+func mediaQuality(prefs []preference, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	q, specificity := 0.0, -1
+	for _, p := range prefs {
+		s := -1
+		switch p.value {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = p.q, s
+		}
+	}
+
+	return q
+}
+
+// Encoding wählt aus offers die Kompression mit dem höchsten q-Wert laut
+// Accept-Encoding, bei Gleichstand die zuerst angebotene. "" heißt unkomprimiert.
+func Encoding(acceptEncoding string, offers ...string) string {
+	prefs := parsePreferences(acceptEncoding)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, wildcard := -1.0, 0.0
+		for _, p := range prefs {
+			switch p.value {
+			case offer:
+				q = p.q
+			case "*":
+				wildcard = p.q
+			}
+		}
+		if q < 0 {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
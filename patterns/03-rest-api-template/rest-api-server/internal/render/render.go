@@ -0,0 +1,102 @@
+// Package render wählt anhand von Accept und Accept-Encoding, wie eine
+// Antwort kodiert und komprimiert wird.
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Format ist eine Darstellung für Antworten
+type Format struct {
+	Name        string // Kurzname, z.B. für ETag-Suffixe
+	MediaType   string // wird gegen Accept verglichen
+	ContentType string // leer = MediaType
+	Encode      func(w io.Writer, v any) error
+	ListsOnly   bool // kodiert nur Listen und zwar ohne Envelope (z.B. CSV)
+}
+
+// Header liefert den Content-Type der Antwort
+func (f Format) Header() string {
+	if f.ContentType != "" {
+		return f.ContentType
+	}
+	return f.MediaType
+}
+
+// Registry kennt alle angebotenen Formate. Das zuerst registrierte ist der
+// Default für Requests ohne Accept-Header.
+type Registry struct {
+	formats []Format
+}
+
+func NewRegistry(formats ...Format) *Registry {
+	return &Registry{formats: formats}
+}
+
+// Default liefert das zuerst registrierte Format
+func (reg *Registry) Default() Format {
+	return reg.formats[0]
+}
+
+// Negotiate wählt das Format mit dem höchsten q-Wert, bei Gleichstand das
+// zuerst registrierte. list gibt an, ob die Antwort eine Liste ist; sonst
+// kommen ListsOnly-Formate nicht in Frage. false heißt 406 Not Acceptable.
+func (reg *Registry) Negotiate(accept string, list bool) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return reg.Default(), true
+	}
+
+	prefs := parsePreferences(accept)
+
+	var best Format
+	bestQ := 0.0
+	for _, f := range reg.formats {
+		if f.ListsOnly && !list {
+			continue
+		}
+		if q := mediaQuality(prefs, f.MediaType); q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+// Acceptable prüft, ob überhaupt eines der Formate akzeptiert wird; ob die
+// Antwort eine Liste wird, steht vor dem Handler noch nicht fest
+func (reg *Registry) Acceptable(accept string) bool {
+	_, ok := reg.Negotiate(accept, true)
+	return ok
+}
+
+// MediaTypes listet die angebotenen Formate für Fehlermeldungen auf
+func (reg *Registry) MediaTypes() []string {
+	types := make([]string, 0, len(reg.formats))
+	for _, f := range reg.formats {
+		if f.ListsOnly {
+			types = append(types, f.MediaType+" (lists only)")
+			continue
+		}
+		types = append(types, f.MediaType)
+	}
+	return types
+}
+
+// IsList meldet, ob v ein Slice von Structs ist und damit z.B. als CSV
+// kodiert werden kann
+func IsList(v any) bool {
+	t := reflect.TypeOf(v)
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return false
+	}
+
+	return structType(t.Elem()) != nil
+}
+
+// JSON ist der Default-Encoder
+func JSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
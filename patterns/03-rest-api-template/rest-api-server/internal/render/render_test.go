@@ -0,0 +1,161 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func testRegistry() *Registry {
+	return NewRegistry(
+		Format{Name: "json", MediaType: "application/json", Encode: JSON},
+		Format{Name: "msgpack", MediaType: "application/msgpack", Encode: MessagePack},
+		Format{Name: "csv", MediaType: "text/csv", Encode: CSV, ListsOnly: true},
+	)
+}
+
+func TestNegotiate(t *testing.T) {
+	reg := testRegistry()
+
+	tests := []struct {
+		name   string
+		accept string
+		list   bool
+		want   string // "" = 406
+	}{
+		{"no header", "", false, "json"},
+		{"wildcard", "*/*", false, "json"},
+		{"msgpack", "application/msgpack", false, "msgpack"},
+		{"q values", "application/json;q=0.5, application/msgpack", false, "msgpack"},
+		{"specific beats wildcard", "application/*;q=0.1, application/json;q=0", false, "msgpack"},
+		{"csv list", "text/csv", true, "csv"},
+		{"csv not a list", "text/csv", false, ""},
+		{"csv not a list with fallback", "text/csv, application/json;q=0.5", false, "json"},
+		{"unsupported", "application/xml", false, ""},
+		{"explicitly refused", "application/json;q=0", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := reg.Negotiate(tt.accept, tt.list)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("Expected 406, got %s", format.Name)
+				}
+				return
+			}
+			if !ok || format.Name != tt.want {
+				t.Fatalf("Expected %s, got %q (ok=%v)", tt.want, format.Name, ok)
+			}
+		})
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br", EncodingBrotli},
+		{"br;q=0.5, gzip", EncodingGzip},
+		{"*", EncodingBrotli},
+		{"*, br;q=0", EncodingGzip},
+		{"identity", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Encoding(tt.header, Encodings...); got != tt.want {
+			t.Errorf("Encoding(%q): expected %q, got %q", tt.header, tt.want, got)
+		}
+	}
+}
+
+type base struct {
+	ID int64 `json:"id"`
+}
+
+type row struct {
+	*base
+	Title   string          `json:"title"`
+	Tags    []string        `json:"tags"`
+	Score   *float64        `json:"score,omitempty"`
+	Secret  string          `json:"-"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func TestCSV(t *testing.T) {
+	score := 1.5
+	rows := []row{
+		{base: &base{ID: 1}, Title: "a, b", Tags: []string{"go"}, Score: &score, Secret: "x", Payload: json.RawMessage(`{"n":1}`)},
+		{Title: "no base"},
+		{Title: "=1+1"},
+	}
+
+	var buf bytes.Buffer
+	if err := CSV(&buf, rows); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := "id,title,tags,score,payload\n" +
+		"1,\"a, b\",\"[\"\"go\"\"]\",1.5,\"{\"\"n\"\":1}\"\n" +
+		",no base,,,\n" +
+		",'=1+1,,,\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+
+	if err := CSV(&buf, row{}); err == nil {
+		t.Error("Expected an error for a single struct")
+	}
+}
+
+func TestEscapeCSVCell(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"", ""},
+		{"=SUM(A1)", "'=SUM(A1)"},
+		{"- bullet", "'- bullet"},
+		{"@mention", "'@mention"},
+		{"'quoted", "'quoted"},
+		{"'=already", "''=already"},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeCSVCell(tt.in); got != tt.want {
+			t.Errorf("EscapeCSVCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got := UnescapeCSVCell(EscapeCSVCell(tt.in)); got != tt.in {
+			t.Errorf("Expected %q to survive a round trip, got %q", tt.in, got)
+		}
+	}
+}
+
+func TestMessagePack(t *testing.T) {
+	var buf bytes.Buffer
+	v := row{base: &base{ID: 7}, Title: "t", Payload: json.RawMessage(`{"n":1,"f":0.5}`)}
+	if err := MessagePack(&buf, v); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got map[string]any
+	if err := msgpack.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Expected valid msgpack, got %v", err)
+	}
+
+	if got["id"] != int8(7) || got["title"] != "t" {
+		t.Errorf("Expected json field names with inlined base, got %v", got)
+	}
+	if _, ok := got["Secret"]; ok {
+		t.Errorf("Expected json:\"-\" to be skipped, got %v", got)
+	}
+	payload, ok := got["payload"].(map[string]any)
+	if !ok || payload["n"] != int8(1) || payload["f"] != 0.5 {
+		t.Errorf("Expected raw JSON payload as a map, got %#v", got["payload"])
+	}
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Scopes eines API Keys
+const (
+	ScopePostsRead  = "posts:read"  // Posts lesen und exportieren
+	ScopePostsWrite = "posts:write" // Posts anlegen, importieren und löschen
+)
+
+// APIKeyScopes sind alle Scopes, die ein API Key haben kann
+var APIKeyScopes = []string{ScopePostsRead, ScopePostsWrite}
+
+// IsValidScope prüft, ob scope ein bekannter Scope ist
+func IsValidScope(scope string) bool {
+	return slices.Contains(APIKeyScopes, scope)
+}
+
+// APIKey erlaubt Diensten ohne User-Identität den Zugriff auf einzelne Scopes
+type APIKey struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedBy  string   `json:"created_by"` // Basic Auth Admin
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	RevokedAt  *string  `json:"revoked_at,omitempty"`
+}
+
+// HasScope prüft, ob der Key scope erlaubt
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type APIKeysStorage struct {
+	db router
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+// Create speichert einen neuen Key; hash ist der SHA-256 des Keys
+func (s *APIKeysStorage) Create(ctx context.Context, key *APIKey, hash string, expiresAt time.Time) error {
+	query := `
+	INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, expires_at
+	`
+
+	return s.db.write(ctx).QueryRowContext(ctx, query, key.Name, key.Prefix, hash, pq.Array(key.Scopes), key.CreatedBy, expiresAt).
+		Scan(&key.ID, &key.CreatedAt, &key.ExpiresAt)
+}
+
+// GetByHash liefert einen gültigen Key; ErrNotFound, wenn er unbekannt,
+// widerrufen oder abgelaufen ist
+func (s *APIKeysStorage) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+
+	var key APIKey
+	if err := scanAPIKey(s.db.readPrimary(ctx).QueryRowContext(ctx, query, hash), &key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// List liefert alle Keys inkl. widerrufener, neueste zuerst
+func (s *APIKeysStorage) List(ctx context.Context, limit, offset int) ([]APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id DESC LIMIT $1 OFFSET $2`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Revoke widerruft einen Key; ErrNotFound, wenn er unbekannt oder schon widerrufen ist
+func (s *APIKeysStorage) Revoke(ctx context.Context, id int64) error {
+	res, err := s.db.write(ctx).ExecContext(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Touch setzt last_used_at, höchstens einmal pro Minute, damit nicht jeder
+// Request schreibt
+func (s *APIKeysStorage) Touch(ctx context.Context, id int64) error {
+	query := `
+	UPDATE api_keys SET last_used_at = NOW()
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - interval '1 minute')
+	`
+
+	_, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }, key *APIKey) error {
+	return row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+}
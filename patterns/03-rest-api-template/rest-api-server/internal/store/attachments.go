@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+)
+
+// Attachment ist eine Datei im blob.Store, die zu einem Post gehört
+type Attachment struct {
+	ID          int64  `json:"id"`
+	PostID      int64  `json:"post_id"`
+	Key         string `json:"-"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	URL         string `json:"url,omitempty"` // signierte Download-URL, setzt der Handler
+}
+
+type AttachmentsStorage struct {
+	db router
+}
+
+func (s *AttachmentsStorage) Create(ctx context.Context, a *Attachment) error {
+	query := `
+	INSERT INTO post_attachments (post_id, blob_key, filename, content_type, size_bytes, width, height)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at
+	`
+
+	return s.db.write(ctx).QueryRowContext(ctx, query, a.PostID, a.Key, a.Filename, a.ContentType, a.Size, a.Width, a.Height).
+		Scan(&a.ID, &a.CreatedAt)
+}
+
+// ListByPost liefert alle Attachments eines Posts in Upload-Reihenfolge
+func (s *AttachmentsStorage) ListByPost(ctx context.Context, postID int64) ([]Attachment, error) {
+	query := `
+	SELECT id, post_id, blob_key, filename, content_type, size_bytes, width, height, created_at
+	FROM post_attachments
+	WHERE post_id = $1
+	ORDER BY id
+	`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+	for rows.Next() {
+		var a Attachment
+		err := rows.Scan(&a.ID, &a.PostID, &a.Key, &a.Filename, &a.ContentType, &a.Size, &a.Width, &a.Height, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Actor-Typen eines Audit Events
+const (
+	AuditActorUser   = "user"    // per JWT eingeloggter User, ActorID = User-ID
+	AuditActorAdmin  = "admin"   // per Basic Auth, ActorID = Benutzername
+	AuditActorAPIKey = "api_key" // per X-API-Key, ActorID = Key-ID
+	AuditActorCLI    = "cli"     // per cmd/admin, ActorID = Benutzer des Betriebssystems
+)
+
+// AuditEvent hält fest, wer was an welchem Objekt geändert hat
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	ActorType  string                 `json:"actor_type"`
+	ActorID    string                 `json:"actor_id"`
+	Action     string                 `json:"action"` // z.B. "post.update"
+	TargetType string                 `json:"target_type"`
+	TargetID   int64                  `json:"target_id"`
+	Changes    map[string]AuditChange `json:"changes"`
+	IP         string                 `json:"ip"`
+	RequestID  string                 `json:"request_id"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+// AuditChange ist der alte und neue Wert eines Feldes (null = nicht vorhanden)
+type AuditChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// AuditFilter schränkt List ein; leere Felder filtern nicht
+type AuditFilter struct {
+	ActorType  string
+	ActorID    string
+	TargetType string
+	TargetID   int64 // 0 = alle Objekte des TargetType
+	From       time.Time
+	To         time.Time // exklusiv
+	Limit      int
+	Offset     int
+}
+
+// AuditDiff vergleicht die JSON-Darstellung von before und after feldweise und
+// liefert nur die geänderten Felder. nil steht für "existierte nicht", beim
+// Anlegen ist before nil, beim Löschen after. Felder mit json:"-" (Passwörter,
+// Secrets) tauchen dadurch nie im Audit Log auf.
+func AuditDiff(before, after any) (map[string]AuditChange, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for field, value := range from {
+		if !bytes.Equal(value, to[field]) {
+			changes[field] = AuditChange{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			changes[field] = AuditChange{To: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// auditFields zerlegt v in seine JSON-Felder
+func auditFields(v any) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if v == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audit: %T is not a JSON object: %w", v, err)
+	}
+
+	return fields, nil
+}
+
+// auditWhere baut die WHERE-Klausel für filter. placeholder liefert den
+// Platzhalter für das n-te Argument ($n bzw. ?), ts formatiert Zeitstempel.
+func auditWhere(filter AuditFilter, placeholder func(n int) string, ts func(time.Time) any) (string, []any) {
+	var conds []string
+	var args []any
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, placeholder(len(args))))
+	}
+
+	if filter.ActorType != "" {
+		add("actor_type = %s", filter.ActorType)
+	}
+	if filter.ActorID != "" {
+		add("actor_id = %s", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		add("target_type = %s", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		add("target_id = %s", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= %s", ts(filter.From))
+	}
+	if !filter.To.IsZero() {
+		add("created_at < %s", ts(filter.To))
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// scanAuditEvents liest die Rows von List, changes kommt als JSON
+func scanAuditEvents(rows interface {
+	Next() bool
+	Scan(...any) error
+	Err() error
+}) ([]AuditEvent, error) {
+	events := []AuditEvent{}
+	for rows.Next() {
+		var e AuditEvent
+		var changes []byte
+		err := rows.Scan(&e.ID, &e.ActorType, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID,
+			&changes, &e.IP, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+type AuditStorage struct {
+	db router
+}
+
+func (s *AuditStorage) Record(ctx context.Context, e *AuditEvent) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO audit_events (actor_type, actor_id, action, target_type, target_id, changes, ip, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at
+	`
+
+	return s.db.write(ctx).QueryRowContext(ctx, query, e.ActorType, e.ActorID, e.Action, e.TargetType, e.TargetID,
+		changes, e.IP, e.RequestID).Scan(&e.ID, &e.CreatedAt)
+}
+
+// List liefert die passenden Events, neueste zuerst
+func (s *AuditStorage) List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	where, args := auditWhere(filter,
+		func(n int) string { return fmt.Sprintf("$%d", n) },
+		func(t time.Time) any { return t },
+	)
+
+	query := fmt.Sprintf(`
+	SELECT id, actor_type, actor_id, action, target_type, target_id, changes, ip, request_id, created_at
+	FROM audit_events
+	%s
+	ORDER BY created_at DESC, id DESC
+	LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
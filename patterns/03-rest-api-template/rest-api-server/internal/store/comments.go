@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type Comment struct {
+	ID        int64  `json:"id"`
+	PostID    int64  `json:"post_id"`
+	UserID    int64  `json:"user_id"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+type CommentsStorage struct {
+	db router
+}
+
+func (s *CommentsStorage) Create(ctx context.Context, comment *Comment) error {
+	query := `
+	INSERT INTO comments (post_id, user_id, content)
+	VALUES ($1, $2, $3) RETURNING id, created_at
+	`
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, comment.PostID, comment.UserID, comment.Content).Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByPostID liefert alle Kommentare eines Posts, älteste zuerst
+func (s *CommentsStorage) GetByPostID(ctx context.Context, postID int64) ([]Comment, error) {
+	query := `
+	SELECT id, post_id, user_id, content, created_at
+	FROM comments
+	WHERE post_id = $1
+	ORDER BY created_at, id
+	`
+
+	return scanComments(s.db.read(ctx).QueryContext(ctx, query, postID))
+}
+
+// GetByPostIDs liefert für mehrere Posts mit einer Query je höchstens limit
+// Kommentare, sortiert nach Post und darin älteste zuerst
+func (s *CommentsStorage) GetByPostIDs(ctx context.Context, postIDs []int64, limit int) ([]Comment, error) {
+	if len(postIDs) == 0 {
+		return []Comment{}, nil
+	}
+
+	query := `
+	SELECT id, post_id, user_id, content, created_at
+	FROM (
+		SELECT id, post_id, user_id, content, created_at,
+			ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at, id) AS n
+		FROM comments
+		WHERE post_id = ANY($1)
+	) ranked
+	WHERE n <= $2
+	ORDER BY post_id, created_at, id
+	`
+
+	return scanComments(s.db.read(ctx).QueryContext(ctx, query, pq.Array(postIDs), limit))
+}
+
+// scanComments liest die Rows von GetByPostID(s) (Postgres und SQLite)
+func scanComments(rows *sql.Rows, err error) ([]Comment, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []Comment{}
+	for rows.Next() {
+		var c Comment
+		if err := rows.Scan(&c.ID, &c.PostID, &c.UserID, &c.Content, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
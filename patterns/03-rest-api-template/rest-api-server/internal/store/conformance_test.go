@@ -0,0 +1,1291 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// conformanceSetups liefert pro registriertem Treiber die Config für einen
+// Test-Storage. false = Backend ist in dieser Umgebung nicht verfügbar.
+//
+// Postgres läuft nur mit TEST_POSTGRES_ADDR, z.B. gegen eine wegwerfbare,
+// migrierte Datenbank aus docker-compose. Die Tests löschen dort Daten (Purge).
+var conformanceSetups = map[string]func(t *testing.T) (store.Config, bool){
+	"memory": func(t *testing.T) (store.Config, bool) {
+		return store.Config{}, true
+	},
+	"sqlite": func(t *testing.T) (store.Config, bool) {
+		addr := filepath.Join(t.TempDir(), "test.db")
+		return store.Config{Addr: addr, MaxOpenConns: 4, MaxIdleConns: 4, MaxIdleTime: "1m"}, true
+	},
+	"postgres": func(t *testing.T) (store.Config, bool) {
+		addr := os.Getenv("TEST_POSTGRES_ADDR")
+		if addr == "" {
+			return store.Config{}, false
+		}
+		return store.Config{Addr: addr, MaxOpenConns: 5, MaxIdleConns: 5, MaxIdleTime: "1m"}, true
+	},
+}
+
+// TestConformance prüft, dass sich alle registrierten Backends gleich verhalten
+func TestConformance(t *testing.T) {
+	for _, driver := range store.Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			setup, ok := conformanceSetups[driver]
+			if !ok {
+				t.Fatalf("driver %q is registered but has no conformance setup", driver)
+			}
+
+			open := func(t *testing.T) store.Storage {
+				t.Helper()
+
+				cfg, available := setup(t)
+				if !available {
+					t.Skipf("driver %q is not available in this environment", driver)
+				}
+
+				s, conn, err := store.Open(driver, cfg)
+				if err != nil {
+					t.Fatalf("Expected driver to open, got %v", err)
+				}
+				if conn != nil {
+					t.Cleanup(func() { conn.Close() })
+				}
+				return s
+			}
+
+			for _, tc := range conformanceTests {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.run(t, open(t))
+				})
+			}
+		})
+	}
+}
+
+var conformanceTests = []struct {
+	name string
+	run  func(t *testing.T, s store.Storage)
+}{
+	{"users create and get", testUsersCreateAndGet},
+	{"users unique", testUsersUnique},
+	{"users soft delete", testUsersSoftDelete},
+	{"users roles and tokens", testUsersRolesAndTokens},
+	{"posts create and get", testPostsCreateAndGet},
+	{"posts list newest first", testPostsList},
+	{"posts update", testPostsUpdate},
+	{"posts soft delete and purge", testPostsSoftDelete},
+	{"posts by user", testPostsByUser},
+	{"posts batch create and keyset paging", testPostsBatch},
+	{"batch lookups", testBatchLookups},
+	{"audit record and list", testAuditRecordAndList},
+	{"reports and moderation queue", testReports},
+	{"password resets", testPasswordResets},
+	{"sessions and refresh token rotation", testSessions},
+	{"api keys", testAPIKeys},
+	{"search", testSearch},
+	{"reactions", testReactions},
+	{"comments", testComments},
+	{"followers", testFollowers},
+	{"idempotency keys", testIdempotency},
+	{"webhooks and deliveries", testWebhooks},
+	{"attachments", testAttachments},
+	{"unknown ids", testUnknownIDs},
+}
+
+// unique macht Usernamen eindeutig, damit Tests auch gegen eine bestehende Datenbank laufen
+func unique(name string) string {
+	return fmt.Sprintf("%s-%d", name, time.Now().UnixNano())
+}
+
+func mustCreateUser(t *testing.T, s store.Storage, name string) *store.User {
+	t.Helper()
+
+	username := unique(name)
+	user := &store.User{Username: username, Email: username + "@Example.com", Passwort: "hash"}
+	if err := s.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Expected no error creating user, got %v", err)
+	}
+	return user
+}
+
+func mustCreatePost(t *testing.T, s store.Storage, userID int64, title string, tags ...string) *store.Post {
+	t.Helper()
+
+	post := &store.Post{Title: title, Content: "Content of " + title, UserID: userID, Tags: tags}
+	if err := s.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("Expected no error creating post, got %v", err)
+	}
+	return post
+}
+
+// mustParseTime prüft, dass Backends Zeitstempel einheitlich als RFC 3339 liefern
+func mustParseTime(t *testing.T, field, value string) time.Time {
+	t.Helper()
+
+	ts, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		t.Fatalf("Expected %s to be RFC 3339, got %q", field, value)
+	}
+	return ts
+}
+
+func testUsersCreateAndGet(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "anna")
+
+	if user.ID == 0 {
+		t.Fatal("Expected Create to set the ID")
+	}
+	created := mustParseTime(t, "created_at", user.Created)
+	if time.Since(created) > time.Minute {
+		t.Errorf("Expected created_at to be now, got %s", user.Created)
+	}
+
+	got, err := s.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Username != user.Username || got.Email != user.Email || got.Passwort != "" {
+		t.Errorf("Expected user without password hash, got %+v", got)
+	}
+
+	// Login: E-Mail ohne Beachtung der Groß-/Kleinschreibung, inkl. Hash
+	byEmail, err := s.Users.GetByEmail(ctx, user.Username+"@example.COM")
+	if err != nil {
+		t.Fatalf("Expected case-insensitive email lookup, got %v", err)
+	}
+	if byEmail.ID != user.ID || byEmail.Passwort != "hash" {
+		t.Errorf("Expected user with password hash, got %+v", byEmail)
+	}
+
+	if err := s.Users.SetAvatar(ctx, user.ID, "avatars/1/a.png"); err != nil {
+		t.Fatalf("Expected no error setting avatar, got %v", err)
+	}
+	got, _ = s.Users.GetByID(ctx, user.ID)
+	if got.AvatarKey != "avatars/1/a.png" {
+		t.Errorf("Expected avatar key to be stored, got %q", got.AvatarKey)
+	}
+}
+
+func testUsersUnique(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "ben")
+
+	sameName := &store.User{Username: user.Username, Email: unique("other") + "@example.com", Passwort: "hash"}
+	if err := s.Users.Create(ctx, sameName); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for duplicate username, got %v", err)
+	}
+
+	sameEmail := &store.User{Username: unique("other"), Email: user.Email, Passwort: "hash"}
+	if err := s.Users.Create(ctx, sameEmail); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for duplicate email, got %v", err)
+	}
+}
+
+func testUsersSoftDelete(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "clara")
+	post := mustCreatePost(t, s, user.ID, "Owned by clara")
+
+	if err := s.Users.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Users.Delete(ctx, user.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected second delete to be ErrNotFound, got %v", err)
+	}
+	if _, err := s.Users.GetByID(ctx, user.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected deleted user to be hidden, got %v", err)
+	}
+	if _, err := s.Users.GetByEmail(ctx, user.Email); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected deleted user to be unable to log in, got %v", err)
+	}
+
+	deleted, err := s.Users.ListDeleted(ctx, 1, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != user.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("Expected the user as most recently deleted, got %+v", deleted)
+	}
+	mustParseTime(t, "deleted_at", *deleted[0].DeletedAt)
+
+	if err := s.Users.Restore(ctx, user.ID); err != nil {
+		t.Fatalf("Expected no error restoring, got %v", err)
+	}
+	if err := s.Users.Restore(ctx, user.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected restoring an active user to be ErrNotFound, got %v", err)
+	}
+
+	// Purge entfernt den User endgültig, seine Posts per Cascade gleich mit
+	if err := s.Users.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Users.Purge(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Users.Restore(ctx, user.ID); err != nil {
+		t.Errorf("Expected user deleted within retention to survive purge, got %v", err)
+	}
+
+	if err := s.Users.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	purged, err := s.Users.Purge(ctx, time.Now().Add(time.Hour))
+	if err != nil || purged < 1 {
+		t.Fatalf("Expected at least one purged user, got %d, %v", purged, err)
+	}
+	if err := s.Users.Restore(ctx, user.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected purged user to be gone, got %v", err)
+	}
+	if _, err := s.Posts.GetByID(ctx, post.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected posts of a purged user to be gone, got %v", err)
+	}
+}
+
+func testUsersRolesAndTokens(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "hanna")
+
+	if user.Role != store.RoleUser || !user.IsActive {
+		t.Fatalf("Expected new users to be active with role user, got %q, %v", user.Role, user.IsActive)
+	}
+
+	if err := s.Users.SetRole(ctx, user.ID, store.RoleAdmin); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Users.SetActive(ctx, user.ID, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Users.RevokeTokens(ctx, user.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, _ := s.Users.GetByID(ctx, user.ID)
+	if !got.IsAdmin() || got.IsActive || got.TokenVersion != user.TokenVersion+1 {
+		t.Errorf("Expected inactive admin with bumped token version, got %+v", got)
+	}
+
+	byEmail, _ := s.Users.GetByEmail(ctx, user.Email)
+	if !byEmail.IsAdmin() || byEmail.IsActive || byEmail.TokenVersion != got.TokenVersion {
+		t.Errorf("Expected GetByEmail to return role, status and token version, got %+v", byEmail)
+	}
+
+	users, err := s.Users.List(ctx, 1000, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	found := false
+	for i, u := range users {
+		if i > 0 && users[i-1].ID >= u.ID {
+			t.Errorf("Expected users ordered by id, got %d before %d", users[i-1].ID, u.ID)
+		}
+		if u.ID == user.ID {
+			found = u.Role == store.RoleAdmin && !u.IsActive && u.Passwort == ""
+		}
+	}
+	if !found {
+		t.Errorf("Expected the user in List with role and status but without hash, got %+v", users)
+	}
+}
+
+func testPostsCreateAndGet(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "david")
+
+	post := mustCreatePost(t, s, user.ID, "Tagged", "go", "api")
+	if post.ID == 0 || post.CreatedAt == "" || post.UpdatedAt == "" {
+		t.Fatalf("Expected Create to set ID and timestamps, got %+v", post)
+	}
+	mustParseTime(t, "created_at", post.CreatedAt)
+
+	untagged := mustCreatePost(t, s, user.ID, "Untagged")
+
+	got, err := s.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Title != "Tagged" || got.UserID != user.ID || len(got.Tags) != 2 || got.Tags[0] != "go" || got.Tags[1] != "api" {
+		t.Errorf("Expected stored post with tags in order, got %+v", got)
+	}
+
+	got, err = s.Posts.GetByID(ctx, untagged.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Tags == nil || len(got.Tags) != 0 {
+		t.Errorf("Expected empty, non-nil tags, got %#v", got.Tags)
+	}
+}
+
+func testPostsList(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "emma")
+
+	first := mustCreatePost(t, s, user.ID, "First")
+	second := mustCreatePost(t, s, user.ID, "Second")
+	deleted := mustCreatePost(t, s, user.ID, "Deleted")
+	if err := s.Posts.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	posts, err := s.Posts.List(ctx, 2, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != second.ID || posts[1].ID != first.ID {
+		t.Fatalf("Expected [second, first] without the deleted post, got %+v", posts)
+	}
+
+	next, err := s.Posts.List(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(next) != 1 || next[0].ID != first.ID {
+		t.Errorf("Expected offset to skip the newest post, got %+v", next)
+	}
+}
+
+func testPostsUpdate(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "felix")
+	post := mustCreatePost(t, s, user.ID, "Before", "old")
+
+	post.Title = "After"
+	post.Content = "New content"
+	post.Tags = []string{"new", "tags"}
+	if err := s.Posts.Update(ctx, post); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, _ := s.Posts.GetByID(ctx, post.ID)
+	if got.Title != "After" || got.Content != "New content" || len(got.Tags) != 2 || got.Tags[1] != "tags" {
+		t.Errorf("Expected updated post, got %+v", got)
+	}
+	if mustParseTime(t, "updated_at", got.UpdatedAt).Before(mustParseTime(t, "created_at", got.CreatedAt)) {
+		t.Errorf("Expected updated_at >= created_at, got %s < %s", got.UpdatedAt, got.CreatedAt)
+	}
+	if got.UpdatedAt != post.UpdatedAt {
+		t.Errorf("Expected Update to return the stored updated_at %s, got %s", got.UpdatedAt, post.UpdatedAt)
+	}
+
+	if err := s.Posts.Delete(ctx, post.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Posts.Update(ctx, post); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected updating a deleted post to be ErrNotFound, got %v", err)
+	}
+}
+
+func testPostsSoftDelete(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "greta")
+	post := mustCreatePost(t, s, user.ID, "Short lived")
+
+	if err := s.Posts.Delete(ctx, post.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Posts.Delete(ctx, post.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected second delete to be ErrNotFound, got %v", err)
+	}
+	if _, err := s.Posts.GetByID(ctx, post.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected deleted post to be hidden, got %v", err)
+	}
+
+	deleted, err := s.Posts.ListDeleted(ctx, 1, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != post.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("Expected the post as most recently deleted, got %+v", deleted)
+	}
+
+	if err := s.Posts.Restore(ctx, post.ID); err != nil {
+		t.Fatalf("Expected no error restoring, got %v", err)
+	}
+	if _, err := s.Posts.GetByID(ctx, post.ID); err != nil {
+		t.Errorf("Expected restored post to be visible, got %v", err)
+	}
+
+	if err := s.Posts.Delete(ctx, post.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	purged, err := s.Posts.Purge(ctx, time.Now().Add(time.Hour))
+	if err != nil || purged < 1 {
+		t.Fatalf("Expected at least one purged post, got %d, %v", purged, err)
+	}
+	if err := s.Posts.Restore(ctx, post.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected purged post to be gone, got %v", err)
+	}
+}
+
+func testPostsByUser(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	spammer := mustCreateUser(t, s, "ida")
+	other := mustCreateUser(t, s, "jonas")
+
+	first := mustCreatePost(t, s, spammer.ID, "Spam 1")
+	second := mustCreatePost(t, s, spammer.ID, "Spam 2")
+	kept := mustCreatePost(t, s, other.ID, "Legit")
+
+	posts, err := s.Posts.ListByUser(ctx, spammer.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(posts) != 2 || posts[0].ID != second.ID || posts[1].ID != first.ID {
+		t.Fatalf("Expected [second, first] of the user, got %+v", posts)
+	}
+
+	deleted, err := s.Posts.DeleteByUser(ctx, spammer.ID)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 deleted posts, got %d, %v", deleted, err)
+	}
+	if posts, _ := s.Posts.ListByUser(ctx, spammer.ID, 10, 0); len(posts) != 0 {
+		t.Errorf("Expected no posts left, got %+v", posts)
+	}
+	if _, err := s.Posts.GetByID(ctx, kept.ID); err != nil {
+		t.Errorf("Expected posts of other users to survive, got %v", err)
+	}
+	if err := s.Posts.Restore(ctx, first.ID); err != nil {
+		t.Errorf("Expected DeleteByUser to be a soft delete, got %v", err)
+	}
+}
+
+func testPostsBatch(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "karla")
+
+	batch := []*store.Post{
+		{Title: unique("Import A"), Content: "a", UserID: user.ID, Tags: []string{"import"}},
+		{Title: unique("Import B"), Content: "b", UserID: user.ID},
+		{Title: unique("Import C"), Content: "c", UserID: user.ID},
+	}
+	err := s.WithTx(ctx, func(tx store.Storage) error {
+		return tx.Posts.CreateBatch(ctx, batch)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	imported, err := s.Posts.ListByUser(ctx, user.ID, 10, 0)
+	if err != nil || len(imported) != 3 {
+		t.Fatalf("Expected 3 imported posts, got %+v, %v", imported, err)
+	}
+	if err := s.Posts.Delete(ctx, imported[0].ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Von kurz vor dem Import an seitenweise blättern, gelöschte Posts fehlen
+	after := imported[len(imported)-1].ID - 1
+	var seen []store.Post
+	for {
+		page, err := s.Posts.ListAfter(ctx, after, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		seen = append(seen, page...)
+		after = page[len(page)-1].ID
+	}
+
+	if len(seen) != 2 || seen[0].Title != batch[0].Title || seen[1].Title != batch[1].Title {
+		t.Fatalf("Expected A and B in ID order, got %+v", seen)
+	}
+	if len(seen[0].Tags) != 1 || seen[0].Tags[0] != "import" || seen[1].Tags == nil {
+		t.Errorf("Expected tags to round-trip, got %v and %v", seen[0].Tags, seen[1].Tags)
+	}
+}
+
+func testBatchLookups(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	lena := mustCreateUser(t, s, "lena")
+	ben := mustCreateUser(t, s, "ben")
+	gone := mustCreateUser(t, s, "gone")
+	if err := s.Users.Delete(ctx, gone.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	users, err := s.Users.GetByIDs(ctx, []int64{ben.ID, lena.ID, gone.ID, ben.ID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(users) != 2 || users[0].ID != lena.ID || users[1].ID != ben.ID || users[0].Passwort != "" {
+		t.Fatalf("Expected lena and ben once, without deleted users or hashes, got %+v", users)
+	}
+
+	old := mustCreatePost(t, s, lena.ID, "Old")
+	newer := mustCreatePost(t, s, lena.ID, "Newer")
+	newest := mustCreatePost(t, s, lena.ID, "Newest")
+	only := mustCreatePost(t, s, ben.ID, "Only")
+
+	posts, err := s.Posts.ListByUsers(ctx, []int64{lena.ID, ben.ID}, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var got []int64
+	for _, p := range posts {
+		got = append(got, p.ID)
+	}
+	if fmt.Sprint(got) != fmt.Sprint([]int64{newest.ID, newer.ID, only.ID}) {
+		t.Fatalf("Expected the 2 newest posts per user, got %v", got)
+	}
+
+	for _, c := range []*store.Comment{
+		{PostID: newest.ID, UserID: ben.ID, Content: "first"},
+		{PostID: old.ID, UserID: lena.ID, Content: "on old"},
+		{PostID: newest.ID, UserID: lena.ID, Content: "second"},
+	} {
+		if err := s.Comments.Create(ctx, c); err != nil {
+			t.Fatalf("Expected no error creating comment, got %v", err)
+		}
+	}
+
+	comments, err := s.Comments.GetByPostIDs(ctx, []int64{newest.ID, old.ID, only.ID}, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var contents []string
+	for _, c := range comments {
+		contents = append(contents, c.Content)
+	}
+	if fmt.Sprint(contents) != "[on old first second]" {
+		t.Errorf("Expected comments grouped by post, oldest first, got %v", contents)
+	}
+
+	comments, err = s.Comments.GetByPostIDs(ctx, []int64{newest.ID, old.ID}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	contents = nil
+	for _, c := range comments {
+		contents = append(contents, c.Content)
+	}
+	if fmt.Sprint(contents) != "[on old first]" {
+		t.Errorf("Expected the oldest comment per post, got %v", contents)
+	}
+
+	if empty, err := s.Comments.GetByPostIDs(ctx, nil, 10); err != nil || len(empty) != 0 {
+		t.Errorf("Expected no comments for no posts, got %v, %v", empty, err)
+	}
+}
+
+func testAuditRecordAndList(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	actor := unique("moderator")
+	before := time.Now().Add(-time.Second)
+
+	post := &store.Post{ID: 7, Title: "Old", Content: "Same", Tags: []string{}}
+	changes, err := store.AuditDiff(post, &store.Post{ID: 7, Title: "New", Content: "Same", Tags: []string{}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(changes) != 1 || string(changes["title"].From) != `"Old"` || string(changes["title"].To) != `"New"` {
+		t.Fatalf("Expected only the title to change, got %+v", changes)
+	}
+
+	events := []*store.AuditEvent{
+		{ActorType: store.AuditActorAdmin, ActorID: actor, Action: "post.update", TargetType: "post", TargetID: 7, Changes: changes, IP: "10.0.0.1", RequestID: "req-1"},
+		{ActorType: store.AuditActorAdmin, ActorID: actor, Action: "user.delete", TargetType: "user", TargetID: 3, Changes: map[string]store.AuditChange{}},
+	}
+	for _, e := range events {
+		if err := s.Audit.Record(ctx, e); err != nil {
+			t.Fatalf("Expected no error recording %s, got %v", e.Action, err)
+		}
+		if e.ID == 0 {
+			t.Fatalf("Expected Record to set the ID")
+		}
+		mustParseTime(t, "created_at", e.CreatedAt)
+	}
+
+	all, err := s.Audit.List(ctx, store.AuditFilter{ActorType: store.AuditActorAdmin, ActorID: actor, Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(all) != 2 || all[0].ID != events[1].ID || all[1].ID != events[0].ID {
+		t.Fatalf("Expected both events newest first, got %+v", all)
+	}
+	got := all[1]
+	if got.IP != "10.0.0.1" || got.RequestID != "req-1" || string(got.Changes["title"].To) != `"New"` {
+		t.Errorf("Expected the event to round-trip, got %+v", got)
+	}
+
+	byTarget, err := s.Audit.List(ctx, store.AuditFilter{ActorID: actor, TargetType: "post", TargetID: 7, Limit: 10})
+	if err != nil || len(byTarget) != 1 || byTarget[0].Action != "post.update" {
+		t.Errorf("Expected only the post event, got %+v, %v", byTarget, err)
+	}
+
+	inRange, err := s.Audit.List(ctx, store.AuditFilter{ActorID: actor, From: before, To: time.Now().Add(time.Second), Limit: 10})
+	if err != nil || len(inRange) != 2 {
+		t.Errorf("Expected both events in the time range, got %+v, %v", inRange, err)
+	}
+
+	future, err := s.Audit.List(ctx, store.AuditFilter{ActorID: actor, From: time.Now().Add(time.Hour), Limit: 10})
+	if err != nil || len(future) != 0 {
+		t.Errorf("Expected no events in the future, got %+v, %v", future, err)
+	}
+}
+
+func testReports(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	author := mustCreateUser(t, s, "troll")
+	mod := mustCreateUser(t, s, "mod")
+	reporters := []*store.User{mustCreateUser(t, s, "rita"), mustCreateUser(t, s, "rolf")}
+	bad := mustCreatePost(t, s, author.ID, "Buy cheap pills")
+	meh := mustCreatePost(t, s, author.ID, "Hot take")
+
+	reports := []*store.Report{
+		{PostID: bad.ID, ReporterID: reporters[0].ID, Reason: "spam"},
+		{PostID: bad.ID, ReporterID: reporters[1].ID, Reason: "other", Details: "scam"},
+		{PostID: meh.ID, ReporterID: reporters[0].ID, Reason: "harassment"},
+	}
+	for _, r := range reports {
+		if err := s.Reports.Create(ctx, r); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if r.ID == 0 || r.Status != store.ReportOpen {
+			t.Fatalf("Expected an open report with ID, got %+v", r)
+		}
+		mustParseTime(t, "created_at", r.CreatedAt)
+	}
+
+	dup := &store.Report{PostID: bad.ID, ReporterID: reporters[0].ID, Reason: "hate"}
+	if err := s.Reports.Create(ctx, dup); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a second report by the same user, got %v", err)
+	}
+
+	if n, err := s.Reports.CountOpen(ctx, bad.ID); err != nil || n != 2 {
+		t.Errorf("Expected 2 open reports, got %d, %v", n, err)
+	}
+
+	item, err := s.Reports.GetQueued(ctx, bad.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if item.AuthorID != author.ID || item.Reports != 2 || strings.Join(item.Reasons, ",") != "other,spam" || item.Hidden {
+		t.Errorf("Expected the grouped reports, got %+v", item)
+	}
+
+	// Ausblenden versteckt den Post überall, nur nicht in der Queue
+	if err := s.Posts.SetHidden(ctx, bad.ID, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Posts.GetByID(ctx, bad.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the hidden post to be gone, got %v", err)
+	}
+	if got, err := s.Posts.GetByIDIncludingHidden(ctx, bad.ID); err != nil || got.ID != bad.ID {
+		t.Errorf("Expected the hidden post for deletion, got %+v, %v", got, err)
+	}
+	if posts, _ := s.Posts.ListByUser(ctx, author.ID, 10, 0); len(posts) != 1 || posts[0].ID != meh.ID {
+		t.Errorf("Expected only the visible post, got %+v", posts)
+	}
+	if item, err := s.Reports.GetQueued(ctx, bad.ID); err != nil || !item.Hidden {
+		t.Errorf("Expected the hidden post in the queue, got %+v, %v", item, err)
+	}
+
+	queue, err := s.Reports.Queue(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var order []int64
+	for _, q := range queue {
+		if q.PostID == bad.ID || q.PostID == meh.ID {
+			order = append(order, q.PostID)
+		}
+	}
+	if fmt.Sprint(order) != fmt.Sprint([]int64{bad.ID, meh.ID}) {
+		t.Errorf("Expected the most reported post first, got %v", order)
+	}
+
+	resolved, err := s.Reports.Resolve(ctx, bad.ID, store.ReportDismissed, mod.ID)
+	if err != nil || resolved != 2 {
+		t.Fatalf("Expected 2 resolved reports, got %d, %v", resolved, err)
+	}
+	if _, err := s.Reports.GetQueued(ctx, bad.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the post to leave the queue, got %v", err)
+	}
+	if n, _ := s.Reports.CountOpen(ctx, bad.ID); n != 0 {
+		t.Errorf("Expected no open reports, got %d", n)
+	}
+
+	if err := s.Posts.SetHidden(ctx, bad.ID, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Posts.GetByID(ctx, bad.ID); err != nil {
+		t.Errorf("Expected the post to be visible again, got %v", err)
+	}
+	if err := s.Posts.SetHidden(ctx, 999999, true); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown post, got %v", err)
+	}
+
+	err = s.WithTx(ctx, func(tx store.Storage) error { return tx.Posts.Lock(ctx, bad.ID) })
+	if err != nil {
+		t.Errorf("Expected to lock the post, got %v", err)
+	}
+	if err := s.Posts.Lock(ctx, 999999); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound locking an unknown post, got %v", err)
+	}
+}
+
+func testUnknownIDs(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	const missing = int64(1) << 60
+
+	checks := map[string]error{}
+	_, checks["Posts.GetByID"] = s.Posts.GetByID(ctx, missing)
+	checks["Posts.Update"] = s.Posts.Update(ctx, &store.Post{ID: missing, Title: "x", Content: "x", Tags: []string{}})
+	checks["Posts.Delete"] = s.Posts.Delete(ctx, missing)
+	checks["Posts.Restore"] = s.Posts.Restore(ctx, missing)
+	_, checks["Users.GetByID"] = s.Users.GetByID(ctx, missing)
+	_, checks["Users.GetByEmail"] = s.Users.GetByEmail(ctx, unique("nobody")+"@example.com")
+	checks["Users.Delete"] = s.Users.Delete(ctx, missing)
+	checks["Users.Restore"] = s.Users.Restore(ctx, missing)
+	checks["Users.SetAvatar"] = s.Users.SetAvatar(ctx, missing, "avatars/x.png")
+	checks["Users.SetRole"] = s.Users.SetRole(ctx, missing, store.RoleAdmin)
+	checks["Users.SetActive"] = s.Users.SetActive(ctx, missing, false)
+	checks["Users.RevokeTokens"] = s.Users.RevokeTokens(ctx, missing)
+
+	for name, err := range checks {
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Expected %s to return ErrNotFound, got %v", name, err)
+		}
+	}
+}
+
+func testPasswordResets(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "ida")
+	hour := time.Now().Add(time.Hour)
+
+	if err := s.PasswordResets.Create(ctx, user.ID, strings.Repeat("a", 64), hour); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Ein neuer Token macht den alten ungültig
+	if err := s.PasswordResets.Create(ctx, user.ID, strings.Repeat("b", 64), hour); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.PasswordResets.Consume(ctx, strings.Repeat("a", 64)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the older token to be invalid, got %v", err)
+	}
+
+	userID, err := s.PasswordResets.Consume(ctx, strings.Repeat("b", 64))
+	if err != nil || userID != user.ID {
+		t.Fatalf("Expected user %d, got %d, %v", user.ID, userID, err)
+	}
+	if _, err := s.PasswordResets.Consume(ctx, strings.Repeat("b", 64)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the token to be single-use, got %v", err)
+	}
+
+	expired := strings.Repeat("c", 64)
+	if err := s.PasswordResets.Create(ctx, user.ID, expired, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.PasswordResets.Consume(ctx, expired); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the expired token to be rejected, got %v", err)
+	}
+	if n, err := s.PasswordResets.PurgeExpired(ctx); err != nil || n < 1 {
+		t.Errorf("Expected the expired token to be purged, got %d, %v", n, err)
+	}
+
+	if err := s.Users.UpdatePassword(ctx, user.ID, "new-hash"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got, _ := s.Users.GetByEmail(ctx, user.Email); got.Passwort != "new-hash" {
+		t.Errorf("Expected the new password hash, got %q", got.Passwort)
+	}
+}
+
+func testSessions(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "jana")
+	hash := func(c string) string { return strings.Repeat(c, 64) }
+
+	session := &store.Session{UserID: user.ID, UserAgent: "curl/8", IP: "127.0.0.1"}
+	if err := s.Sessions.Create(ctx, session, hash("a"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if session.ID == 0 || session.CreatedAt == "" || session.ExpiresAt == "" {
+		t.Fatalf("Expected id and timestamps, got %+v", session)
+	}
+
+	got, err := s.Sessions.GetByToken(ctx, hash("a"))
+	if err != nil || got.ID != session.ID || got.UserAgent != "curl/8" {
+		t.Fatalf("Expected the session by token, got %+v, %v", got, err)
+	}
+
+	rotated, err := s.Sessions.Rotate(ctx, hash("a"), hash("b"))
+	if err != nil || rotated.ID != session.ID || rotated.UserID != user.ID {
+		t.Fatalf("Expected the rotated session, got %+v, %v", rotated, err)
+	}
+	if _, err := s.Sessions.GetByToken(ctx, hash("a")); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the old token to be replaced, got %v", err)
+	}
+	if _, err := s.Sessions.Rotate(ctx, hash("x"), hash("y")); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown token, got %v", err)
+	}
+
+	// Der alte Token taucht wieder auf: Session melden, aber nichts ändern
+	reused, err := s.Sessions.Rotate(ctx, hash("a"), hash("c"))
+	if !errors.Is(err, store.ErrTokenReused) || reused == nil || reused.ID != session.ID {
+		t.Fatalf("Expected ErrTokenReused with the session, got %+v, %v", reused, err)
+	}
+	if _, err := s.Sessions.GetByToken(ctx, hash("b")); err != nil {
+		t.Errorf("Expected the current token to stay valid until the caller revokes, got %v", err)
+	}
+
+	second := &store.Session{UserID: user.ID}
+	if err := s.Sessions.Create(ctx, second, hash("d"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expired := &store.Session{UserID: user.ID}
+	if err := s.Sessions.Create(ctx, expired, hash("e"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Sessions.Rotate(ctx, hash("e"), hash("f")); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an expired session, got %v", err)
+	}
+
+	sessions, err := s.Sessions.ListByUser(ctx, user.ID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("Expected two active sessions, got %+v, %v", sessions, err)
+	}
+
+	if err := s.Sessions.Revoke(ctx, session.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Sessions.Revoke(ctx, session.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound revoking twice, got %v", err)
+	}
+	if _, err := s.Sessions.Get(ctx, session.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the revoked session to be gone, got %v", err)
+	}
+	if _, err := s.Sessions.Rotate(ctx, hash("b"), hash("g")); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a revoked session, got %v", err)
+	}
+	if _, err := s.Sessions.Rotate(ctx, hash("a"), hash("g")); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected no reuse report for a revoked session, got %v", err)
+	}
+
+	if n, err := s.Sessions.RevokeAll(ctx, user.ID); err != nil || n != 1 {
+		t.Errorf("Expected one revoked session, got %d, %v", n, err)
+	}
+	if n, err := s.Sessions.PurgeExpired(ctx); err != nil || n < 3 {
+		t.Errorf("Expected revoked and expired sessions to be purged, got %d, %v", n, err)
+	}
+}
+
+func testAPIKeys(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	hash := unique("hash")
+
+	key := &store.APIKey{Name: "nightly export", Prefix: "gak_abcdefgh", Scopes: []string{store.ScopePostsRead}, CreatedBy: "admin"}
+	if err := s.APIKeys.Create(ctx, key, hash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if key.ID == 0 || key.CreatedAt == "" || key.ExpiresAt == "" {
+		t.Fatalf("Expected id and timestamps, got %+v", key)
+	}
+	if err := s.APIKeys.Create(ctx, &store.APIKey{Name: "dup"}, hash, time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("Expected an error for a duplicate hash")
+	}
+
+	got, err := s.APIKeys.GetByHash(ctx, hash)
+	if err != nil || got.ID != key.ID || !got.HasScope(store.ScopePostsRead) || got.HasScope(store.ScopePostsWrite) || got.LastUsedAt != nil {
+		t.Fatalf("Expected the key with its scope, got %+v, %v", got, err)
+	}
+
+	if err := s.APIKeys.Touch(ctx, key.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got, _ = s.APIKeys.GetByHash(ctx, hash); got.LastUsedAt == nil {
+		t.Errorf("Expected last_used_at to be set, got %+v", got)
+	}
+
+	expired := unique("expired")
+	if err := s.APIKeys.Create(ctx, &store.APIKey{Name: "old", Prefix: "gak_old"}, expired, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.APIKeys.GetByHash(ctx, expired); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an expired key, got %v", err)
+	}
+
+	if err := s.APIKeys.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.APIKeys.Revoke(ctx, key.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound revoking twice, got %v", err)
+	}
+	if _, err := s.APIKeys.GetByHash(ctx, hash); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a revoked key, got %v", err)
+	}
+
+	keys, err := s.APIKeys.List(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, k := range keys {
+		if k.ID == key.ID && (k.RevokedAt == nil || k.Scopes[0] != store.ScopePostsRead) {
+			t.Errorf("Expected the revoked key in the list, got %+v", k)
+		}
+	}
+}
+
+func testSearch(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "sina")
+	other := mustCreateUser(t, s, "otto")
+
+	// Eindeutige Wörter, damit Treffer aus anderen Tests nicht mitzählen
+	word := fmt.Sprintf("quokka%d", time.Now().UnixNano())
+	alt := fmt.Sprintf("wombat%d", time.Now().UnixNano())
+
+	channels := mustCreatePost(t, s, user.ID, word+" channels", "go")
+	mustCreatePost(t, s, user.ID, word+" testing", "go", "testing")
+	mustCreatePost(t, s, other.ID, "Cooking "+alt, "food")
+
+	search := func(q string, filter store.SearchFilter) *store.SearchResult {
+		t.Helper()
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		result, err := s.Posts.Search(ctx, q, filter)
+		if err != nil {
+			t.Fatalf("Expected no error searching %q, got %v", q, err)
+		}
+		return result
+	}
+
+	result := search(word, store.SearchFilter{})
+	if result.Total != 2 || len(result.Hits) != 2 {
+		t.Fatalf("Expected 2 hits, got total %d with %d hits", result.Total, len(result.Hits))
+	}
+	if !strings.Contains(result.Hits[0].TitleHighlight, "<mark>") {
+		t.Errorf("Expected a highlighted title, got %q", result.Hits[0].TitleHighlight)
+	}
+	if fmt.Sprint(result.Facets) != "[{go 2} {testing 1}]" {
+		t.Errorf("Expected facets over all hits, got %v", result.Facets)
+	}
+
+	tests := []struct {
+		name   string
+		q      string
+		filter store.SearchFilter
+		want   int
+	}{
+		{"tag filter", word, store.SearchFilter{Tags: []string{"testing"}}, 1},
+		{"author filter", word, store.SearchFilter{UserID: other.ID}, 0},
+		{"exclude", word + " -channels", store.SearchFilter{}, 1},
+		{"or", word + " or " + alt, store.SearchFilter{}, 3},
+		{"no match", word + " " + alt, store.SearchFilter{}, 0},
+	}
+	for _, tt := range tests {
+		if got := search(tt.q, tt.filter); got.Total != tt.want || len(got.Hits) != tt.want {
+			t.Errorf("%s: expected %d hits, got total %d with %d hits", tt.name, tt.want, got.Total, len(got.Hits))
+		}
+	}
+
+	// Total und Facets zählen auch, wenn die Seite hinter dem letzten Treffer liegt
+	page := search(word, store.SearchFilter{Limit: 1, Offset: 5})
+	if page.Total != 2 || len(page.Hits) != 0 || len(page.Facets) != 2 {
+		t.Errorf("Expected total 2 and facets with an empty page, got %+v", page)
+	}
+
+	if err := s.Posts.Delete(ctx, channels.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := search(word, store.SearchFilter{}); got.Total != 1 {
+		t.Errorf("Expected deleted posts not to be found, got total %d", got.Total)
+	}
+}
+
+func testReactions(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	anna := mustCreateUser(t, s, "anna")
+	ben := mustCreateUser(t, s, "ben")
+	post := mustCreatePost(t, s, anna.ID, "Reacted")
+	quiet := mustCreatePost(t, s, anna.ID, "Quiet")
+
+	for _, r := range []struct {
+		userID   int64
+		reaction string
+	}{{anna.ID, "like"}, {anna.ID, "like"}, {ben.ID, "like"}, {ben.ID, "love"}} {
+		if err := s.Reactions.Add(ctx, r.userID, post.ID, r.reaction); err != nil {
+			t.Fatalf("Expected duplicate reactions to be ignored, got %v", err)
+		}
+	}
+
+	counts, err := s.Reactions.CountsForPosts(ctx, []int64{post.ID, quiet.ID})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if counts[post.ID]["like"] != 2 || counts[post.ID]["love"] != 1 || len(counts[quiet.ID]) != 0 {
+		t.Errorf("Expected 2 likes and 1 love, got %v", counts)
+	}
+
+	if err := s.Reactions.Remove(ctx, ben.ID, post.ID, "love"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Reactions.Remove(ctx, ben.ID, post.ID, "love"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound removing twice, got %v", err)
+	}
+
+	counts, _ = s.Reactions.CountsForPosts(ctx, []int64{post.ID})
+	if counts[post.ID]["love"] != 0 || counts[post.ID]["like"] != 2 {
+		t.Errorf("Expected the love to be removed, got %v", counts)
+	}
+
+	if empty, err := s.Reactions.CountsForPosts(ctx, nil); err != nil || len(empty) != 0 {
+		t.Errorf("Expected no counts for no posts, got %v, %v", empty, err)
+	}
+}
+
+func testComments(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "carla")
+	post := mustCreatePost(t, s, user.ID, "Commented")
+
+	first := &store.Comment{PostID: post.ID, UserID: user.ID, Content: "first"}
+	if err := s.Comments.Create(ctx, first); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.ID == 0 {
+		t.Fatal("Expected Create to set the ID")
+	}
+	mustParseTime(t, "created_at", first.CreatedAt)
+
+	if err := s.Comments.Create(ctx, &store.Comment{PostID: post.ID, UserID: user.ID, Content: "second"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	comments, err := s.Comments.GetByPostID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(comments) != 2 || comments[0].ID != first.ID || comments[1].Content != "second" || comments[0].UserID != user.ID {
+		t.Errorf("Expected both comments, oldest first, got %+v", comments)
+	}
+
+	if none, err := s.Comments.GetByPostID(ctx, post.ID+1_000_000); err != nil || none == nil || len(none) != 0 {
+		t.Errorf("Expected an empty list for an unknown post, got %v, %v", none, err)
+	}
+}
+
+func testFollowers(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	fan := mustCreateUser(t, s, "fan")
+	star := mustCreateUser(t, s, "star")
+
+	if err := s.Followers.Follow(ctx, fan.ID, star.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Followers.Follow(ctx, fan.ID, star.ID); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict following twice, got %v", err)
+	}
+
+	// Die Richtung zählt: star folgt fan noch nicht
+	if err := s.Followers.Unfollow(ctx, star.ID, fan.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for the reverse direction, got %v", err)
+	}
+
+	if err := s.Followers.Unfollow(ctx, fan.ID, star.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Followers.Unfollow(ctx, fan.ID, star.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound unfollowing twice, got %v", err)
+	}
+	if err := s.Followers.Follow(ctx, fan.ID, star.ID); err != nil {
+		t.Errorf("Expected to follow again after unfollowing, got %v", err)
+	}
+}
+
+func testIdempotency(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	scope := unique("user")
+
+	record := &store.IdempotencyRecord{Scope: scope, Key: "k1", Fingerprint: "fp", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.Idempotency.Reserve(ctx, record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Idempotency.Reserve(ctx, record); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("Expected ErrConflict reserving twice, got %v", err)
+	}
+
+	got, err := s.Idempotency.Get(ctx, scope, "k1")
+	if err != nil || got.Completed() || got.Fingerprint != "fp" {
+		t.Fatalf("Expected a pending record, got %+v, %v", got, err)
+	}
+
+	record.StatusCode = 201
+	record.Header = map[string][]string{"Content-Type": {"application/json"}, "Vary": {"Accept", "Accept-Encoding"}}
+	record.Body = []byte(`{"data":{}}`)
+	if err := s.Idempotency.Complete(ctx, record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, err = s.Idempotency.Get(ctx, scope, "k1")
+	if err != nil || !got.Completed() || got.StatusCode != 201 || string(got.Body) != `{"data":{}}` {
+		t.Fatalf("Expected the stored response, got %+v, %v", got, err)
+	}
+	if fmt.Sprint(got.Header) != "map[Content-Type:[application/json] Vary:[Accept Accept-Encoding]]" {
+		t.Errorf("Expected the stored headers, got %v", got.Header)
+	}
+
+	if err := s.Idempotency.Complete(ctx, &store.IdempotencyRecord{Scope: scope, Key: "unknown", StatusCode: 200}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound completing an unknown key, got %v", err)
+	}
+
+	if err := s.Idempotency.Delete(ctx, scope, "k1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Idempotency.Get(ctx, scope, "k1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// Abgelaufene Keys sind unsichtbar, dürfen neu reserviert und gepurged werden
+	expired := &store.IdempotencyRecord{Scope: scope, Key: "old", Fingerprint: "fp", ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := s.Idempotency.Reserve(ctx, expired); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Idempotency.Get(ctx, scope, "old"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an expired key, got %v", err)
+	}
+	if err := s.Idempotency.Reserve(ctx, expired); err != nil {
+		t.Errorf("Expected an expired key to be reserved again, got %v", err)
+	}
+	if purged, err := s.Idempotency.PurgeExpired(ctx); err != nil || purged < 1 {
+		t.Errorf("Expected the expired key to be purged, got %d, %v", purged, err)
+	}
+}
+
+func testWebhooks(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	event := unique("post.created")
+
+	hook := &store.Webhook{URL: "https://example.com/hook", Secret: "secret", Events: []string{event}}
+	if err := s.Webhooks.Create(ctx, hook); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if hook.ID == 0 || !hook.Active {
+		t.Fatalf("Expected an active webhook with ID, got %+v", hook)
+	}
+	other := &store.Webhook{URL: "https://example.com/other", Secret: "secret", Events: []string{unique("user.created")}}
+	if err := s.Webhooks.Create(ctx, other); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, err := s.Webhooks.GetByID(ctx, hook.ID)
+	if err != nil || got.Secret != "secret" || !got.Subscribed(event) {
+		t.Fatalf("Expected the webhook with secret and events, got %+v, %v", got, err)
+	}
+
+	active, err := s.Webhooks.ListActiveForEvent(ctx, event)
+	if err != nil || len(active) != 1 || active[0].ID != hook.ID {
+		t.Fatalf("Expected only the subscribed webhook, got %+v, %v", active, err)
+	}
+
+	deliver := func(success bool) (*store.WebhookDelivery, bool) {
+		t.Helper()
+		d := &store.WebhookDelivery{WebhookID: hook.ID, EventID: unique("evt"), Event: event,
+			Payload: []byte(`{"id":1}`), Attempt: 1, StatusCode: 500, Success: success}
+		disabled, err := s.Webhooks.RecordDelivery(ctx, d, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return d, disabled
+	}
+
+	// Ein Erfolg setzt den Zähler zurück, erst 2 Fehler in Folge deaktivieren
+	if _, disabled := deliver(false); disabled {
+		t.Error("Expected one failure not to disable the webhook")
+	}
+	deliver(true)
+	if _, disabled := deliver(false); disabled {
+		t.Error("Expected a success to reset the failure count")
+	}
+	last, disabled := deliver(false)
+	if !disabled {
+		t.Error("Expected the second failure in a row to disable the webhook")
+	}
+
+	got, _ = s.Webhooks.GetByID(ctx, hook.ID)
+	if got.Active || got.FailureCount != 2 || got.DisabledAt == nil {
+		t.Errorf("Expected a disabled webhook, got %+v", got)
+	}
+	if active, _ := s.Webhooks.ListActiveForEvent(ctx, event); len(active) != 0 {
+		t.Errorf("Expected disabled webhooks not to receive events, got %+v", active)
+	}
+
+	deliveries, err := s.Webhooks.ListDeliveries(ctx, hook.ID, 2, 0)
+	if err != nil || len(deliveries) != 2 || deliveries[0].ID != last.ID {
+		t.Fatalf("Expected the 2 newest deliveries, got %+v, %v", deliveries, err)
+	}
+	if rest, _ := s.Webhooks.ListDeliveries(ctx, hook.ID, 10, 2); len(rest) != 2 {
+		t.Errorf("Expected 2 older deliveries, got %d", len(rest))
+	}
+
+	delivery, err := s.Webhooks.GetDelivery(ctx, last.ID)
+	if err != nil || string(delivery.Payload) != `{"id":1}` || delivery.Success || delivery.StatusCode != 500 {
+		t.Errorf("Expected the stored delivery, got %+v, %v", delivery, err)
+	}
+
+	if err := s.Webhooks.Enable(ctx, hook.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, _ = s.Webhooks.GetByID(ctx, hook.ID)
+	if !got.Active || got.FailureCount != 0 || got.DisabledAt != nil {
+		t.Errorf("Expected an enabled webhook with reset counters, got %+v", got)
+	}
+
+	if err := s.Webhooks.Delete(ctx, hook.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Webhooks.Delete(ctx, hook.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	if _, err := s.Webhooks.GetByID(ctx, hook.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a deleted webhook, got %v", err)
+	}
+}
+
+func testAttachments(t *testing.T, s store.Storage) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "paula")
+	post := mustCreatePost(t, s, user.ID, "With files")
+	empty := mustCreatePost(t, s, user.ID, "Without files")
+
+	image := &store.Attachment{PostID: post.ID, Key: "posts/1/a.png", Filename: "a.png", ContentType: "image/png", Size: 1024, Width: 64, Height: 32}
+	pdf := &store.Attachment{PostID: post.ID, Key: "posts/1/b.pdf", Filename: "b.pdf", ContentType: "application/pdf", Size: 2048}
+	for _, a := range []*store.Attachment{image, pdf} {
+		if err := s.Attachments.Create(ctx, a); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if a.ID == 0 {
+			t.Fatal("Expected Create to set the ID")
+		}
+		mustParseTime(t, "created_at", a.CreatedAt)
+	}
+
+	attachments, err := s.Attachments.ListByPost(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(attachments) != 2 || attachments[0].ID != image.ID || attachments[1].ID != pdf.ID {
+		t.Fatalf("Expected both attachments in upload order, got %+v", attachments)
+	}
+	if got := attachments[0]; got.Key != image.Key || got.Width != 64 || got.Height != 32 || got.Size != 1024 || got.ContentType != "image/png" {
+		t.Errorf("Expected all fields to round-trip, got %+v", got)
+	}
+
+	if none, err := s.Attachments.ListByPost(ctx, empty.ID); err != nil || none == nil || len(none) != 0 {
+		t.Errorf("Expected an empty list, got %v, %v", none, err)
+	}
+}
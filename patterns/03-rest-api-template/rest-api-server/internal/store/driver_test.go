@@ -0,0 +1,27 @@
+package store_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+func TestSQLiteRejectsURLs(t *testing.T) {
+	_, _, err := store.Open("sqlite", store.Config{Addr: store.DefaultAddr("postgres"), MaxOpenConns: 1, MaxIdleConns: 1, MaxIdleTime: "1m"})
+	if err == nil {
+		t.Fatal("Expected a postgres URL to be rejected")
+	}
+	if strings.Contains(err.Error(), "adminpassword") {
+		t.Errorf("Expected the error not to leak the address, got %q", err)
+	}
+	if _, err := os.Stat("postgres:"); !os.IsNotExist(err) {
+		os.RemoveAll("postgres:")
+		t.Error("Expected no directory to be created for the URL")
+	}
+
+	if got := store.DefaultAddr("sqlite"); strings.Contains(got, "://") {
+		t.Errorf("Expected a file path as sqlite default, got %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type Follower struct {
+	UserID     int64  `json:"user_id"`
+	FollowerID int64  `json:"follower_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type FollowersStorage struct {
+	db router
+}
+
+// Follow lässt followerID dem User userID folgen
+func (s *FollowersStorage) Follow(ctx context.Context, followerID, userID int64) error {
+	query := `INSERT INTO followers (user_id, follower_id) VALUES ($1, $2)`
+
+	_, err := s.db.write(ctx).ExecContext(ctx, query, userID, followerID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Unfollow entfernt die Follow-Beziehung wieder
+func (s *FollowersStorage) Unfollow(ctx context.Context, followerID, userID int64) error {
+	query := `DELETE FROM followers WHERE user_id = $1 AND follower_id = $2`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, userID, followerID)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// IdempotencyRecord merkt sich einen Request und seine Antwort pro Idempotency-Key
+type IdempotencyRecord struct {
+	Scope       string // z.B. "user:42", damit Keys verschiedener User sich nicht stören
+	Key         string
+	Fingerprint string              // Hash aus Methode, Pfad und Body
+	StatusCode  int                 // 0 = Request läuft noch
+	Header      map[string][]string // Antwort-Header für den Replay, z.B. Content-Type und Location
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Completed ist true, sobald eine Antwort gespeichert wurde
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// marshalHeader speichert einen fehlenden Header als leeres Objekt statt null
+func marshalHeader(header map[string][]string) ([]byte, error) {
+	if header == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(header)
+}
+
+type IdempotencyStorage struct {
+	db router
+}
+
+// Get liefert einen nicht abgelaufenen Record oder ErrNotFound
+func (s *IdempotencyStorage) Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error) {
+	query := `
+	SELECT scope, key, fingerprint, status_code, response_headers, response_body, expires_at
+	FROM idempotency_keys
+	WHERE scope = $1 AND key = $2 AND expires_at > NOW()
+	`
+
+	var (
+		record IdempotencyRecord
+		header []byte
+	)
+	err := s.db.readPrimary(ctx).QueryRowContext(ctx, query, scope, key).Scan(
+		&record.Scope, &record.Key, &record.Fingerprint, &record.StatusCode,
+		&header, &record.Body, &record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(header, &record.Header); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// Reserve legt einen neuen Record an. Existiert der Key schon (und ist nicht
+// abgelaufen), gibt es ErrConflict – so gewinnt bei parallelen Retries nur einer.
+func (s *IdempotencyStorage) Reserve(ctx context.Context, record *IdempotencyRecord) error {
+	query := `
+	INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (scope, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status_code = 0, response_headers = '{}',
+			response_body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+	`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, record.Scope, record.Key, record.Fingerprint, record.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	if err := affectedOrNotFound(res); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Complete speichert die Antwort, die bei Retries wieder ausgespielt wird
+func (s *IdempotencyStorage) Complete(ctx context.Context, record *IdempotencyRecord) error {
+	query := `
+	UPDATE idempotency_keys
+	SET status_code = $3, response_headers = $4, response_body = $5
+	WHERE scope = $1 AND key = $2
+	`
+
+	header, err := marshalHeader(record.Header)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, record.Scope, record.Key, record.StatusCode, header, record.Body)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Delete gibt einen Key wieder frei, z.B. wenn der Request mit 5xx fehlschlug
+func (s *IdempotencyStorage) Delete(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`
+
+	_, err := s.db.write(ctx).ExecContext(ctx, query, scope, key)
+	return err
+}
+
+// PurgeExpired entfernt alle abgelaufenen Keys
+func (s *IdempotencyStorage) PurgeExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
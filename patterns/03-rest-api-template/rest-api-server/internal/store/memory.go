@@ -0,0 +1,1409 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryDB hält alle Tabellen des In-Memory Stores. Gedacht für Tests und Demos,
+// deshalb gibt es auch keine Transaktionen: WithTx führt fn direkt aus.
+type memoryDB struct {
+	sync.RWMutex
+	posts       map[int64]*Post
+	users       map[int64]*User
+	comments    map[int64]*Comment
+	followers   map[[2]int64]*Follower           // key: {userID, followerID}
+	reactions   map[reactionKey]string           // value: created_at
+	idemKeys    map[[2]string]*IdempotencyRecord // key: {scope, key}
+	webhooks    map[int64]*Webhook
+	deliveries  map[int64]*WebhookDelivery
+	attachments map[int64]*Attachment
+	audit       []AuditEvent
+	hidden      map[int64]string // postID → hidden_at
+	reports     map[int64]*Report
+	resets      map[string]passwordReset // key: token_hash
+	sessions    map[int64]*memorySession
+	refresh     map[string]*refreshToken // key: token_hash
+	apiKeys     map[int64]*memoryAPIKey
+	nextID      map[string]int64
+}
+
+// NewMemoryStorage erstellt einen Storage, der komplett im Speicher arbeitet
+func NewMemoryStorage() Storage {
+	m := &memoryDB{
+		posts:       make(map[int64]*Post),
+		users:       make(map[int64]*User),
+		comments:    make(map[int64]*Comment),
+		followers:   make(map[[2]int64]*Follower),
+		reactions:   make(map[reactionKey]string),
+		idemKeys:    make(map[[2]string]*IdempotencyRecord),
+		webhooks:    make(map[int64]*Webhook),
+		deliveries:  make(map[int64]*WebhookDelivery),
+		attachments: make(map[int64]*Attachment),
+		hidden:      make(map[int64]string),
+		reports:     make(map[int64]*Report),
+		resets:      make(map[string]passwordReset),
+		sessions:    make(map[int64]*memorySession),
+		refresh:     make(map[string]*refreshToken),
+		apiKeys:     make(map[int64]*memoryAPIKey),
+		nextID:      make(map[string]int64),
+	}
+
+	return Storage{
+		Posts:       &memoryPostsStore{m},
+		Users:       &memoryUsersStore{m},
+		Comments:    &memoryCommentsStore{m},
+		Followers:   &memoryFollowersStore{m},
+		Reactions:   &memoryReactionsStore{m},
+		Idempotency: &memoryIdempotencyStore{m},
+		Webhooks:    &memoryWebhooksStore{m},
+		Attachments: &memoryAttachmentsStore{m},
+		Audit:       &memoryAuditStore{m},
+		Reports:     &memoryReportsStore{m},
+
+		Sessions:       &memorySessionsStore{m},
+		PasswordResets: &memoryPasswordResetsStore{m},
+		APIKeys:        &memoryAPIKeysStore{m},
+	}
+}
+
+// id vergibt fortlaufende IDs pro Tabelle (wie bigserial). Aufrufer hält den Lock.
+func (m *memoryDB) id(table string) int64 {
+	m.nextID[table]++
+	return m.nextID[table]
+}
+
+// deletePost entfernt einen Post inkl. abhängiger Rows (wie ON DELETE CASCADE).
+// Aufrufer hält den Lock.
+func (m *memoryDB) deletePost(id int64) {
+	delete(m.posts, id)
+
+	for commentID, c := range m.comments {
+		if c.PostID == id {
+			delete(m.comments, commentID)
+		}
+	}
+	for key := range m.reactions {
+		if key.postID == id {
+			delete(m.reactions, key)
+		}
+	}
+	for attachmentID, a := range m.attachments {
+		if a.PostID == id {
+			delete(m.attachments, attachmentID)
+		}
+	}
+	for reportID, r := range m.reports {
+		if r.PostID == id {
+			delete(m.reports, reportID)
+		}
+	}
+	delete(m.hidden, id)
+}
+
+// deleteUser entfernt einen User inkl. abhängiger Rows (wie ON DELETE CASCADE).
+// Aufrufer hält den Lock.
+func (m *memoryDB) deleteUser(id int64) {
+	delete(m.users, id)
+
+	for postID, p := range m.posts {
+		if p.UserID == id {
+			m.deletePost(postID)
+		}
+	}
+	for commentID, c := range m.comments {
+		if c.UserID == id {
+			delete(m.comments, commentID)
+		}
+	}
+	for key := range m.followers {
+		if key[0] == id || key[1] == id {
+			delete(m.followers, key)
+		}
+	}
+	for key := range m.reactions {
+		if key.userID == id {
+			delete(m.reactions, key)
+		}
+	}
+	for hash, reset := range m.resets {
+		if reset.userID == id {
+			delete(m.resets, hash)
+		}
+	}
+	for sessionID, session := range m.sessions {
+		if session.UserID == id {
+			m.deleteSession(sessionID)
+		}
+	}
+	for reportID, r := range m.reports {
+		switch {
+		case r.ReporterID == id:
+			delete(m.reports, reportID)
+		case r.ResolvedBy != nil && *r.ResolvedBy == id:
+			r.ResolvedBy = nil
+		}
+	}
+}
+
+// visible ist false für gelöschte und ausgeblendete Posts. Aufrufer hält den Lock.
+func (m *memoryDB) visible(p *Post) bool {
+	return p.DeletedAt == nil && m.hidden[p.ID] == ""
+}
+
+// timeFormat ist RFC3339 mit fester Nanosekunden-Breite, damit sich
+// Zeitstempel als Strings korrekt sortieren lassen
+const timeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// now liefert den aktuellen Zeitstempel als String wie der Postgres Store
+func now() string {
+	return time.Now().UTC().Format(timeFormat)
+}
+
+// deletedBefore prüft, ob ein Soft Delete älter als before ist
+func deletedBefore(deletedAt *string, before time.Time) bool {
+	if deletedAt == nil {
+		return false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, *deletedAt)
+	return err == nil && t.Before(before)
+}
+
+// sortedIDs liefert ids sortiert und ohne Duplikate, wie ORDER BY bei IN-Listen
+func sortedIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	sorted := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			sorted = append(sorted, id)
+		}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// page schneidet limit/offset aus einer bereits sortierten Liste
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+
+	end := offset + limit
+	if limit <= 0 || end > len(items) {
+		end = len(items)
+	}
+
+	return items[offset:end]
+}
+
+type memoryPostsStore struct {
+	m *memoryDB
+}
+
+// copyPost verhindert, dass Aufrufer den internen Zustand verändern
+func copyPost(p *Post) Post {
+	c := *p
+	c.Tags = append([]string{}, p.Tags...)
+	return c
+}
+
+func (s *memoryPostsStore) Create(ctx context.Context, post *Post) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	post.ID = s.m.id("posts")
+	post.CreatedAt = now()
+	post.UpdatedAt = post.CreatedAt
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+
+	stored := copyPost(post)
+	s.m.posts[post.ID] = &stored
+
+	return nil
+}
+
+func (s *memoryPostsStore) GetByID(ctx context.Context, id int64) (*Post, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	p, ok := s.m.posts[id]
+	if !ok || !s.m.visible(p) {
+		return nil, ErrNotFound
+	}
+
+	post := copyPost(p)
+	return &post, nil
+}
+
+func (s *memoryPostsStore) GetByIDIncludingHidden(ctx context.Context, id int64) (*Post, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	p, ok := s.m.posts[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	post := copyPost(p)
+	return &post, nil
+}
+
+func (s *memoryPostsStore) List(ctx context.Context, limit, offset int) ([]Post, error) {
+	return s.list(func(*Post) bool { return true }, limit, offset), nil
+}
+
+func (s *memoryPostsStore) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]Post, error) {
+	return s.list(func(p *Post) bool { return p.UserID == userID }, limit, offset), nil
+}
+
+func (s *memoryPostsStore) ListAfter(ctx context.Context, afterID int64, limit int) ([]Post, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	posts := []Post{}
+	for _, p := range s.m.posts {
+		if p.ID > afterID && s.m.visible(p) {
+			posts = append(posts, copyPost(p))
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+
+	return page(posts, limit, 0), nil
+}
+
+func (s *memoryPostsStore) ListByUsers(ctx context.Context, userIDs []int64, limit int) ([]Post, error) {
+	posts := []Post{}
+	for _, id := range sortedIDs(userIDs) {
+		userID := id
+		posts = append(posts, s.list(func(p *Post) bool { return p.UserID == userID }, limit, 0)...)
+	}
+
+	return posts, nil
+}
+
+func (s *memoryPostsStore) CreateBatch(ctx context.Context, posts []*Post) error {
+	for _, post := range posts {
+		if err := s.Create(ctx, post); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// list liefert die nicht gelöschten Posts, auf die match passt, neueste zuerst
+func (s *memoryPostsStore) list(match func(*Post) bool, limit, offset int) []Post {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	posts := []Post{}
+	for _, p := range s.m.posts {
+		if s.m.visible(p) && match(p) {
+			posts = append(posts, copyPost(p))
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].CreatedAt != posts[j].CreatedAt {
+			return posts[i].CreatedAt > posts[j].CreatedAt
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	return page(posts, limit, offset)
+}
+
+func (s *memoryPostsStore) Update(ctx context.Context, post *Post) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	p, ok := s.m.posts[post.ID]
+	if !ok || !s.m.visible(p) {
+		return ErrNotFound
+	}
+
+	p.Title = post.Title
+	p.Content = post.Content
+	p.Tags = append([]string{}, post.Tags...)
+	p.UpdatedAt = now()
+	post.UpdatedAt = p.UpdatedAt
+
+	return nil
+}
+
+func (s *memoryPostsStore) Delete(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	p, ok := s.m.posts[id]
+	if !ok || p.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	deletedAt := now()
+	p.DeletedAt = &deletedAt
+
+	return nil
+}
+
+func (s *memoryPostsStore) DeleteByUser(ctx context.Context, userID int64) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var deleted int64
+	for _, p := range s.m.posts {
+		if p.UserID == userID && p.DeletedAt == nil {
+			deletedAt := now()
+			p.DeletedAt = &deletedAt
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *memoryPostsStore) Restore(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	p, ok := s.m.posts[id]
+	if !ok || p.DeletedAt == nil {
+		return ErrNotFound
+	}
+
+	p.DeletedAt = nil
+
+	return nil
+}
+
+func (s *memoryPostsStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	p, ok := s.m.posts[id]
+	if !ok || p.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	switch {
+	case !hidden:
+		delete(s.m.hidden, id)
+	case s.m.hidden[id] == "":
+		s.m.hidden[id] = now()
+	}
+
+	return nil
+}
+
+// Lock prüft nur, ob der Post existiert; der Memory Store hat keine Transaktionen
+func (s *memoryPostsStore) Lock(ctx context.Context, id int64) error {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	if p, ok := s.m.posts[id]; !ok || p.DeletedAt != nil {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *memoryPostsStore) ListDeleted(ctx context.Context, limit, offset int) ([]Post, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	posts := []Post{}
+	for _, p := range s.m.posts {
+		if p.DeletedAt != nil {
+			posts = append(posts, copyPost(p))
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if *posts[i].DeletedAt != *posts[j].DeletedAt {
+			return *posts[i].DeletedAt > *posts[j].DeletedAt
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	return page(posts, limit, offset), nil
+}
+
+func (s *memoryPostsStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var purged int64
+	for id, p := range s.m.posts {
+		if deletedBefore(p.DeletedAt, before) {
+			s.m.deletePost(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Search ist das tokenisierte Gegenstück zur tsvector-Suche im Postgres Store
+func (s *memoryPostsStore) Search(ctx context.Context, q string, filter SearchFilter) (*SearchResult, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	posts := make([]*Post, 0, len(s.m.posts))
+	for _, p := range s.m.posts {
+		if s.m.visible(p) {
+			posts = append(posts, p)
+		}
+	}
+
+	return searchPosts(posts, q, filter), nil
+}
+
+type memoryUsersStore struct {
+	m *memoryDB
+}
+
+func (s *memoryUsersStore) Create(ctx context.Context, user *User) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Wie die UNIQUE Constraints in Postgres, auch gegen soft-deleted User
+	for _, u := range s.m.users {
+		if u.Username == user.Username || strings.EqualFold(u.Email, user.Email) {
+			return ErrConflict
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+	user.ID = s.m.id("users")
+	user.Created = now()
+	user.IsActive = true
+
+	stored := *user
+	s.m.users[user.ID] = &stored
+
+	return nil
+}
+
+func (s *memoryUsersStore) GetByIDs(ctx context.Context, ids []int64) ([]User, error) {
+	users := []User{}
+	for _, id := range sortedIDs(ids) {
+		if user, err := s.GetByID(ctx, id); err == nil {
+			users = append(users, *user)
+		}
+	}
+
+	return users, nil
+}
+
+func (s *memoryUsersStore) GetByID(ctx context.Context, id int64) (*User, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	u, ok := s.m.users[id]
+	if !ok || u.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	// Den Passwort-Hash liefert wie in Postgres nur GetByEmail
+	user := *u
+	user.Passwort = ""
+	return &user, nil
+}
+
+func (s *memoryUsersStore) GetByEmail(ctx context.Context, email string) (*User, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	for _, u := range s.m.users {
+		if u.DeletedAt == nil && strings.EqualFold(u.Email, email) {
+			user := *u
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *memoryUsersStore) List(ctx context.Context, limit, offset int) ([]User, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	users := []User{}
+	for _, u := range s.m.users {
+		if u.DeletedAt == nil {
+			user := *u
+			user.Passwort = ""
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return page(users, limit, offset), nil
+}
+
+func (s *memoryUsersStore) SetRole(ctx context.Context, id int64, role string) error {
+	return s.update(id, func(u *User) { u.Role = role })
+}
+
+func (s *memoryUsersStore) UpdatePassword(ctx context.Context, id int64, hash string) error {
+	return s.update(id, func(u *User) { u.Passwort = hash })
+}
+
+func (s *memoryUsersStore) SetActive(ctx context.Context, id int64, active bool) error {
+	return s.update(id, func(u *User) { u.IsActive = active })
+}
+
+func (s *memoryUsersStore) RevokeTokens(ctx context.Context, id int64) error {
+	return s.update(id, func(u *User) { u.TokenVersion++ })
+}
+
+// update wendet fn auf einen nicht gelöschten User an
+func (s *memoryUsersStore) update(id int64, fn func(*User)) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u, ok := s.m.users[id]
+	if !ok || u.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	fn(u)
+
+	return nil
+}
+
+func (s *memoryUsersStore) SetAvatar(ctx context.Context, id int64, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u, ok := s.m.users[id]
+	if !ok || u.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	u.AvatarKey = key
+
+	return nil
+}
+
+func (s *memoryUsersStore) Delete(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u, ok := s.m.users[id]
+	if !ok || u.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	deletedAt := now()
+	u.DeletedAt = &deletedAt
+
+	return nil
+}
+
+func (s *memoryUsersStore) Restore(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	u, ok := s.m.users[id]
+	if !ok || u.DeletedAt == nil {
+		return ErrNotFound
+	}
+
+	u.DeletedAt = nil
+
+	return nil
+}
+
+func (s *memoryUsersStore) ListDeleted(ctx context.Context, limit, offset int) ([]User, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	users := []User{}
+	for _, u := range s.m.users {
+		if u.DeletedAt != nil {
+			user := *u
+			user.Passwort = ""
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if *users[i].DeletedAt != *users[j].DeletedAt {
+			return *users[i].DeletedAt > *users[j].DeletedAt
+		}
+		return users[i].ID > users[j].ID
+	})
+
+	return page(users, limit, offset), nil
+}
+
+func (s *memoryUsersStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var purged int64
+	for id, u := range s.m.users {
+		if deletedBefore(u.DeletedAt, before) {
+			s.m.deleteUser(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+type memoryAttachmentsStore struct {
+	m *memoryDB
+}
+
+func (s *memoryAttachmentsStore) Create(ctx context.Context, a *Attachment) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if _, ok := s.m.posts[a.PostID]; !ok {
+		return ErrNotFound
+	}
+
+	a.ID = s.m.id("post_attachments")
+	a.CreatedAt = now()
+
+	stored := *a
+	s.m.attachments[a.ID] = &stored
+
+	return nil
+}
+
+func (s *memoryAttachmentsStore) ListByPost(ctx context.Context, postID int64) ([]Attachment, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	attachments := []Attachment{}
+	for _, a := range s.m.attachments {
+		if a.PostID == postID {
+			attachments = append(attachments, *a)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+
+	return attachments, nil
+}
+
+type memoryCommentsStore struct {
+	m *memoryDB
+}
+
+func (s *memoryCommentsStore) Create(ctx context.Context, comment *Comment) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	comment.ID = s.m.id("comments")
+	comment.CreatedAt = now()
+
+	stored := *comment
+	s.m.comments[comment.ID] = &stored
+
+	return nil
+}
+
+func (s *memoryCommentsStore) GetByPostID(ctx context.Context, postID int64) ([]Comment, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	comments := []Comment{}
+	for _, c := range s.m.comments {
+		if c.PostID == postID {
+			comments = append(comments, *c)
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+
+	return comments, nil
+}
+
+func (s *memoryCommentsStore) GetByPostIDs(ctx context.Context, postIDs []int64, limit int) ([]Comment, error) {
+	comments := []Comment{}
+	for _, id := range sortedIDs(postIDs) {
+		c, _ := s.GetByPostID(ctx, id)
+		comments = append(comments, c[:min(limit, len(c))]...)
+	}
+
+	return comments, nil
+}
+
+type memoryFollowersStore struct {
+	m *memoryDB
+}
+
+func (s *memoryFollowersStore) Follow(ctx context.Context, followerID, userID int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	key := [2]int64{userID, followerID}
+	if _, ok := s.m.followers[key]; ok {
+		return ErrConflict
+	}
+
+	s.m.followers[key] = &Follower{UserID: userID, FollowerID: followerID, CreatedAt: now()}
+
+	return nil
+}
+
+func (s *memoryFollowersStore) Unfollow(ctx context.Context, followerID, userID int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	key := [2]int64{userID, followerID}
+	if _, ok := s.m.followers[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.followers, key)
+
+	return nil
+}
+
+type reactionKey struct {
+	userID   int64
+	postID   int64
+	reaction string
+}
+
+type memoryReactionsStore struct {
+	m *memoryDB
+}
+
+func (s *memoryReactionsStore) Add(ctx context.Context, userID, postID int64, reaction string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	key := reactionKey{userID, postID, reaction}
+	if _, ok := s.m.reactions[key]; !ok {
+		s.m.reactions[key] = now()
+	}
+
+	return nil
+}
+
+func (s *memoryReactionsStore) Remove(ctx context.Context, userID, postID int64, reaction string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	key := reactionKey{userID, postID, reaction}
+	if _, ok := s.m.reactions[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.reactions, key)
+
+	return nil
+}
+
+func (s *memoryReactionsStore) CountsForPosts(ctx context.Context, postIDs []int64) (map[int64]ReactionCounts, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	wanted := make(map[int64]bool, len(postIDs))
+	for _, id := range postIDs {
+		wanted[id] = true
+	}
+
+	counts := make(map[int64]ReactionCounts, len(postIDs))
+	for key := range s.m.reactions {
+		if !wanted[key.postID] {
+			continue
+		}
+		if counts[key.postID] == nil {
+			counts[key.postID] = ReactionCounts{}
+		}
+		counts[key.postID][key.reaction]++
+	}
+
+	return counts, nil
+}
+
+type memoryIdempotencyStore struct {
+	m *memoryDB
+}
+
+func (s *memoryIdempotencyStore) Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	r, ok := s.m.idemKeys[[2]string{scope, key}]
+	if !ok || !r.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	record := *r
+	return &record, nil
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *IdempotencyRecord) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	k := [2]string{record.Scope, record.Key}
+	if r, ok := s.m.idemKeys[k]; ok && r.ExpiresAt.After(time.Now()) {
+		return ErrConflict
+	}
+
+	stored := *record
+	stored.StatusCode, stored.Header, stored.Body = 0, nil, nil
+	s.m.idemKeys[k] = &stored
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record *IdempotencyRecord) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	r, ok := s.m.idemKeys[[2]string{record.Scope, record.Key}]
+	if !ok {
+		return ErrNotFound
+	}
+
+	r.StatusCode = record.StatusCode
+	r.Header = make(map[string][]string, len(record.Header))
+	for k, v := range record.Header {
+		r.Header[k] = append([]string{}, v...)
+	}
+	r.Body = append([]byte{}, record.Body...)
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Delete(ctx context.Context, scope, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	delete(s.m.idemKeys, [2]string{scope, key})
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var purged int64
+	for k, r := range s.m.idemKeys {
+		if !r.ExpiresAt.After(time.Now()) {
+			delete(s.m.idemKeys, k)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+type memoryAuditStore struct {
+	m *memoryDB
+}
+
+func (s *memoryAuditStore) Record(ctx context.Context, e *AuditEvent) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	e.ID = s.m.id("audit_events")
+	e.CreatedAt = now()
+
+	stored := *e
+	s.m.audit = append(s.m.audit, stored)
+
+	return nil
+}
+
+func (s *memoryAuditStore) List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	from, to := "", ""
+	if !filter.From.IsZero() {
+		from = filter.From.UTC().Format(timeFormat)
+	}
+	if !filter.To.IsZero() {
+		to = filter.To.UTC().Format(timeFormat)
+	}
+
+	events := []AuditEvent{}
+	// Rückwärts, damit die neuesten zuerst kommen
+	for i := len(s.m.audit) - 1; i >= 0; i-- {
+		e := s.m.audit[i]
+		switch {
+		case filter.ActorType != "" && e.ActorType != filter.ActorType,
+			filter.ActorID != "" && e.ActorID != filter.ActorID,
+			filter.TargetType != "" && e.TargetType != filter.TargetType,
+			filter.TargetID != 0 && e.TargetID != filter.TargetID,
+			from != "" && e.CreatedAt < from,
+			to != "" && e.CreatedAt >= to:
+			continue
+		}
+		events = append(events, e)
+	}
+
+	return page(events, filter.Limit, filter.Offset), nil
+}
+
+type memoryReportsStore struct {
+	m *memoryDB
+}
+
+func (s *memoryReportsStore) Create(ctx context.Context, report *Report) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Wie UNIQUE (post_id, reporter_id), egal in welchem Status
+	for _, r := range s.m.reports {
+		if r.PostID == report.PostID && r.ReporterID == report.ReporterID {
+			return ErrConflict
+		}
+	}
+
+	report.ID = s.m.id("reports")
+	report.Status = ReportOpen
+	report.CreatedAt = now()
+
+	stored := *report
+	s.m.reports[report.ID] = &stored
+
+	return nil
+}
+
+func (s *memoryReportsStore) CountOpen(ctx context.Context, postID int64) (int, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	count := 0
+	for _, r := range s.m.reports {
+		if r.PostID == postID && r.Status == ReportOpen {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *memoryReportsStore) Queue(ctx context.Context, limit, offset int) ([]ReportedPost, error) {
+	return page(s.queue(0), limit, offset), nil
+}
+
+func (s *memoryReportsStore) GetQueued(ctx context.Context, postID int64) (*ReportedPost, error) {
+	items := s.queue(postID)
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &items[0], nil
+}
+
+// queue gruppiert die offenen Meldungen nicht gelöschter Posts, postID 0 = alle
+func (s *memoryReportsStore) queue(postID int64) []ReportedPost {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	byPost := make(map[int64]*ReportedPost)
+	for _, r := range s.m.reports {
+		p, ok := s.m.posts[r.PostID]
+		if r.Status != ReportOpen || !ok || p.DeletedAt != nil || postID != 0 && r.PostID != postID {
+			continue
+		}
+
+		item, ok := byPost[r.PostID]
+		if !ok {
+			item = &ReportedPost{
+				PostID:          p.ID,
+				AuthorID:        p.UserID,
+				Title:           p.Title,
+				Hidden:          s.m.hidden[p.ID] != "",
+				Reasons:         []string{},
+				FirstReportedAt: r.CreatedAt,
+				LastReportedAt:  r.CreatedAt,
+			}
+			byPost[r.PostID] = item
+		}
+
+		item.Reports++
+		if !slices.Contains(item.Reasons, r.Reason) {
+			item.Reasons = append(item.Reasons, r.Reason)
+		}
+		item.FirstReportedAt = min(item.FirstReportedAt, r.CreatedAt)
+		item.LastReportedAt = max(item.LastReportedAt, r.CreatedAt)
+	}
+
+	items := make([]ReportedPost, 0, len(byPost))
+	for _, item := range byPost {
+		sort.Strings(item.Reasons)
+		items = append(items, *item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Reports != b.Reports {
+			return a.Reports > b.Reports
+		}
+		if a.FirstReportedAt != b.FirstReportedAt {
+			return a.FirstReportedAt < b.FirstReportedAt
+		}
+		return a.PostID < b.PostID
+	})
+
+	return items
+}
+
+func (s *memoryReportsStore) Resolve(ctx context.Context, postID int64, status string, moderatorID int64) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var resolved int64
+	for _, r := range s.m.reports {
+		if r.PostID == postID && r.Status == ReportOpen {
+			resolvedAt, by := now(), moderatorID
+			r.Status, r.ResolvedAt, r.ResolvedBy = status, &resolvedAt, &by
+			resolved++
+		}
+	}
+
+	return resolved, nil
+}
+
+type passwordReset struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+type memoryPasswordResetsStore struct {
+	m *memoryDB
+}
+
+func (s *memoryPasswordResetsStore) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for hash, reset := range s.m.resets {
+		if reset.userID == userID {
+			delete(s.m.resets, hash)
+		}
+	}
+	s.m.resets[tokenHash] = passwordReset{userID: userID, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (s *memoryPasswordResetsStore) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	reset, ok := s.m.resets[tokenHash]
+	if !ok || !reset.expiresAt.After(time.Now()) {
+		return 0, ErrNotFound
+	}
+	delete(s.m.resets, tokenHash)
+
+	return reset.userID, nil
+}
+
+func (s *memoryPasswordResetsStore) PurgeExpired(ctx context.Context) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var purged int64
+	for hash, reset := range s.m.resets {
+		if !reset.expiresAt.After(time.Now()) {
+			delete(s.m.resets, hash)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+type memorySession struct {
+	Session
+	expiresAt time.Time
+	revoked   bool
+}
+
+func (s *memorySession) active() bool {
+	return !s.revoked && s.expiresAt.After(time.Now())
+}
+
+type refreshToken struct {
+	sessionID int64
+	used      bool
+}
+
+// deleteSession entfernt eine Session samt Refresh Tokens. Aufrufer hält den Lock.
+func (m *memoryDB) deleteSession(id int64) {
+	delete(m.sessions, id)
+
+	for hash, t := range m.refresh {
+		if t.sessionID == id {
+			delete(m.refresh, hash)
+		}
+	}
+}
+
+type memorySessionsStore struct {
+	m *memoryDB
+}
+
+func (s *memorySessionsStore) Create(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if _, ok := s.m.users[session.UserID]; !ok {
+		return ErrNotFound
+	}
+
+	session.ID = s.m.id("sessions")
+	session.CreatedAt = now()
+	session.LastUsedAt = session.CreatedAt
+	session.ExpiresAt = expiresAt.UTC().Format(timeFormat)
+
+	s.m.sessions[session.ID] = &memorySession{Session: *session, expiresAt: expiresAt}
+	s.m.refresh[tokenHash] = &refreshToken{sessionID: session.ID}
+
+	return nil
+}
+
+func (s *memorySessionsStore) Get(ctx context.Context, id int64) (*Session, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	session, ok := s.m.sessions[id]
+	if !ok || !session.active() {
+		return nil, ErrNotFound
+	}
+
+	result := session.Session
+	return &result, nil
+}
+
+func (s *memorySessionsStore) GetByToken(ctx context.Context, tokenHash string) (*Session, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	t, ok := s.m.refresh[tokenHash]
+	if !ok || t.used {
+		return nil, ErrNotFound
+	}
+
+	session := s.m.sessions[t.sessionID]
+	if !session.active() {
+		return nil, ErrNotFound
+	}
+
+	result := session.Session
+	return &result, nil
+}
+
+func (s *memorySessionsStore) Rotate(ctx context.Context, oldHash, newHash string) (*Session, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	t, ok := s.m.refresh[oldHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	session := s.m.sessions[t.sessionID]
+	if t.used && !session.revoked {
+		result := session.Session
+		return &result, ErrTokenReused
+	}
+	if t.used || !session.active() {
+		return nil, ErrNotFound
+	}
+
+	t.used = true
+	session.LastUsedAt = now()
+	s.m.refresh[newHash] = &refreshToken{sessionID: session.ID}
+
+	result := session.Session
+	return &result, nil
+}
+
+func (s *memorySessionsStore) ListByUser(ctx context.Context, userID int64) ([]Session, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	sessions := []Session{}
+	for _, session := range s.m.sessions {
+		if session.UserID == userID && session.active() {
+			sessions = append(sessions, session.Session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].LastUsedAt != sessions[j].LastUsedAt {
+			return sessions[i].LastUsedAt > sessions[j].LastUsedAt
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+func (s *memorySessionsStore) Revoke(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	session, ok := s.m.sessions[id]
+	if !ok || session.revoked {
+		return ErrNotFound
+	}
+	session.revoked = true
+
+	return nil
+}
+
+func (s *memorySessionsStore) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var revoked int64
+	for _, session := range s.m.sessions {
+		if session.UserID == userID && session.active() {
+			session.revoked = true
+			revoked++
+		}
+	}
+
+	return revoked, nil
+}
+
+func (s *memorySessionsStore) PurgeExpired(ctx context.Context) (int64, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var purged int64
+	for id, session := range s.m.sessions {
+		if !session.active() {
+			s.m.deleteSession(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+type memoryAPIKey struct {
+	APIKey
+	hash      string
+	expiresAt time.Time
+	lastUsed  time.Time
+}
+
+type memoryAPIKeysStore struct {
+	m *memoryDB
+}
+
+func (s *memoryAPIKeysStore) Create(ctx context.Context, key *APIKey, hash string, expiresAt time.Time) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for _, k := range s.m.apiKeys {
+		if k.hash == hash {
+			return ErrConflict
+		}
+	}
+
+	key.ID = s.m.id("api_keys")
+	key.CreatedAt = now()
+	key.ExpiresAt = expiresAt.UTC().Format(timeFormat)
+
+	stored := *key
+	stored.Scopes = slices.Clone(key.Scopes)
+	s.m.apiKeys[key.ID] = &memoryAPIKey{APIKey: stored, hash: hash, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (s *memoryAPIKeysStore) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	for _, k := range s.m.apiKeys {
+		if k.hash == hash && k.RevokedAt == nil && k.expiresAt.After(time.Now()) {
+			return k.copy(), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *memoryAPIKeysStore) List(ctx context.Context, limit, offset int) ([]APIKey, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	keys := []APIKey{}
+	for _, k := range s.m.apiKeys {
+		keys = append(keys, *k.copy())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+
+	return page(keys, limit, offset), nil
+}
+
+func (s *memoryAPIKeysStore) Revoke(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	k, ok := s.m.apiKeys[id]
+	if !ok || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+
+	revokedAt := now()
+	k.RevokedAt = &revokedAt
+
+	return nil
+}
+
+func (s *memoryAPIKeysStore) Touch(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	k, ok := s.m.apiKeys[id]
+	if !ok || time.Since(k.lastUsed) < time.Minute {
+		return nil
+	}
+
+	k.lastUsed = time.Now()
+	lastUsed := now()
+	k.LastUsedAt = &lastUsed
+
+	return nil
+}
+
+// copy liefert eine Kopie ohne gemeinsame Slices und Pointer
+func (k *memoryAPIKey) copy() *APIKey {
+	key := k.APIKey
+	key.Scopes = slices.Clone(k.Scopes)
+	if k.LastUsedAt != nil {
+		lastUsed := *k.LastUsedAt
+		key.LastUsedAt = &lastUsed
+	}
+	if k.RevokedAt != nil {
+		revokedAt := *k.RevokedAt
+		key.RevokedAt = &revokedAt
+	}
+
+	return &key
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+type memoryWebhooksStore struct {
+	m *memoryDB
+}
+
+func copyWebhook(w *Webhook) Webhook {
+	c := *w
+	c.Events = append([]string{}, w.Events...)
+	return c
+}
+
+func (s *memoryWebhooksStore) Create(ctx context.Context, hook *Webhook) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	hook.ID = s.m.id("webhooks")
+	hook.Active = true
+	hook.CreatedAt = now()
+
+	stored := copyWebhook(hook)
+	s.m.webhooks[hook.ID] = &stored
+
+	return nil
+}
+
+func (s *memoryWebhooksStore) GetByID(ctx context.Context, id int64) (*Webhook, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	w, ok := s.m.webhooks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	hook := copyWebhook(w)
+	return &hook, nil
+}
+
+func (s *memoryWebhooksStore) List(ctx context.Context) ([]Webhook, error) {
+	return s.list(func(*Webhook) bool { return true }), nil
+}
+
+func (s *memoryWebhooksStore) ListActiveForEvent(ctx context.Context, event string) ([]Webhook, error) {
+	return s.list(func(w *Webhook) bool { return w.Active && w.Subscribed(event) }), nil
+}
+
+func (s *memoryWebhooksStore) list(match func(*Webhook) bool) []Webhook {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	hooks := []Webhook{}
+	for _, w := range s.m.webhooks {
+		if match(w) {
+			hooks = append(hooks, copyWebhook(w))
+		}
+	}
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+
+	return hooks
+}
+
+func (s *memoryWebhooksStore) Delete(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if _, ok := s.m.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.webhooks, id)
+	for deliveryID, d := range s.m.deliveries {
+		if d.WebhookID == id {
+			delete(s.m.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryWebhooksStore) Enable(ctx context.Context, id int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	w, ok := s.m.webhooks[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	w.Active = true
+	w.FailureCount = 0
+	w.DisabledAt = nil
+
+	return nil
+}
+
+func (s *memoryWebhooksStore) RecordDelivery(ctx context.Context, d *WebhookDelivery, maxFailures int) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	w, ok := s.m.webhooks[d.WebhookID]
+	if !ok {
+		return false, ErrNotFound
+	}
+
+	d.ID = s.m.id("webhook_deliveries")
+	d.CreatedAt = now()
+
+	stored := *d
+	s.m.deliveries[d.ID] = &stored
+
+	if d.Success {
+		w.FailureCount = 0
+		return false, nil
+	}
+
+	w.FailureCount++
+	if w.Active && w.FailureCount >= maxFailures {
+		disabledAt := now()
+		w.Active = false
+		w.DisabledAt = &disabledAt
+	}
+
+	return !w.Active, nil
+}
+
+func (s *memoryWebhooksStore) ListDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]WebhookDelivery, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	deliveries := []WebhookDelivery{}
+	for _, d := range s.m.deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, *d)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	return page(deliveries, limit, offset), nil
+}
+
+func (s *memoryWebhooksStore) GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	d, ok := s.m.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	delivery := *d
+	return &delivery, nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PasswordResetsStorage struct {
+	db router
+}
+
+// Create legt einen Reset-Token an und verwirft ältere Tokens des Users,
+// damit immer nur der zuletzt verschickte Link funktioniert
+func (s *PasswordResetsStorage) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	if _, err := s.db.write(ctx).ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+
+	_, err := s.db.write(ctx).ExecContext(ctx, query, tokenHash, userID, expiresAt)
+	return err
+}
+
+// Consume löst einen gültigen Token ein und liefert die User-ID. Der Token wird
+// dabei gelöscht; ErrNotFound für unbekannte, benutzte oder abgelaufene Tokens.
+func (s *PasswordResetsStorage) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	query := `DELETE FROM password_resets WHERE token_hash = $1 AND expires_at > NOW() RETURNING user_id`
+
+	var userID int64
+	if err := s.db.write(ctx).QueryRowContext(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// PurgeExpired entfernt alle abgelaufenen Tokens
+func (s *PasswordResetsStorage) PurgeExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.write(ctx).ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -15,11 +17,14 @@ type Post struct {
 	Tags      []string `json:"tags"`
 	CreatedAt string   `json:"created_at"`
 	UpdatedAt string   `json:"updated_at"`
+	DeletedAt *string  `json:"deleted_at,omitempty"`
+
+	Reactions ReactionCounts `json:"reactions,omitempty"`
 }
 
 // das ist der ganze code um einen neuen row in die Database zu implementieren.
 type PostsStorage struct {
-	db *sql.DB
+	db router
 }
 
 func (s *PostsStorage) Create(ctx context.Context, post *Post) error {
@@ -28,7 +33,7 @@ func (s *PostsStorage) Create(ctx context.Context, post *Post) error {
 	VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at
 	`
 
-	err := s.db.QueryRowContext(ctx, query, post.Title, post.Content, post.UserID,
+	err := s.db.write(ctx).QueryRowContext(ctx, query, post.Title, post.Content, post.UserID,
 		pq.Array(post.Tags),
 	).Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
 
@@ -39,3 +44,354 @@ func (s *PostsStorage) Create(ctx context.Context, post *Post) error {
 	return nil
 
 }
+
+// GetByID liefert nur Posts, die nicht soft-deleted und nicht ausgeblendet sind
+func (s *PostsStorage) GetByID(ctx context.Context, id int64) (*Post, error) {
+	return s.getByID(ctx, id, `hidden_at IS NULL`)
+}
+
+// GetByIDIncludingHidden liefert auch ausgeblendete Posts, z.B. damit sie
+// noch gelöscht werden können
+func (s *PostsStorage) GetByIDIncludingHidden(ctx context.Context, id int64) (*Post, error) {
+	return s.getByID(ctx, id, `TRUE`)
+}
+
+func (s *PostsStorage) getByID(ctx context.Context, id int64, hidden string) (*Post, error) {
+	query := `
+	SELECT id, title, content, user_id, tags, created_at, updated_at
+	FROM posts
+	WHERE id = $1 AND deleted_at IS NULL AND ` + hidden
+
+	var post Post
+	err := s.db.read(ctx).QueryRowContext(ctx, query, id).Scan(
+		&post.ID, &post.Title, &post.Content, &post.UserID,
+		pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+// List liefert den Feed: alle nicht gelöschten Posts, neueste zuerst
+func (s *PostsStorage) List(ctx context.Context, limit, offset int) ([]Post, error) {
+	query := `
+	SELECT id, title, content, user_id, tags, created_at, updated_at
+	FROM posts
+	WHERE deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY created_at DESC, id DESC
+	LIMIT $1 OFFSET $2
+	`
+
+	return s.list(ctx, query, limit, offset)
+}
+
+// ListByUser liefert die Posts eines Users, neueste zuerst
+func (s *PostsStorage) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]Post, error) {
+	query := `
+	SELECT id, title, content, user_id, tags, created_at, updated_at
+	FROM posts
+	WHERE user_id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY created_at DESC, id DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	return s.list(ctx, query, userID, limit, offset)
+}
+
+// ListAfter blättert per Keyset in ID-Reihenfolge durch alle nicht gelöschten
+// Posts, z.B. für Exporte. Anders als OFFSET bleibt das auch tief hinten schnell.
+func (s *PostsStorage) ListAfter(ctx context.Context, afterID int64, limit int) ([]Post, error) {
+	query := `
+	SELECT id, title, content, user_id, tags, created_at, updated_at
+	FROM posts
+	WHERE id > $1 AND deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY id
+	LIMIT $2
+	`
+
+	return s.list(ctx, query, afterID, limit)
+}
+
+// ListByUsers liefert für jeden User höchstens limit Posts, neueste zuerst.
+// Damit lädt z.B. GraphQL die Posts vieler User mit einer Query.
+func (s *PostsStorage) ListByUsers(ctx context.Context, userIDs []int64, limit int) ([]Post, error) {
+	if len(userIDs) == 0 {
+		return []Post{}, nil
+	}
+
+	query := `
+	SELECT id, title, content, user_id, tags, created_at, updated_at
+	FROM (
+		SELECT id, title, content, user_id, tags, created_at, updated_at,
+			ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS n
+		FROM posts
+		WHERE user_id = ANY($1) AND deleted_at IS NULL AND hidden_at IS NULL
+	) ranked
+	WHERE n <= $2
+	ORDER BY user_id, created_at DESC, id DESC
+	`
+
+	return s.list(ctx, query, pq.Array(userIDs), limit)
+}
+
+// CreateBatch schreibt posts per COPY. COPY geht nur innerhalb einer
+// Transaktion (siehe Storage.WithTx) und liefert keine IDs zurück.
+func (s *PostsStorage) CreateBatch(ctx context.Context, posts []*Post) error {
+	stmt, err := s.db.write(ctx).PrepareContext(ctx, pq.CopyIn("posts", "title", "content", "user_id", "tags"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, post := range posts {
+		if post.Tags == nil {
+			post.Tags = []string{}
+		}
+		if _, err := stmt.ExecContext(ctx, post.Title, post.Content, post.UserID, pq.Array(post.Tags)); err != nil {
+			return err
+		}
+	}
+
+	// Ein Exec ohne Argumente schickt die gepufferten Rows ab
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+func (s *PostsStorage) list(ctx context.Context, query string, args ...any) ([]Post, error) {
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Content, &post.UserID,
+			pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// Update ändert Titel, Content und Tags und setzt updated_at neu
+func (s *PostsStorage) Update(ctx context.Context, post *Post) error {
+	query := `
+	UPDATE posts
+	SET title = $1, content = $2, tags = $3, updated_at = NOW()
+	WHERE id = $4 AND deleted_at IS NULL AND hidden_at IS NULL
+	RETURNING updated_at
+	`
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, post.Title, post.Content, pq.Array(post.Tags), post.ID).Scan(&post.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete markiert den Post nur als gelöscht (Soft Delete)
+func (s *PostsStorage) Delete(ctx context.Context, id int64) error {
+	query := `UPDATE posts SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// DeleteByUser soft-deleted alle Posts eines Users (z.B. Spam aufräumen)
+func (s *PostsStorage) DeleteByUser(ctx context.Context, userID int64) (int64, error) {
+	query := `UPDATE posts SET deleted_at = NOW() WHERE user_id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Restore macht einen Soft Delete rückgängig
+func (s *PostsStorage) Restore(ctx context.Context, id int64) error {
+	query := `UPDATE posts SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// SetHidden blendet einen Post aus bzw. wieder ein. Ausgeblendete Posts
+// fehlen in allen Abfragen außer der Moderations-Queue.
+func (s *PostsStorage) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	query := `
+	UPDATE posts SET hidden_at = CASE WHEN $2 THEN COALESCE(hidden_at, NOW()) END
+	WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id, hidden)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Lock sperrt die Zeile eines Posts bis zum Ende der Transaktion (siehe
+// Storage.WithTx), damit sich gleichzeitige Änderungen am Post nacheinander
+// anstellen. Ohne Transaktion ist die Sperre sofort wieder weg.
+func (s *PostsStorage) Lock(ctx context.Context, id int64) error {
+	query := `SELECT id FROM posts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, id).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ListDeleted liefert alle soft-deleted Posts, zuletzt gelöschte zuerst
+func (s *PostsStorage) ListDeleted(ctx context.Context, limit, offset int) ([]Post, error) {
+	query := `
+	SELECT id, title, content, user_id, tags, created_at, updated_at, deleted_at
+	FROM posts
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id DESC
+	LIMIT $1 OFFSET $2
+	`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Content, &post.UserID,
+			pq.Array(&post.Tags), &post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// Purge löscht alle Posts endgültig, die vor before soft-deleted wurden
+func (s *PostsStorage) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM posts WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// searchMatches ist FROM und WHERE aller Suchqueries: $1 Query, $2 Tags, $3 Autor
+const searchMatches = `
+	FROM posts p, websearch_to_tsquery('english', $1) AS q(query)
+	WHERE p.search @@ q.query
+		AND p.deleted_at IS NULL AND p.hidden_at IS NULL
+		AND p.tags @> $2::varchar[]
+		AND ($3::bigint = 0 OR p.user_id = $3)`
+
+// Search durchsucht Titel und Content über die tsvector-Spalte "search".
+// Die Query versteht websearch-Syntax ("phrase", -ausschluss, or).
+func (s *PostsStorage) Search(ctx context.Context, q string, filter SearchFilter) (*SearchResult, error) {
+	query := `
+	SELECT p.id, p.title, p.content, p.user_id, p.tags, p.created_at, p.updated_at,
+		ts_rank(p.search, q.query) AS rank,
+		ts_headline('english', p.title, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+		ts_headline('english', p.content, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10')` + searchMatches + `
+	ORDER BY rank DESC, p.id DESC
+	LIMIT $4 OFFSET $5
+	`
+
+	tags := filter.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, q, pq.Array(tags), filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &SearchResult{Hits: []SearchHit{}}
+	for rows.Next() {
+		var hit SearchHit
+		err := rows.Scan(
+			&hit.ID, &hit.Title, &hit.Content, &hit.UserID, pq.Array(&hit.Tags),
+			&hit.CreatedAt, &hit.UpdatedAt, &hit.Rank, &hit.TitleHighlight, &hit.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Total und Facets über alle Treffer, nicht nur über die aktuelle Seite.
+	// Liegt offset hinter dem letzten Treffer, ist die Seite leer, Total aber nicht.
+	countQuery := `SELECT COUNT(*)` + searchMatches
+
+	err = s.db.read(ctx).QueryRowContext(ctx, countQuery, q, pq.Array(tags), filter.UserID).Scan(&result.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	facetQuery := `
+	SELECT tag, COUNT(*) AS count
+	FROM (SELECT p.tags` + searchMatches + `) AS m, unnest(m.tags) AS tag
+	GROUP BY tag
+	ORDER BY count DESC, tag
+	LIMIT $4
+	`
+
+	facetRows, err := s.db.read(ctx).QueryContext(ctx, facetQuery, q, pq.Array(tags), filter.UserID, maxFacets)
+	if err != nil {
+		return nil, err
+	}
+	defer facetRows.Close()
+
+	result.Facets = []TagFacet{}
+	for facetRows.Next() {
+		var facet TagFacet
+		if err := facetRows.Scan(&facet.Tag, &facet.Count); err != nil {
+			return nil, err
+		}
+		result.Facets = append(result.Facets, facet)
+	}
+
+	return result, facetRows.Err()
+}
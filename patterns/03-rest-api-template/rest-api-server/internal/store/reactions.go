@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// ReactionTypes sind alle erlaubten Reaktionen (siehe CHECK in post_reactions)
+var ReactionTypes = []string{"like", "love", "laugh", "wow", "sad", "angry"}
+
+// IsValidReaction prüft, ob t eine erlaubte Reaktion ist
+func IsValidReaction(t string) bool {
+	for _, r := range ReactionTypes {
+		if r == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionCounts zählt Reaktionen pro Typ, z.B. {"like": 3, "love": 1}
+type ReactionCounts map[string]int
+
+type ReactionsStorage struct {
+	db router
+}
+
+// Add speichert eine Reaktion. Doppelte Reaktionen werden ignoriert.
+func (s *ReactionsStorage) Add(ctx context.Context, userID, postID int64, reaction string) error {
+	query := `
+	INSERT INTO post_reactions (user_id, post_id, type)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, post_id, type) DO NOTHING
+	`
+
+	_, err := s.db.write(ctx).ExecContext(ctx, query, userID, postID, reaction)
+	return err
+}
+
+// Remove entfernt eine Reaktion; ErrNotFound, wenn es sie nicht gab
+func (s *ReactionsStorage) Remove(ctx context.Context, userID, postID int64, reaction string) error {
+	query := `DELETE FROM post_reactions WHERE user_id = $1 AND post_id = $2 AND type = $3`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, userID, postID, reaction)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// CountsForPosts lädt die Counts für viele Posts mit einer einzigen Query,
+// damit Listen wie der Feed kein N+1 Problem haben
+func (s *ReactionsStorage) CountsForPosts(ctx context.Context, postIDs []int64) (map[int64]ReactionCounts, error) {
+	counts := make(map[int64]ReactionCounts, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+	SELECT post_id, type, COUNT(*)
+	FROM post_reactions
+	WHERE post_id = ANY($1)
+	GROUP BY post_id, type
+	`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, pq.Array(postIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			postID   int64
+			reaction string
+			count    int
+		)
+		if err := rows.Scan(&postID, &reaction, &count); err != nil {
+			return nil, err
+		}
+
+		if counts[postID] == nil {
+			counts[postID] = ReactionCounts{}
+		}
+		counts[postID][reaction] = count
+	}
+
+	return counts, rows.Err()
+}
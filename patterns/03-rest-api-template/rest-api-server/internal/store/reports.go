@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ReportReasons sind alle erlaubten Gründe einer Meldung (siehe CHECK in reports)
+var ReportReasons = []string{"spam", "harassment", "hate", "violence", "nudity", "misinformation", "other"}
+
+// IsValidReportReason prüft, ob reason ein erlaubter Grund ist
+func IsValidReportReason(reason string) bool {
+	for _, r := range ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Status einer Meldung
+const (
+	ReportOpen      = "open"
+	ReportDismissed = "dismissed" // Moderator hat nichts beanstandet
+	ReportActioned  = "actioned"  // Post ausgeblendet oder Autor gesperrt
+)
+
+// Report ist die Meldung eines Posts durch einen User
+type Report struct {
+	ID         int64   `json:"id"`
+	PostID     int64   `json:"post_id"`
+	ReporterID int64   `json:"reporter_id"`
+	Reason     string  `json:"reason"`
+	Details    string  `json:"details,omitempty"`
+	Status     string  `json:"status"`
+	CreatedAt  string  `json:"created_at"`
+	ResolvedAt *string `json:"resolved_at,omitempty"`
+	ResolvedBy *int64  `json:"resolved_by,omitempty"`
+}
+
+// ReportedPost ist ein Eintrag der Moderations-Queue: ein Post mit offenen Meldungen
+type ReportedPost struct {
+	PostID          int64    `json:"post_id"`
+	AuthorID        int64    `json:"author_id"`
+	Title           string   `json:"title"`
+	Hidden          bool     `json:"hidden"`
+	Reports         int      `json:"reports"`
+	Reasons         []string `json:"reasons"`
+	FirstReportedAt string   `json:"first_reported_at"`
+	LastReportedAt  string   `json:"last_reported_at"`
+}
+
+type ReportsStorage struct {
+	db router
+}
+
+// Create speichert eine offene Meldung; ErrConflict, wenn der User den Post
+// schon gemeldet hat
+func (s *ReportsStorage) Create(ctx context.Context, report *Report) error {
+	query := `
+	INSERT INTO reports (post_id, reporter_id, reason, details)
+	VALUES ($1, $2, $3, $4) RETURNING id, status, created_at
+	`
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, report.PostID, report.ReporterID, report.Reason, report.Details).
+		Scan(&report.ID, &report.Status, &report.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CountOpen zählt die offenen Meldungen eines Posts, also verschiedene Melder
+func (s *ReportsStorage) CountOpen(ctx context.Context, postID int64) (int, error) {
+	var count int
+	err := s.db.read(ctx).QueryRowContext(ctx, `SELECT COUNT(*) FROM reports WHERE post_id = $1 AND status = 'open'`, postID).Scan(&count)
+	return count, err
+}
+
+// Queue liefert die Posts mit offenen Meldungen, die meistgemeldeten zuerst
+func (s *ReportsStorage) Queue(ctx context.Context, limit, offset int) ([]ReportedPost, error) {
+	return s.queue(ctx, 0, limit, offset)
+}
+
+// GetQueued liefert den Queue-Eintrag eines Posts; ErrNotFound ohne offene Meldungen
+func (s *ReportsStorage) GetQueued(ctx context.Context, postID int64) (*ReportedPost, error) {
+	items, err := s.queue(ctx, postID, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &items[0], nil
+}
+
+func (s *ReportsStorage) queue(ctx context.Context, postID int64, limit, offset int) ([]ReportedPost, error) {
+	query := `
+	SELECT r.post_id, p.user_id, p.title, p.hidden_at IS NOT NULL, COUNT(*),
+		array_agg(DISTINCT r.reason ORDER BY r.reason), MIN(r.created_at), MAX(r.created_at)
+	FROM reports r
+	JOIN posts p ON p.id = r.post_id
+	WHERE r.status = 'open' AND p.deleted_at IS NULL AND ($1::bigint = 0 OR r.post_id = $1)
+	GROUP BY r.post_id, p.user_id, p.title, p.hidden_at
+	ORDER BY COUNT(*) DESC, MIN(r.created_at), r.post_id
+	LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, postID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ReportedPost{}
+	for rows.Next() {
+		var item ReportedPost
+		err := rows.Scan(&item.PostID, &item.AuthorID, &item.Title, &item.Hidden, &item.Reports,
+			pq.Array(&item.Reasons), &item.FirstReportedAt, &item.LastReportedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Resolve schließt alle offenen Meldungen eines Posts mit status ab und
+// liefert ihre Anzahl
+func (s *ReportsStorage) Resolve(ctx context.Context, postID int64, status string, moderatorID int64) (int64, error) {
+	query := `
+	UPDATE reports SET status = $2, resolved_at = NOW(), resolved_by = $3
+	WHERE post_id = $1 AND status = 'open'
+	`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, postID, status, moderatorID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
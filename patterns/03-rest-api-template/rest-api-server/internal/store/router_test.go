@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/timour/go-api/internal/db"
+)
+
+func TestClusterRouter(t *testing.T) {
+	dir := t.TempDir()
+	cluster, err := db.NewCluster("sqlite", filepath.Join(dir, "primary.db"),
+		[]string{filepath.Join(dir, "replica.db")}, 1, 1, "1m", 0)
+	if err != nil {
+		t.Fatalf("Expected no error opening the cluster, got %v", err)
+	}
+	defer cluster.Close()
+
+	r := clusterRouter{cluster: cluster, primary: traced(cluster.Primary(), "postgresql")}
+	onPrimary := func(q querier) bool {
+		return q.(*tracedQuerier).q == cluster.Primary()
+	}
+
+	ctx := db.WithPin(context.Background())
+	if onPrimary(r.read(ctx)) {
+		t.Error("Expected read to use the replica")
+	}
+	if !onPrimary(r.readPrimary(ctx)) || db.Pinned(ctx) {
+		t.Error("Expected readPrimary to use the primary without pinning")
+	}
+	if !onPrimary(r.write(ctx)) || !db.Pinned(ctx) {
+		t.Error("Expected write to use the primary and pin the request")
+	}
+	if !onPrimary(r.read(ctx)) {
+		t.Error("Expected reads after a write to use the primary")
+	}
+
+	// Transaktionen laufen auf dem Primary und pinnen den Request
+	s := NewPostgresClusterStorage(cluster)
+	txCtx := db.WithPin(context.Background())
+	err = s.WithTx(txCtx, func(Storage) error { return nil })
+	if err != nil || !db.Pinned(txCtx) {
+		t.Errorf("Expected WithTx to pin the request, got pinned=%v err=%v", db.Pinned(txCtx), err)
+	}
+}
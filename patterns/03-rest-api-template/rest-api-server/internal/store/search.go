@@ -0,0 +1,304 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Markierungen für hervorgehobene Treffer in Snippets
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// SearchFilter schränkt eine Volltextsuche weiter ein
+type SearchFilter struct {
+	Tags   []string // Post muss alle Tags haben
+	UserID int64    // 0 = alle Autoren
+	Limit  int
+	Offset int
+}
+
+// SearchHit ist ein Treffer inkl. Ranking und hervorgehobenen Snippets
+type SearchHit struct {
+	Post
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// TagFacet zählt, wie viele Treffer einen Tag haben
+type TagFacet struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// SearchResult enthält eine Seite Treffer, die Facets und die Gesamtanzahl
+type SearchResult struct {
+	Hits   []SearchHit `json:"hits"`
+	Facets []TagFacet  `json:"facets"`
+	Total  int         `json:"total"`
+}
+
+// maxFacets begrenzt die Anzahl der zurückgegebenen Tag-Facets
+const maxFacets = 20
+
+// stopWords sind Wörter, die (wie bei Postgres 'english') nicht indexiert werden
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+// tokenize zerlegt Text in normalisierte Suchbegriffe
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+		tokens = append(tokens, stem(w))
+	}
+
+	return tokens
+}
+
+// stem ist ein sehr einfacher Stemmer, damit "posts" auch "post" findet
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// searchQuery ist die geparste Form eines Suchstrings im websearch-Stil:
+// alle Begriffe einer Gruppe müssen vorkommen, "-begriff" darf nicht
+// vorkommen, "or" trennt Gruppen. Wie bei Postgres bindet "or" schwächer als
+// das implizite AND: "go tests or rust" ist (go & tests) | rust.
+type searchQuery struct {
+	groups []searchGroup
+}
+
+type searchGroup struct {
+	include []string
+	exclude []string
+}
+
+func parseSearchQuery(q string) searchQuery {
+	var (
+		sq    searchQuery
+		group searchGroup
+	)
+	for _, field := range strings.Fields(q) {
+		if strings.EqualFold(field, "or") {
+			if len(group.include) > 0 {
+				sq.groups = append(sq.groups, group)
+			}
+			group = searchGroup{}
+			continue
+		}
+
+		negate := strings.HasPrefix(field, "-")
+		for _, token := range tokenize(strings.TrimPrefix(field, "-")) {
+			if negate {
+				group.exclude = append(group.exclude, token)
+			} else {
+				group.include = append(group.include, token)
+			}
+		}
+	}
+	if len(group.include) > 0 {
+		sq.groups = append(sq.groups, group)
+	}
+	return sq
+}
+
+// terms sind alle gesuchten Begriffe über alle Gruppen, z.B. zum Hervorheben
+func (sq searchQuery) terms() map[string]bool {
+	terms := make(map[string]bool)
+	for _, g := range sq.groups {
+		for _, t := range g.include {
+			terms[t] = true
+		}
+	}
+	return terms
+}
+
+// rank bewertet einen Post ähnlich wie ts_rank mit Gewichten A (Titel) und B (Content).
+// Liefert false, wenn der Post zu keiner Gruppe der Query passt.
+func (sq searchQuery) rank(post *Post) (float64, bool) {
+	titleCounts := countTokens(tokenize(post.Title))
+	contentCounts := countTokens(tokenize(post.Content))
+
+	var (
+		best    float64
+		matched bool
+	)
+	for _, g := range sq.groups {
+		if score, ok := g.score(titleCounts, contentCounts); ok && (!matched || score > best) {
+			best, matched = score, true
+		}
+	}
+	if !matched {
+		return 0, false
+	}
+
+	total := len(titleCounts) + len(contentCounts)
+	return best / float64(total+1), true
+}
+
+// score liefert die gewichtete Trefferzahl einer Gruppe oder false, wenn ein
+// Begriff fehlt oder ein ausgeschlossener vorkommt
+func (g searchGroup) score(titleCounts, contentCounts map[string]int) (float64, bool) {
+	for _, term := range g.exclude {
+		if titleCounts[term] > 0 || contentCounts[term] > 0 {
+			return 0, false
+		}
+	}
+
+	var score float64
+	for _, term := range g.include {
+		if titleCounts[term] == 0 && contentCounts[term] == 0 {
+			return 0, false
+		}
+		score += 1.0*float64(titleCounts[term]) + 0.4*float64(contentCounts[term])
+	}
+
+	return score, true
+}
+
+func countTokens(tokens []string) map[string]int {
+	counts := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		counts[t]++
+	}
+	return counts
+}
+
+// highlight markiert alle Wörter in text, die einem Suchbegriff entsprechen.
+// maxWords > 0 schneidet den Text auf ein Fenster um den ersten Treffer zu.
+func (sq searchQuery) highlight(text string, maxWords int) string {
+	terms := sq.terms()
+
+	words := strings.Fields(text)
+	first := -1
+	for i, w := range words {
+		tokens := tokenize(w)
+		if len(tokens) > 0 && terms[tokens[0]] {
+			words[i] = highlightStart + w + highlightStop
+			if first == -1 {
+				first = i
+			}
+		}
+	}
+
+	if maxWords <= 0 || len(words) <= maxWords {
+		return strings.Join(words, " ")
+	}
+
+	start := 0
+	if first > maxWords/2 {
+		start = first - maxWords/2
+	}
+	end := start + maxWords
+	if end > len(words) {
+		end = len(words)
+		start = end - maxWords
+	}
+
+	return strings.Join(words[start:end], " ")
+}
+
+// hasAllTags prüft, ob post alle gesuchten Tags hat
+func hasAllTags(post *Post, tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, t := range post.Tags {
+			if t == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// tagFacets zählt die Tags über alle Treffer, häufigste zuerst
+func tagFacets(hits []SearchHit) []TagFacet {
+	counts := make(map[string]int)
+	for _, h := range hits {
+		for _, t := range h.Tags {
+			counts[t]++
+		}
+	}
+
+	facets := make([]TagFacet, 0, len(counts))
+	for tag, count := range counts {
+		facets = append(facets, TagFacet{Tag: tag, Count: count})
+	}
+
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Tag < facets[j].Tag
+	})
+
+	if len(facets) > maxFacets {
+		facets = facets[:maxFacets]
+	}
+
+	return facets
+}
+
+// searchPosts rankt posts in Go. Genutzt von Backends ohne eigene
+// Volltextsuche (Memory, SQLite); posts enthält keine soft-deleted Posts.
+func searchPosts(posts []*Post, q string, filter SearchFilter) *SearchResult {
+	sq := parseSearchQuery(q)
+
+	hits := []SearchHit{}
+	for _, p := range posts {
+		if !hasAllTags(p, filter.Tags) {
+			continue
+		}
+		if filter.UserID != 0 && p.UserID != filter.UserID {
+			continue
+		}
+
+		rank, ok := sq.rank(p)
+		if !ok {
+			continue
+		}
+
+		hits = append(hits, SearchHit{
+			Post:           copyPost(p),
+			Rank:           rank,
+			TitleHighlight: sq.highlight(p.Title, 0),
+			Snippet:        sq.highlight(p.Content, 30),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	return &SearchResult{
+		Hits:   page(hits, filter.Limit, filter.Offset),
+		Facets: tagFacets(hits),
+		Total:  len(hits),
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrTokenReused heißt: ein schon rotierter Refresh Token wurde noch einmal
+// vorgezeigt. Entweder wurde er gestohlen oder der Client hat den neuen verloren,
+// in beiden Fällen muss die ganze Session widerrufen werden.
+var ErrTokenReused = errors.New("refresh token has already been used")
+
+// Session ist ein Login mit Refresh Token. Alle rotierten Tokens einer Session
+// gehören zu einer Familie und werden gemeinsam widerrufen.
+type Session struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"` // Session des anfragenden Access Tokens, setzt der Handler
+}
+
+type SessionsStorage struct {
+	db router
+}
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_used_at, expires_at`
+
+// Create legt eine Session samt erstem Refresh Token an
+func (s *SessionsStorage) Create(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time) error {
+	query := `
+	INSERT INTO sessions (user_id, user_agent, ip, expires_at)
+	VALUES ($1, $2, $3, $4) RETURNING id, created_at, last_used_at, expires_at
+	`
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, session.UserID, session.UserAgent, session.IP, expiresAt).
+		Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.write(ctx).ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)`, tokenHash, session.ID)
+	return err
+}
+
+// Get liefert eine aktive Session; ErrNotFound, wenn sie widerrufen oder abgelaufen ist
+func (s *SessionsStorage) Get(ctx context.Context, id int64) (*Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+
+	return s.scanOne(s.db.readPrimary(ctx).QueryRowContext(ctx, query, id))
+}
+
+// GetByToken liefert die aktive Session, deren aktueller Refresh Token tokenHash ist
+func (s *SessionsStorage) GetByToken(ctx context.Context, tokenHash string) (*Session, error) {
+	query := `
+	SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at
+	FROM refresh_tokens t
+	JOIN sessions s ON s.id = t.session_id
+	WHERE t.token_hash = $1 AND t.used_at IS NULL AND s.revoked_at IS NULL AND s.expires_at > NOW()
+	`
+
+	return s.scanOne(s.db.readPrimary(ctx).QueryRowContext(ctx, query, tokenHash))
+}
+
+// Rotate tauscht den Refresh Token oldHash gegen newHash. Ein schon benutzter
+// Token liefert die Session zusammen mit ErrTokenReused, ändert aber nichts;
+// unbekannte Tokens und inaktive Sessions ErrNotFound.
+func (s *SessionsStorage) Rotate(ctx context.Context, oldHash, newHash string) (*Session, error) {
+	// Das UPDATE reserviert den Token atomar, von zwei gleichzeitigen
+	// Refreshs mit demselben Token gewinnt nur einer
+	var sessionID int64
+	err := s.db.write(ctx).QueryRowContext(ctx,
+		`UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL RETURNING session_id`,
+		oldHash).Scan(&sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.reused(ctx, oldHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	UPDATE sessions SET last_used_at = NOW()
+	WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	RETURNING ` + sessionColumns
+
+	session, err := s.scanOne(s.db.write(ctx).QueryRowContext(ctx, query, sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.write(ctx).ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)`, newHash, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// reused unterscheidet einen wiederverwendeten von einem unbekannten Token
+func (s *SessionsStorage) reused(ctx context.Context, tokenHash string) (*Session, error) {
+	query := `
+	SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at
+	FROM refresh_tokens t
+	JOIN sessions s ON s.id = t.session_id
+	WHERE t.token_hash = $1 AND s.revoked_at IS NULL
+	`
+
+	session, err := s.scanOne(s.db.readPrimary(ctx).QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		return nil, err
+	}
+
+	return session, ErrTokenReused
+}
+
+// ListByUser liefert die aktiven Sessions eines Users, zuletzt benutzte zuerst
+func (s *SessionsStorage) ListByUser(ctx context.Context, userID int64) ([]Session, error) {
+	query := `
+	SELECT ` + sessionColumns + ` FROM sessions
+	WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	ORDER BY last_used_at DESC, id DESC
+	`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		if err := scanSession(rows, &session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Revoke widerruft eine aktive Session mit allen ihren Refresh Tokens
+func (s *SessionsStorage) Revoke(ctx context.Context, id int64) error {
+	res, err := s.db.write(ctx).ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// RevokeAll widerruft alle Sessions eines Users und liefert ihre Anzahl
+func (s *SessionsStorage) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// PurgeExpired löscht abgelaufene und widerrufene Sessions samt Tokens
+func (s *SessionsStorage) PurgeExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.write(ctx).ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= NOW() OR revoked_at IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *SessionsStorage) scanOne(row *sql.Row) (*Session, error) {
+	var session Session
+	if err := scanSession(row, &session); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// scanSession liest die Spalten aus sessionColumns
+func scanSession(row interface{ Scan(...any) error }, session *Session) error {
+	return row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+}
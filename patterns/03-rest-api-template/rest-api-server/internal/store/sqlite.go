@@ -0,0 +1,604 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// NewSQLiteStorage erstellt einen Storage auf einer SQLite-Datenbank, z.B. für
+// Demos und CI ohne Docker. Das Schema kommt aus internal/migrations/sqlite
+// (siehe MigrateSQLite). Die Verbindung sollte foreign_keys aktiviert haben,
+// sonst greifen die ON DELETE CASCADE Regeln nicht (siehe SQLiteDSN).
+func NewSQLiteStorage(db *sql.DB) Storage {
+	s := newSQLiteStorage(db)
+	s.withTx = func(ctx context.Context, fn func(Storage) error) error {
+		return withTx(ctx, db, func(tx *sql.Tx) error {
+			return fn(newSQLiteStorage(tx))
+		})
+	}
+
+	return s
+}
+
+func newSQLiteStorage(db querier) Storage {
+	db = traced(db, "sqlite")
+
+	return Storage{
+		Posts:       &sqlitePostsStore{db},
+		Users:       &sqliteUsersStore{db},
+		Comments:    &sqliteCommentsStore{db},
+		Followers:   &sqliteFollowersStore{db},
+		Reactions:   &sqliteReactionsStore{db},
+		Idempotency: &sqliteIdempotencyStore{db},
+		Webhooks:    &sqliteWebhooksStore{db},
+		Attachments: &sqliteAttachmentsStore{db},
+		Audit:       &sqliteAuditStore{db},
+		Reports:     &sqliteReportsStore{db},
+
+		Sessions:       &sqliteSessionsStore{db},
+		PasswordResets: &sqlitePasswordResetsStore{db},
+		APIKeys:        &sqliteAPIKeysStore{db},
+	}
+}
+
+// SQLiteDSN macht aus einem Dateipfad einen DSN für modernc.org/sqlite mit
+// Foreign Keys, WAL und Busy Timeout. Ein fertiger "file:" DSN bleibt unverändert.
+func SQLiteDSN(addr string) string {
+	if strings.HasPrefix(addr, "file:") {
+		return addr
+	}
+
+	return "file:" + addr + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+}
+
+// MigrateSQLite wendet alle noch fehlenden *.up.sql Dateien aus fsys an. Die
+// aktuelle Version steht wie bei golang-migrate in schema_migrations, sodass
+// man später auch mit dem migrate CLI weitermachen kann.
+func MigrateSQLite(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL, dirty INTEGER NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	var current int64
+	var dirty bool
+	err = db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("sqlite schema is dirty at version %d, fix it manually", current)
+	}
+
+	files, err := fs.Glob(fsys, "*/*.up.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version, err := strconv.ParseInt(strings.SplitN(path.Base(file), "_", 2)[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migration %s: invalid version prefix", file)
+		}
+		if version <= current {
+			continue
+		}
+
+		script, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		err = withTx(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return fmt.Errorf("migration %s: %w", file, err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES (?, 0)`, version)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sqliteTime formatiert t wie die Zeitstempel der Tabellen (siehe timeFormat)
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// sqliteIn baut "(?, ?, …)" samt Argumenten für eine IN-Liste von IDs
+func sqliteIn(ids []int64) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return "(?" + strings.Repeat(", ?", len(ids)-1) + ")", args
+}
+
+// isSQLiteUnique erkennt verletzte UNIQUE und PRIMARY KEY Constraints
+func isSQLiteUnique(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// encodeTags speichert Tags als JSON Array (SQLite hat keine Arrays)
+func encodeTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	b, _ := json.Marshal(tags)
+	return string(b)
+}
+
+func decodeTags(raw string, tags *[]string) error {
+	*tags = []string{}
+	return json.Unmarshal([]byte(raw), tags)
+}
+
+type sqlitePostsStore struct {
+	db querier
+}
+
+const sqlitePostColumns = `id, title, content, user_id, tags, created_at, updated_at, deleted_at`
+
+func scanSQLitePost(row interface{ Scan(...any) error }, post *Post) error {
+	var tags string
+	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.UserID, &tags,
+		&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt)
+	if err != nil {
+		return err
+	}
+
+	return decodeTags(tags, &post.Tags)
+}
+
+func (s *sqlitePostsStore) Create(ctx context.Context, post *Post) error {
+	query := `
+	INSERT INTO posts (title, content, user_id, tags, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?) RETURNING id
+	`
+
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+	createdAt := now()
+
+	err := s.db.QueryRowContext(ctx, query, post.Title, post.Content, post.UserID,
+		encodeTags(post.Tags), createdAt, createdAt).Scan(&post.ID)
+	if err != nil {
+		return err
+	}
+
+	post.CreatedAt = createdAt
+	post.UpdatedAt = createdAt
+	return nil
+}
+
+func (s *sqlitePostsStore) GetByID(ctx context.Context, id int64) (*Post, error) {
+	return s.getByID(ctx, id, `hidden_at IS NULL`)
+}
+
+func (s *sqlitePostsStore) GetByIDIncludingHidden(ctx context.Context, id int64) (*Post, error) {
+	return s.getByID(ctx, id, `1`)
+}
+
+func (s *sqlitePostsStore) getByID(ctx context.Context, id int64, hidden string) (*Post, error) {
+	query := `SELECT ` + sqlitePostColumns + ` FROM posts WHERE id = ? AND deleted_at IS NULL AND ` + hidden
+
+	var post Post
+	if err := scanSQLitePost(s.db.QueryRowContext(ctx, query, id), &post); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+func (s *sqlitePostsStore) List(ctx context.Context, limit, offset int) ([]Post, error) {
+	query := `
+	SELECT ` + sqlitePostColumns + ` FROM posts
+	WHERE deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY created_at DESC, id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	return s.list(ctx, query, limit, offset)
+}
+
+func (s *sqlitePostsStore) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]Post, error) {
+	query := `
+	SELECT ` + sqlitePostColumns + ` FROM posts
+	WHERE user_id = ? AND deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY created_at DESC, id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	return s.list(ctx, query, userID, limit, offset)
+}
+
+func (s *sqlitePostsStore) ListAfter(ctx context.Context, afterID int64, limit int) ([]Post, error) {
+	query := `
+	SELECT ` + sqlitePostColumns + ` FROM posts
+	WHERE id > ? AND deleted_at IS NULL AND hidden_at IS NULL
+	ORDER BY id
+	LIMIT ?
+	`
+
+	return s.list(ctx, query, afterID, limit)
+}
+
+// ListByUsers nutzt wie Postgres ROW_NUMBER (SQLite >= 3.25)
+func (s *sqlitePostsStore) ListByUsers(ctx context.Context, userIDs []int64, limit int) ([]Post, error) {
+	if len(userIDs) == 0 {
+		return []Post{}, nil
+	}
+
+	in, args := sqliteIn(userIDs)
+	query := `
+	SELECT ` + sqlitePostColumns + ` FROM (
+		SELECT *, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS n
+		FROM posts
+		WHERE user_id IN ` + in + ` AND deleted_at IS NULL AND hidden_at IS NULL
+	) AS posts
+	WHERE n <= ?
+	ORDER BY user_id, created_at DESC, id DESC
+	`
+
+	return s.list(ctx, query, append(args, limit)...)
+}
+
+// CreateBatch nutzt ein vorbereitetes INSERT. SQLite kennt kein COPY, in einer
+// Transaktion ist das aber ähnlich schnell.
+func (s *sqlitePostsStore) CreateBatch(ctx context.Context, posts []*Post) error {
+	stmt, err := s.db.PrepareContext(ctx, `
+	INSERT INTO posts (title, content, user_id, tags, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, post := range posts {
+		if post.Tags == nil {
+			post.Tags = []string{}
+		}
+		createdAt := now()
+		if _, err := stmt.ExecContext(ctx, post.Title, post.Content, post.UserID, encodeTags(post.Tags), createdAt, createdAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlitePostsStore) list(ctx context.Context, query string, args ...any) ([]Post, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		if err := scanSQLitePost(rows, &post); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+func (s *sqlitePostsStore) Update(ctx context.Context, post *Post) error {
+	query := `
+	UPDATE posts SET title = ?, content = ?, tags = ?, updated_at = ?
+	WHERE id = ? AND deleted_at IS NULL AND hidden_at IS NULL
+	`
+
+	updatedAt := now()
+	res, err := s.db.ExecContext(ctx, query, post.Title, post.Content, encodeTags(post.Tags), updatedAt, post.ID)
+	if err != nil {
+		return err
+	}
+	if err := affectedOrNotFound(res); err != nil {
+		return err
+	}
+
+	post.UpdatedAt = updatedAt
+	return nil
+}
+
+func (s *sqlitePostsStore) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE posts SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, now(), id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqlitePostsStore) DeleteByUser(ctx context.Context, userID int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE posts SET deleted_at = ? WHERE user_id = ? AND deleted_at IS NULL`, now(), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *sqlitePostsStore) Restore(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE posts SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqlitePostsStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	query := `
+	UPDATE posts SET hidden_at = CASE WHEN ? THEN COALESCE(hidden_at, ?) END
+	WHERE id = ? AND deleted_at IS NULL
+	`
+
+	res, err := s.db.ExecContext(ctx, query, hidden, now(), id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Lock prüft nur, ob der Post existiert. SQLite kennt kein FOR UPDATE, die
+// Transaktionen starten aber mit _txlock=immediate und laufen nacheinander.
+func (s *sqlitePostsStore) Lock(ctx context.Context, id int64) error {
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM posts WHERE id = ? AND deleted_at IS NULL`, id).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *sqlitePostsStore) ListDeleted(ctx context.Context, limit, offset int) ([]Post, error) {
+	query := `
+	SELECT ` + sqlitePostColumns + ` FROM posts
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	return s.list(ctx, query, limit, offset)
+}
+
+func (s *sqlitePostsStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM posts WHERE deleted_at IS NOT NULL AND deleted_at < ?`, sqliteTime(before))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Search filtert Autor und Tags per SQL und rankt die Kandidaten in Go
+// (searchPosts), da SQLite keinen tsvector hat
+func (s *sqlitePostsStore) Search(ctx context.Context, q string, filter SearchFilter) (*SearchResult, error) {
+	query := `
+	SELECT ` + sqlitePostColumns + ` FROM posts
+	WHERE deleted_at IS NULL AND hidden_at IS NULL AND (? = 0 OR user_id = ?)
+	`
+	args := []any{filter.UserID, filter.UserID}
+
+	for _, tag := range filter.Tags {
+		query += ` AND EXISTS (SELECT 1 FROM json_each(posts.tags) WHERE value = ?)`
+		args = append(args, tag)
+	}
+
+	candidates, err := s.list(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]*Post, len(candidates))
+	for i := range candidates {
+		posts[i] = &candidates[i]
+	}
+
+	return searchPosts(posts, q, filter), nil
+}
+
+type sqliteUsersStore struct {
+	db querier
+}
+
+func (s *sqliteUsersStore) Create(ctx context.Context, user *User) error {
+	query := `INSERT INTO users (username, password, email, role, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id, is_active`
+
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+
+	createdAt := now()
+	err := s.db.QueryRowContext(ctx, query, user.Username, user.Passwort, user.Email, user.Role, createdAt).Scan(&user.ID, &user.IsActive)
+	if err != nil {
+		if isSQLiteUnique(err) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	user.Created = createdAt
+	return nil
+}
+
+func (s *sqliteUsersStore) GetByIDs(ctx context.Context, ids []int64) ([]User, error) {
+	if len(ids) == 0 {
+		return []User{}, nil
+	}
+
+	in, args := sqliteIn(ids)
+	query := `
+	SELECT id, username, email, created_at, COALESCE(avatar_key, ''), role, is_active, token_version
+	FROM users
+	WHERE id IN ` + in + ` AND deleted_at IS NULL
+	ORDER BY id
+	`
+
+	return getUsers(ctx, s.db, query, args...)
+}
+
+func (s *sqliteUsersStore) GetByID(ctx context.Context, id int64) (*User, error) {
+	query := `
+	SELECT id, username, email, created_at, COALESCE(avatar_key, ''), role, is_active, token_version
+	FROM users
+	WHERE id = ? AND deleted_at IS NULL
+	`
+
+	var user User
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Created, &user.AvatarKey,
+		&user.Role, &user.IsActive, &user.TokenVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetByEmail vergleicht ohne Groß-/Kleinschreibung (email ist COLLATE NOCASE)
+func (s *sqliteUsersStore) GetByEmail(ctx context.Context, email string) (*User, error) {
+	query := `
+	SELECT id, username, email, password, created_at, role, is_active, token_version
+	FROM users
+	WHERE email = ? AND deleted_at IS NULL
+	`
+
+	var user User
+	err := s.db.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Passwort, &user.Created,
+		&user.Role, &user.IsActive, &user.TokenVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *sqliteUsersStore) List(ctx context.Context, limit, offset int) ([]User, error) {
+	query := `
+	SELECT id, username, email, created_at, deleted_at, role, is_active
+	FROM users
+	WHERE deleted_at IS NULL
+	ORDER BY id
+	LIMIT ? OFFSET ?
+	`
+
+	return listUsers(ctx, s.db, query, limit, offset)
+}
+
+func (s *sqliteUsersStore) SetRole(ctx context.Context, id int64, role string) error {
+	return s.update(ctx, `UPDATE users SET role = ? WHERE id = ? AND deleted_at IS NULL`, role, id)
+}
+
+func (s *sqliteUsersStore) UpdatePassword(ctx context.Context, id int64, hash string) error {
+	return s.update(ctx, `UPDATE users SET password = ? WHERE id = ? AND deleted_at IS NULL`, hash, id)
+}
+
+func (s *sqliteUsersStore) SetActive(ctx context.Context, id int64, active bool) error {
+	return s.update(ctx, `UPDATE users SET is_active = ? WHERE id = ? AND deleted_at IS NULL`, active, id)
+}
+
+func (s *sqliteUsersStore) RevokeTokens(ctx context.Context, id int64) error {
+	return s.update(ctx, `UPDATE users SET token_version = token_version + 1 WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+func (s *sqliteUsersStore) update(ctx context.Context, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteUsersStore) SetAvatar(ctx context.Context, id int64, key string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET avatar_key = ? WHERE id = ? AND deleted_at IS NULL`, key, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteUsersStore) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, now(), id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteUsersStore) Restore(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteUsersStore) ListDeleted(ctx context.Context, limit, offset int) ([]User, error) {
+	query := `
+	SELECT id, username, email, created_at, deleted_at, role, is_active
+	FROM users
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	return listUsers(ctx, s.db, query, limit, offset)
+}
+
+// Purge löscht User endgültig; Posts & Co. folgen per ON DELETE CASCADE
+func (s *sqliteUsersStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?`, sqliteTime(before))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
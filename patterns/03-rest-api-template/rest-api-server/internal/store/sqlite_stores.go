@@ -0,0 +1,907 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+type sqliteCommentsStore struct {
+	db querier
+}
+
+func (s *sqliteCommentsStore) Create(ctx context.Context, comment *Comment) error {
+	query := `INSERT INTO comments (post_id, user_id, content, created_at) VALUES (?, ?, ?, ?) RETURNING id`
+
+	createdAt := now()
+	err := s.db.QueryRowContext(ctx, query, comment.PostID, comment.UserID, comment.Content, createdAt).Scan(&comment.ID)
+	if err != nil {
+		return err
+	}
+
+	comment.CreatedAt = createdAt
+	return nil
+}
+
+func (s *sqliteCommentsStore) GetByPostID(ctx context.Context, postID int64) ([]Comment, error) {
+	query := `
+	SELECT id, post_id, user_id, content, created_at
+	FROM comments
+	WHERE post_id = ?
+	ORDER BY created_at, id
+	`
+
+	return scanComments(s.db.QueryContext(ctx, query, postID))
+}
+
+// GetByPostIDs nutzt wie ListByUsers ROW_NUMBER (SQLite >= 3.25)
+func (s *sqliteCommentsStore) GetByPostIDs(ctx context.Context, postIDs []int64, limit int) ([]Comment, error) {
+	if len(postIDs) == 0 {
+		return []Comment{}, nil
+	}
+
+	in, args := sqliteIn(postIDs)
+	query := `
+	SELECT id, post_id, user_id, content, created_at FROM (
+		SELECT *, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at, id) AS n
+		FROM comments
+		WHERE post_id IN ` + in + `
+	) AS comments
+	WHERE n <= ?
+	ORDER BY post_id, created_at, id
+	`
+
+	return scanComments(s.db.QueryContext(ctx, query, append(args, limit)...))
+}
+
+type sqliteFollowersStore struct {
+	db querier
+}
+
+func (s *sqliteFollowersStore) Follow(ctx context.Context, followerID, userID int64) error {
+	query := `INSERT INTO followers (user_id, follower_id, created_at) VALUES (?, ?, ?)`
+
+	if _, err := s.db.ExecContext(ctx, query, userID, followerID, now()); err != nil {
+		if isSQLiteUnique(err) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *sqliteFollowersStore) Unfollow(ctx context.Context, followerID, userID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM followers WHERE user_id = ? AND follower_id = ?`, userID, followerID)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+type sqliteReactionsStore struct {
+	db querier
+}
+
+func (s *sqliteReactionsStore) Add(ctx context.Context, userID, postID int64, reaction string) error {
+	query := `
+	INSERT INTO post_reactions (user_id, post_id, type, created_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT (user_id, post_id, type) DO NOTHING
+	`
+
+	_, err := s.db.ExecContext(ctx, query, userID, postID, reaction, now())
+	return err
+}
+
+func (s *sqliteReactionsStore) Remove(ctx context.Context, userID, postID int64, reaction string) error {
+	query := `DELETE FROM post_reactions WHERE user_id = ? AND post_id = ? AND type = ?`
+
+	res, err := s.db.ExecContext(ctx, query, userID, postID, reaction)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// CountsForPosts baut statt ANY($1) eine IN (?, ?, ...) Liste
+func (s *sqliteReactionsStore) CountsForPosts(ctx context.Context, postIDs []int64) (map[int64]ReactionCounts, error) {
+	counts := make(map[int64]ReactionCounts, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	args := make([]any, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+
+	query := `
+	SELECT post_id, type, COUNT(*)
+	FROM post_reactions
+	WHERE post_id IN (?` + strings.Repeat(`, ?`, len(postIDs)-1) + `)
+	GROUP BY post_id, type
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			postID   int64
+			reaction string
+			count    int
+		)
+		if err := rows.Scan(&postID, &reaction, &count); err != nil {
+			return nil, err
+		}
+		if counts[postID] == nil {
+			counts[postID] = ReactionCounts{}
+		}
+		counts[postID][reaction] = count
+	}
+
+	return counts, rows.Err()
+}
+
+type sqliteIdempotencyStore struct {
+	db querier
+}
+
+func (s *sqliteIdempotencyStore) Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error) {
+	query := `
+	SELECT scope, key, fingerprint, status_code, response_headers, response_body, expires_at
+	FROM idempotency_keys
+	WHERE scope = ? AND key = ? AND expires_at > ?
+	`
+
+	var (
+		record    IdempotencyRecord
+		header    string
+		expiresAt string
+	)
+	err := s.db.QueryRowContext(ctx, query, scope, key, now()).Scan(
+		&record.Scope, &record.Key, &record.Fingerprint, &record.StatusCode,
+		&header, &record.Body, &expiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(header), &record.Header); err != nil {
+		return nil, err
+	}
+	if record.ExpiresAt, err = time.Parse(timeFormat, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (s *sqliteIdempotencyStore) Reserve(ctx context.Context, record *IdempotencyRecord) error {
+	query := `
+	INSERT INTO idempotency_keys (scope, key, fingerprint, created_at, expires_at)
+	VALUES (?1, ?2, ?3, ?4, ?5)
+	ON CONFLICT (scope, key) DO UPDATE
+		SET fingerprint = excluded.fingerprint, status_code = 0, response_headers = '{}',
+			response_body = NULL, created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= ?4
+	`
+
+	res, err := s.db.ExecContext(ctx, query, record.Scope, record.Key, record.Fingerprint, now(), sqliteTime(record.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	if err := affectedOrNotFound(res); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *sqliteIdempotencyStore) Complete(ctx context.Context, record *IdempotencyRecord) error {
+	query := `
+	UPDATE idempotency_keys
+	SET status_code = ?, response_headers = ?, response_body = ?
+	WHERE scope = ? AND key = ?
+	`
+
+	header, err := marshalHeader(record.Header)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, query, record.StatusCode, string(header), record.Body, record.Scope, record.Key)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteIdempotencyStore) Delete(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = ? AND key = ?`, scope, key)
+	return err
+}
+
+func (s *sqliteIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+type sqliteWebhooksStore struct {
+	db querier
+}
+
+func (s *sqliteWebhooksStore) Create(ctx context.Context, hook *Webhook) error {
+	query := `INSERT INTO webhooks (url, secret, events, created_at) VALUES (?, ?, ?, ?) RETURNING id`
+
+	createdAt := now()
+	if err := s.db.QueryRowContext(ctx, query, hook.URL, hook.Secret, encodeTags(hook.Events), createdAt).Scan(&hook.ID); err != nil {
+		return err
+	}
+
+	hook.Active = true
+	hook.CreatedAt = createdAt
+	return nil
+}
+
+func scanSQLiteWebhook(row interface{ Scan(...any) error }, hook *Webhook) error {
+	var events string
+	err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, &events, &hook.Active,
+		&hook.FailureCount, &hook.DisabledAt, &hook.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return decodeTags(events, &hook.Events)
+}
+
+func (s *sqliteWebhooksStore) GetByID(ctx context.Context, id int64) (*Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ?`
+
+	var hook Webhook
+	if err := scanSQLiteWebhook(s.db.QueryRowContext(ctx, query, id), &hook); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+func (s *sqliteWebhooksStore) List(ctx context.Context) ([]Webhook, error) {
+	return s.list(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+}
+
+func (s *sqliteWebhooksStore) ListActiveForEvent(ctx context.Context, event string) ([]Webhook, error) {
+	query := `
+	SELECT ` + webhookColumns + ` FROM webhooks
+	WHERE active AND EXISTS (SELECT 1 FROM json_each(webhooks.events) WHERE value = ?)
+	ORDER BY id
+	`
+
+	return s.list(ctx, query, event)
+}
+
+func (s *sqliteWebhooksStore) list(ctx context.Context, query string, args ...any) ([]Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var hook Webhook
+		if err := scanSQLiteWebhook(rows, &hook); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+func (s *sqliteWebhooksStore) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteWebhooksStore) Enable(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE webhooks SET active = 1, failure_count = 0, disabled_at = NULL WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteWebhooksStore) RecordDelivery(ctx context.Context, d *WebhookDelivery, maxFailures int) (bool, error) {
+	query := `
+	INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, attempt, status_code, error, duration_ms, success, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`
+
+	createdAt := now()
+	err := s.db.QueryRowContext(ctx, query, d.WebhookID, d.EventID, d.Event, string(d.Payload), d.Attempt,
+		d.StatusCode, d.Error, d.DurationMS, d.Success, createdAt).Scan(&d.ID)
+	if err != nil {
+		return false, err
+	}
+	d.CreatedAt = createdAt
+
+	if d.Success {
+		_, err := s.db.ExecContext(ctx, `UPDATE webhooks SET failure_count = 0 WHERE id = ?`, d.WebhookID)
+		return false, err
+	}
+
+	update := `
+	UPDATE webhooks
+	SET failure_count = failure_count + 1,
+		active = active AND failure_count + 1 < ?2,
+		disabled_at = CASE WHEN active AND failure_count + 1 >= ?2 THEN ?3 ELSE disabled_at END
+	WHERE id = ?1
+	RETURNING active
+	`
+
+	var active bool
+	if err := s.db.QueryRowContext(ctx, update, d.WebhookID, maxFailures, now()).Scan(&active); err != nil {
+		return false, err
+	}
+
+	return !active, nil
+}
+
+func (s *sqliteWebhooksStore) ListDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]WebhookDelivery, error) {
+	query := `
+	SELECT id, webhook_id, event_id, event, payload, attempt, status_code, error, duration_ms, success, created_at
+	FROM webhook_deliveries
+	WHERE webhook_id = ?
+	ORDER BY id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *sqliteWebhooksStore) GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	query := `
+	SELECT id, webhook_id, event_id, event, payload, attempt, status_code, error, duration_ms, success, created_at
+	FROM webhook_deliveries
+	WHERE id = ?
+	`
+
+	var d WebhookDelivery
+	if err := scanDelivery(s.db.QueryRowContext(ctx, query, id), &d); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+type sqliteAttachmentsStore struct {
+	db querier
+}
+
+func (s *sqliteAttachmentsStore) Create(ctx context.Context, a *Attachment) error {
+	query := `
+	INSERT INTO post_attachments (post_id, blob_key, filename, content_type, size_bytes, width, height, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`
+
+	createdAt := now()
+	err := s.db.QueryRowContext(ctx, query, a.PostID, a.Key, a.Filename, a.ContentType, a.Size, a.Width, a.Height, createdAt).
+		Scan(&a.ID)
+	if err != nil {
+		return err
+	}
+
+	a.CreatedAt = createdAt
+	return nil
+}
+
+func (s *sqliteAttachmentsStore) ListByPost(ctx context.Context, postID int64) ([]Attachment, error) {
+	query := `
+	SELECT id, post_id, blob_key, filename, content_type, size_bytes, width, height, created_at
+	FROM post_attachments
+	WHERE post_id = ?
+	ORDER BY id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+	for rows.Next() {
+		var a Attachment
+		err := rows.Scan(&a.ID, &a.PostID, &a.Key, &a.Filename, &a.ContentType, &a.Size, &a.Width, &a.Height, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+type sqliteAuditStore struct {
+	db querier
+}
+
+func (s *sqliteAuditStore) Record(ctx context.Context, e *AuditEvent) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO audit_events (actor_type, actor_id, action, target_type, target_id, changes, ip, request_id, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id
+	`
+
+	createdAt := now()
+	err = s.db.QueryRowContext(ctx, query, e.ActorType, e.ActorID, e.Action, e.TargetType, e.TargetID,
+		string(changes), e.IP, e.RequestID, createdAt).Scan(&e.ID)
+	if err != nil {
+		return err
+	}
+
+	e.CreatedAt = createdAt
+	return nil
+}
+
+func (s *sqliteAuditStore) List(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	where, args := auditWhere(filter,
+		func(int) string { return "?" },
+		func(t time.Time) any { return sqliteTime(t) },
+	)
+
+	query := `
+	SELECT id, actor_type, actor_id, action, target_type, target_id, changes, ip, request_id, created_at
+	FROM audit_events
+	` + where + `
+	ORDER BY created_at DESC, id DESC
+	LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
+
+type sqliteReportsStore struct {
+	db querier
+}
+
+func (s *sqliteReportsStore) Create(ctx context.Context, report *Report) error {
+	query := `
+	INSERT INTO reports (post_id, reporter_id, reason, details, created_at)
+	VALUES (?, ?, ?, ?, ?) RETURNING id, status
+	`
+
+	createdAt := now()
+	err := s.db.QueryRowContext(ctx, query, report.PostID, report.ReporterID, report.Reason, report.Details, createdAt).
+		Scan(&report.ID, &report.Status)
+	if err != nil {
+		if isSQLiteUnique(err) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	report.CreatedAt = createdAt
+	return nil
+}
+
+func (s *sqliteReportsStore) CountOpen(ctx context.Context, postID int64) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports WHERE post_id = ? AND status = 'open'`, postID).Scan(&count)
+	return count, err
+}
+
+func (s *sqliteReportsStore) Queue(ctx context.Context, limit, offset int) ([]ReportedPost, error) {
+	return s.queue(ctx, 0, limit, offset)
+}
+
+func (s *sqliteReportsStore) GetQueued(ctx context.Context, postID int64) (*ReportedPost, error) {
+	items, err := s.queue(ctx, postID, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &items[0], nil
+}
+
+// queue fasst die Gründe per group_concat zusammen, sortiert werden sie in Go
+func (s *sqliteReportsStore) queue(ctx context.Context, postID int64, limit, offset int) ([]ReportedPost, error) {
+	query := `
+	SELECT r.post_id, p.user_id, p.title, p.hidden_at IS NOT NULL, COUNT(*),
+		group_concat(DISTINCT r.reason), MIN(r.created_at), MAX(r.created_at)
+	FROM reports r
+	JOIN posts p ON p.id = r.post_id
+	WHERE r.status = 'open' AND p.deleted_at IS NULL AND (? = 0 OR r.post_id = ?)
+	GROUP BY r.post_id
+	ORDER BY COUNT(*) DESC, MIN(r.created_at), r.post_id
+	LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, postID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ReportedPost{}
+	for rows.Next() {
+		var (
+			item    ReportedPost
+			reasons string
+		)
+		err := rows.Scan(&item.PostID, &item.AuthorID, &item.Title, &item.Hidden, &item.Reports,
+			&reasons, &item.FirstReportedAt, &item.LastReportedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Reasons = strings.Split(reasons, ",")
+		sort.Strings(item.Reasons)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *sqliteReportsStore) Resolve(ctx context.Context, postID int64, status string, moderatorID int64) (int64, error) {
+	query := `
+	UPDATE reports SET status = ?, resolved_at = ?, resolved_by = ?
+	WHERE post_id = ? AND status = 'open'
+	`
+
+	res, err := s.db.ExecContext(ctx, query, status, now(), moderatorID, postID)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+type sqlitePasswordResetsStore struct {
+	db querier
+}
+
+func (s *sqlitePasswordResetsStore) Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO password_resets (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, query, tokenHash, userID, sqliteTime(expiresAt), now())
+	return err
+}
+
+func (s *sqlitePasswordResetsStore) Consume(ctx context.Context, tokenHash string) (int64, error) {
+	query := `DELETE FROM password_resets WHERE token_hash = ? AND expires_at > ? RETURNING user_id`
+
+	var userID int64
+	if err := s.db.QueryRowContext(ctx, query, tokenHash, now()).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (s *sqlitePasswordResetsStore) PurgeExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at <= ?`, now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+type sqliteSessionsStore struct {
+	db querier
+}
+
+func (s *sqliteSessionsStore) Create(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time) error {
+	session.CreatedAt = now()
+	session.LastUsedAt = session.CreatedAt
+	session.ExpiresAt = sqliteTime(expiresAt)
+
+	query := `
+	INSERT INTO sessions (user_id, user_agent, ip, created_at, last_used_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	res, err := s.db.ExecContext(ctx, query, session.UserID, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if session.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES (?, ?, ?)`,
+		tokenHash, session.ID, session.CreatedAt)
+	return err
+}
+
+func (s *sqliteSessionsStore) Get(ctx context.Context, id int64) (*Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ? AND revoked_at IS NULL AND expires_at > ?`
+
+	return s.scanOne(s.db.QueryRowContext(ctx, query, id, now()))
+}
+
+func (s *sqliteSessionsStore) GetByToken(ctx context.Context, tokenHash string) (*Session, error) {
+	query := `
+	SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at
+	FROM refresh_tokens t
+	JOIN sessions s ON s.id = t.session_id
+	WHERE t.token_hash = ? AND t.used_at IS NULL AND s.revoked_at IS NULL AND s.expires_at > ?
+	`
+
+	return s.scanOne(s.db.QueryRowContext(ctx, query, tokenHash, now()))
+}
+
+func (s *sqliteSessionsStore) Rotate(ctx context.Context, oldHash, newHash string) (*Session, error) {
+	ts := now()
+
+	var sessionID int64
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL RETURNING session_id`,
+		ts, oldHash).Scan(&sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.reused(ctx, oldHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	UPDATE sessions SET last_used_at = ?
+	WHERE id = ? AND revoked_at IS NULL AND expires_at > ?
+	RETURNING ` + sessionColumns
+
+	session, err := s.scanOne(s.db.QueryRowContext(ctx, query, ts, sessionID, ts))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES (?, ?, ?)`,
+		newHash, sessionID, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *sqliteSessionsStore) reused(ctx context.Context, tokenHash string) (*Session, error) {
+	query := `
+	SELECT s.id, s.user_id, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at
+	FROM refresh_tokens t
+	JOIN sessions s ON s.id = t.session_id
+	WHERE t.token_hash = ? AND s.revoked_at IS NULL
+	`
+
+	session, err := s.scanOne(s.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		return nil, err
+	}
+
+	return session, ErrTokenReused
+}
+
+func (s *sqliteSessionsStore) ListByUser(ctx context.Context, userID int64) ([]Session, error) {
+	query := `
+	SELECT ` + sessionColumns + ` FROM sessions
+	WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+	ORDER BY last_used_at DESC, id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		if err := scanSession(rows, &session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *sqliteSessionsStore) Revoke(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, now(), id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteSessionsStore) RevokeAll(ctx context.Context, userID int64) (int64, error) {
+	ts := now()
+	query := `UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?`
+
+	res, err := s.db.ExecContext(ctx, query, ts, userID, ts)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *sqliteSessionsStore) PurgeExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ? OR revoked_at IS NOT NULL`, now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *sqliteSessionsStore) scanOne(row *sql.Row) (*Session, error) {
+	var session Session
+	if err := scanSession(row, &session); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+type sqliteAPIKeysStore struct {
+	db querier
+}
+
+func (s *sqliteAPIKeysStore) Create(ctx context.Context, key *APIKey, hash string, expiresAt time.Time) error {
+	key.CreatedAt = now()
+	key.ExpiresAt = sqliteTime(expiresAt)
+
+	query := `
+	INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	res, err := s.db.ExecContext(ctx, query, key.Name, key.Prefix, hash, encodeTags(key.Scopes),
+		key.CreatedBy, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		if isSQLiteUnique(err) {
+			return ErrConflict
+		}
+		return err
+	}
+
+	key.ID, err = res.LastInsertId()
+	return err
+}
+
+func (s *sqliteAPIKeysStore) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL AND expires_at > ?`
+
+	var key APIKey
+	if err := scanSQLiteAPIKey(s.db.QueryRowContext(ctx, query, hash, now()), &key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (s *sqliteAPIKeysStore) List(ctx context.Context, limit, offset int) ([]APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		if err := scanSQLiteAPIKey(rows, &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *sqliteAPIKeysStore) Revoke(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, now(), id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+func (s *sqliteAPIKeysStore) Touch(ctx context.Context, id int64) error {
+	query := `UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)`
+
+	_, err := s.db.ExecContext(ctx, query, now(), id, sqliteTime(time.Now().Add(-time.Minute)))
+	return err
+}
+
+func scanSQLiteAPIKey(row interface{ Scan(...any) error }, key *APIKey) error {
+	var scopes string
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedBy,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return err
+	}
+
+	return decodeTags(scopes, &key.Scopes)
+}
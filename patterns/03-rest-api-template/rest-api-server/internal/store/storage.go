@@ -3,23 +3,284 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/timour/go-api/internal/db"
+)
+
+var (
+	ErrNotFound = errors.New("resource not found")
+	ErrConflict = errors.New("resource already exists")
 )
 
 type Storage struct {
 	Posts interface {
 		Create(context.Context, *Post) error
+		GetByID(context.Context, int64) (*Post, error)
+		GetByIDIncludingHidden(context.Context, int64) (*Post, error)
+		List(context.Context, int, int) ([]Post, error)
+		ListByUser(ctx context.Context, userID int64, limit, offset int) ([]Post, error)
+		ListAfter(ctx context.Context, afterID int64, limit int) ([]Post, error)
+		ListByUsers(ctx context.Context, userIDs []int64, limit int) ([]Post, error)
+		CreateBatch(context.Context, []*Post) error
+		Update(context.Context, *Post) error
+		Delete(context.Context, int64) error
+		DeleteByUser(ctx context.Context, userID int64) (int64, error)
+		Restore(context.Context, int64) error
+		SetHidden(ctx context.Context, id int64, hidden bool) error
+		Lock(context.Context, int64) error
+		ListDeleted(context.Context, int, int) ([]Post, error)
+		Purge(context.Context, time.Time) (int64, error)
+		Search(context.Context, string, SearchFilter) (*SearchResult, error)
 	}
 
 	Users interface {
 		Create(context.Context, *User) error
+		GetByID(context.Context, int64) (*User, error)
+		GetByIDs(context.Context, []int64) ([]User, error)
+		GetByEmail(context.Context, string) (*User, error)
+		List(context.Context, int, int) ([]User, error)
+		SetRole(ctx context.Context, id int64, role string) error
+		SetActive(ctx context.Context, id int64, active bool) error
+		UpdatePassword(ctx context.Context, id int64, hash string) error
+		RevokeTokens(context.Context, int64) error
+		Delete(context.Context, int64) error
+		Restore(context.Context, int64) error
+		ListDeleted(context.Context, int, int) ([]User, error)
+		Purge(context.Context, time.Time) (int64, error)
+		SetAvatar(ctx context.Context, id int64, key string) error
+	}
+
+	Comments interface {
+		Create(context.Context, *Comment) error
+		GetByPostID(context.Context, int64) ([]Comment, error)
+		GetByPostIDs(ctx context.Context, postIDs []int64, limit int) ([]Comment, error)
+	}
+
+	Followers interface {
+		Follow(ctx context.Context, followerID, userID int64) error
+		Unfollow(ctx context.Context, followerID, userID int64) error
+	}
+
+	Reactions interface {
+		Add(ctx context.Context, userID, postID int64, reaction string) error
+		Remove(ctx context.Context, userID, postID int64, reaction string) error
+		CountsForPosts(context.Context, []int64) (map[int64]ReactionCounts, error)
+	}
+
+	Idempotency interface {
+		Get(ctx context.Context, scope, key string) (*IdempotencyRecord, error)
+		Reserve(context.Context, *IdempotencyRecord) error
+		Complete(context.Context, *IdempotencyRecord) error
+		Delete(ctx context.Context, scope, key string) error
+		PurgeExpired(context.Context) (int64, error)
+	}
+
+	Webhooks interface {
+		Create(context.Context, *Webhook) error
+		GetByID(context.Context, int64) (*Webhook, error)
+		List(context.Context) ([]Webhook, error)
+		ListActiveForEvent(context.Context, string) ([]Webhook, error)
+		Delete(context.Context, int64) error
+		Enable(context.Context, int64) error
+		RecordDelivery(ctx context.Context, delivery *WebhookDelivery, maxFailures int) (bool, error)
+		ListDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]WebhookDelivery, error)
+		GetDelivery(context.Context, int64) (*WebhookDelivery, error)
+	}
+
+	Attachments interface {
+		Create(context.Context, *Attachment) error
+		ListByPost(context.Context, int64) ([]Attachment, error)
+	}
+
+	Audit interface {
+		Record(context.Context, *AuditEvent) error
+		List(context.Context, AuditFilter) ([]AuditEvent, error)
+	}
+
+	Reports interface {
+		Create(context.Context, *Report) error
+		CountOpen(ctx context.Context, postID int64) (int, error)
+		Queue(ctx context.Context, limit, offset int) ([]ReportedPost, error)
+		GetQueued(ctx context.Context, postID int64) (*ReportedPost, error)
+		Resolve(ctx context.Context, postID int64, status string, moderatorID int64) (int64, error)
+	}
+
+	Sessions interface {
+		Create(ctx context.Context, session *Session, tokenHash string, expiresAt time.Time) error
+		Get(context.Context, int64) (*Session, error)
+		GetByToken(ctx context.Context, tokenHash string) (*Session, error)
+		Rotate(ctx context.Context, oldHash, newHash string) (*Session, error)
+		ListByUser(ctx context.Context, userID int64) ([]Session, error)
+		Revoke(context.Context, int64) error
+		RevokeAll(ctx context.Context, userID int64) (int64, error)
+		PurgeExpired(context.Context) (int64, error)
+	}
+
+	APIKeys interface {
+		Create(ctx context.Context, key *APIKey, hash string, expiresAt time.Time) error
+		GetByHash(ctx context.Context, hash string) (*APIKey, error)
+		List(context.Context, int, int) ([]APIKey, error)
+		Revoke(context.Context, int64) error
+		Touch(context.Context, int64) error
+	}
+
+	PasswordResets interface {
+		Create(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+		Consume(ctx context.Context, tokenHash string) (int64, error)
+		PurgeExpired(context.Context) (int64, error)
+	}
+
+	// withTx startet eine Transaktion; nil = Backend ohne Transaktionen
+	withTx func(context.Context, func(Storage) error) error
+
+	// close gibt Ressourcen frei, die der Storage selbst besitzt (z.B. Replicas); nil = nichts
+	close func() error
+}
+
+// querier wird von *sql.DB und *sql.Tx erfüllt, damit jeder Store
+// sowohl direkt als auch innerhalb einer Transaktion laufen kann
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// router entscheidet, auf welcher Verbindung eine Query läuft. Jede Methode
+// der Postgres-Stores erklärt mit read, readPrimary oder write, was sie vorhat.
+type router interface {
+	read(context.Context) querier        // darf von einer Replica kommen
+	readPrimary(context.Context) querier // Primary, wo Replikationsverzögerung nicht tragbar ist (z.B. Widerrufe)
+	write(context.Context) querier       // Primary, pinnt den Request für Read-your-writes
+}
+
+// single schickt alles an dieselbe Verbindung: ohne Replicas und in Transaktionen
+type single struct {
+	q querier
+}
+
+func (s single) read(context.Context) querier        { return s.q }
+func (s single) readPrimary(context.Context) querier { return s.q }
+func (s single) write(context.Context) querier       { return s.q }
+
+// clusterRouter verteilt Lesen auf die Replicas eines db.Cluster
+type clusterRouter struct {
+	cluster *db.Cluster
+	primary querier // getraced
+}
+
+func (c clusterRouter) read(ctx context.Context) querier {
+	conn := c.cluster.Reader(ctx)
+	if conn == c.cluster.Primary() {
+		return c.primary
+	}
+	return traced(conn, "postgresql")
+}
+
+func (c clusterRouter) readPrimary(context.Context) querier {
+	return c.primary
+}
+
+func (c clusterRouter) write(ctx context.Context) querier {
+	c.cluster.Writer(ctx)
+	return c.primary
+}
+
+func NewPostgresStorage(conn *sql.DB) Storage {
+	s := newPostgresStorage(single{traced(conn, "postgresql")})
+	s.withTx = postgresTx(conn)
+
+	return s
+}
+
+// NewPostgresClusterStorage liest über Replicas und schreibt auf den Primary.
+// Transaktionen laufen immer auf dem Primary. Close schließt den Cluster.
+func NewPostgresClusterStorage(cluster *db.Cluster) Storage {
+	s := newPostgresStorage(clusterRouter{cluster: cluster, primary: traced(cluster.Primary(), "postgresql")})
+	tx := postgresTx(cluster.Primary())
+	s.withTx = func(ctx context.Context, fn func(Storage) error) error {
+		db.PinPrimary(ctx)
+		return tx(ctx, fn)
 	}
+	s.close = cluster.Close
+
+	return s
 }
 
-func NewPostgresStorage(db *sql.DB) Storage {
+func postgresTx(conn *sql.DB) func(context.Context, func(Storage) error) error {
+	return func(ctx context.Context, fn func(Storage) error) error {
+		return withTx(ctx, conn, func(tx *sql.Tx) error {
+			return fn(newPostgresStorage(single{traced(tx, "postgresql")}))
+		})
+	}
+}
+
+func newPostgresStorage(db router) Storage {
 	return Storage{
+		Posts:       &PostsStorage{db},
+		Users:       &UsersStorage{db}, //mongodb, postgres possible :)
+		Comments:    &CommentsStorage{db},
+		Followers:   &FollowersStorage{db},
+		Reactions:   &ReactionsStorage{db},
+		Idempotency: &IdempotencyStorage{db},
+		Webhooks:    &WebhooksStorage{db},
+		Attachments: &AttachmentsStorage{db},
+		Audit:       &AuditStorage{db},
+		Reports:     &ReportsStorage{db},
+
+		Sessions:       &SessionsStorage{db},
+		PasswordResets: &PasswordResetsStorage{db},
+		APIKeys:        &APIKeysStorage{db},
+	}
+}
+
+// WithTx führt fn mit einem Storage aus, dessen Stores alle in derselben
+// Transaktion arbeiten. Gibt fn einen Fehler zurück, wird alles zurückgerollt.
+// Innerhalb von fn laufen weitere WithTx-Aufrufe in der äußeren Transaktion.
+func (s Storage) WithTx(ctx context.Context, fn func(Storage) error) error {
+	if s.withTx == nil {
+		return fn(s)
+	}
+
+	return s.withTx(ctx, fn)
+}
+
+// Close gibt die Ressourcen des Storage frei. Die Verbindung aus Open schließt
+// der Aufrufer selbst.
+func (s Storage) Close() error {
+	if s.close == nil {
+		return nil
+	}
+
+	return s.close()
+}
 
-		Posts: &PostsStorage{db},
-		Users: &UsersStorage{db}, //mongodb, postgres possible :)
+func withTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
 
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
+
+	return tx.Commit()
+}
+
+// affectedOrNotFound wandelt "0 Rows betroffen" in ErrNotFound um
+func affectedOrNotFound(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/timour/go-api/internal/trace"
+)
+
+// tracedQuerier umhüllt *sql.DB bzw. *sql.Tx: jede Query bekommt einen Span
+// und einen SQL-Kommentar mit Request-ID und traceparent, so lassen sich
+// langsame Queries in der Datenbank dem Request zuordnen
+type tracedQuerier struct {
+	q      querier
+	system string // "postgresql" oder "sqlite"
+}
+
+func traced(q querier, system string) querier {
+	return &tracedQuerier{q: q, system: system}
+}
+
+func (t *tracedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := t.start(ctx, "db.exec", query)
+	defer span.End()
+
+	res, err := t.q.ExecContext(ctx, annotate(ctx, query), args...)
+	span.RecordError(err)
+	return res, err
+}
+
+func (t *tracedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, "db.query", query)
+	defer span.End()
+
+	rows, err := t.q.QueryContext(ctx, annotate(ctx, query), args...)
+	span.RecordError(err)
+	return rows, err
+}
+
+func (t *tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := t.start(ctx, "db.query", query)
+	defer span.End()
+
+	row := t.q.QueryRowContext(ctx, annotate(ctx, query), args...)
+	span.RecordError(row.Err())
+	return row
+}
+
+// PrepareContext wird für COPY gebraucht. Der Span deckt nur das Vorbereiten
+// ab, die einzelnen Exec-Aufrufe auf dem Statement laufen ohne eigene Spans.
+func (t *tracedQuerier) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, "db.prepare", query)
+	defer span.End()
+
+	stmt, err := t.q.PrepareContext(ctx, annotate(ctx, query))
+	span.RecordError(err)
+	return stmt, err
+}
+
+func (t *tracedQuerier) start(ctx context.Context, name, query string) (context.Context, *trace.Span) {
+	ctx, span := trace.Start(ctx, name)
+	span.SetAttr("db.system", t.system)
+	span.SetAttr("db.statement", strings.Join(strings.Fields(query), " "))
+	return ctx, span
+}
+
+// annotate hängt den Kommentar ans Ende, damit Query-Normalisierung (z.B.
+// pg_stat_statements) dieselbe Query weiterhin zusammenfasst
+func annotate(ctx context.Context, query string) string {
+	if comment := trace.SQLComment(ctx); comment != "" {
+		return query + " " + comment
+	}
+	return query
+}
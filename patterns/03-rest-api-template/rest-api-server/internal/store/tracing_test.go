@@ -0,0 +1,63 @@
+package store_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+	"github.com/timour/go-api/internal/trace"
+)
+
+// spanCollector ist ein Exporter, der Spans nur im Speicher sammelt
+type spanCollector struct {
+	mu    sync.Mutex
+	spans []trace.SpanData
+}
+
+func (c *spanCollector) Export(ctx context.Context, spans []trace.SpanData) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.spans = append(c.spans, spans...)
+	return nil
+}
+
+func TestStoreCallsAreTraced(t *testing.T) {
+	s, conn, err := store.Open("sqlite", store.Config{
+		Addr: filepath.Join(t.TempDir(), "trace.db"), MaxOpenConns: 1, MaxIdleConns: 1, MaxIdleTime: "1m",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer conn.Close()
+
+	collector := &spanCollector{}
+	recorder := trace.NewRecorder(collector, 10, time.Hour)
+	ctx, root := recorder.StartRequest(context.Background(), "POST /v1/users", trace.SpanContext{}, "req-7")
+
+	user := &store.User{Username: "tracy", Email: "tracy@example.com", Passwort: "hash"}
+	if err := s.Users.Create(ctx, user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := s.Users.GetByID(ctx, user.ID+100); err == nil {
+		t.Fatal("Expected ErrNotFound")
+	}
+	root.End()
+	recorder.Close(context.Background())
+
+	if len(collector.spans) != 3 {
+		t.Fatalf("Expected 2 query spans and the request span, got %+v", collector.spans)
+	}
+	for _, span := range collector.spans[:2] {
+		if span.ParentID != collector.spans[2].SpanID || span.RequestID != "req-7" || span.Error != "" {
+			t.Errorf("Expected a successful child span of the request, got %+v", span)
+		}
+		if stmt, _ := span.Attributes["db.statement"].(string); !strings.Contains(stmt, "users") || strings.Contains(stmt, "/*") {
+			t.Errorf("Expected the statement without the trace comment, got %q", stmt)
+		}
+	}
+}
@@ -3,29 +3,307 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID       int64  `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Passwort string `json:"-"`
-	Created  string `json:"created_at"`
+	ID        int64   `json:"id"`
+	Username  string  `json:"username"`
+	Email     string  `json:"email"`
+	Passwort  string  `json:"-"`
+	Created   string  `json:"created_at"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
+	AvatarKey string  `json:"-"`                    // Key im blob.Store, leer = kein Avatar
+	AvatarURL string  `json:"avatar_url,omitempty"` // signierte Download-URL, setzt der Handler
+	Role      string  `json:"role"`
+	IsActive  bool    `json:"is_active"`
+	// TokenVersion steht in jedem Access Token; RevokeTokens erhöht sie
+	TokenVersion int `json:"-"`
+}
+
+// Rollen eines Users (siehe CHECK in users.role)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsValidRole prüft, ob role eine erlaubte Rolle ist
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
+// IsAdmin ist true für User mit der Rolle admin
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// SetPassword speichert den bcrypt-Hash von plain, nie das Klartext-Passwort
+func (u *User) SetPassword(plain string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	u.Passwort = string(hash)
+	return nil
+}
+
+// ComparePassword prüft plain gegen den gespeicherten Hash
+func (u *User) ComparePassword(plain string) error {
+	return bcrypt.CompareHashAndPassword([]byte(u.Passwort), []byte(plain))
 }
 
 type UsersStorage struct {
-	db *sql.DB
+	db router
 }
 
 func (s *UsersStorage) Create(ctx context.Context, user *User) error {
 	query := `
-	INSERT INTO users (username, password,email) VALUES($1,$2,$3) RETURNING id,
-	created_at
+	INSERT INTO users (username, password,email, role) VALUES($1,$2,$3,$4) RETURNING id,
+	created_at, is_active
 	`
 
-	err := s.db.QueryRowContext(ctx, query, user.Username, user.Passwort, user.Email).Scan(&user.ID, &user.Created)
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, user.Username, user.Passwort, user.Email, user.Role).Scan(&user.ID, &user.Created, &user.IsActive)
 	if err != nil {
+		// Username oder E-Mail ist schon vergeben
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrConflict
+		}
 		return err
 	}
 	return nil
 }
+
+// GetByID liefert nur User, die nicht soft-deleted sind
+func (s *UsersStorage) GetByID(ctx context.Context, id int64) (*User, error) {
+	query := `
+	SELECT id, username, email, created_at, COALESCE(avatar_key, ''), role, is_active, token_version
+	FROM users
+	WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	var user User
+	err := s.db.readPrimary(ctx).QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Created, &user.AvatarKey,
+		&user.Role, &user.IsActive, &user.TokenVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetByIDs lädt mehrere User mit einer Query. Unbekannte oder gelöschte IDs fehlen im Ergebnis.
+func (s *UsersStorage) GetByIDs(ctx context.Context, ids []int64) ([]User, error) {
+	if len(ids) == 0 {
+		return []User{}, nil
+	}
+
+	query := `
+	SELECT id, username, email, created_at, COALESCE(avatar_key, ''), role, is_active, token_version
+	FROM users
+	WHERE id = ANY($1) AND deleted_at IS NULL
+	ORDER BY id
+	`
+
+	return getUsers(ctx, s.db.read(ctx), query, pq.Array(ids))
+}
+
+// getUsers scannt die Spalten von GetByIDs (Postgres und SQLite)
+func getUsers(ctx context.Context, db querier, query string, args ...any) ([]User, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var user User
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Created, &user.AvatarKey,
+			&user.Role, &user.IsActive, &user.TokenVersion)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// GetByEmail liefert den User inkl. Passwort-Hash für den Login
+func (s *UsersStorage) GetByEmail(ctx context.Context, email string) (*User, error) {
+	query := `
+	SELECT id, username, email, password, created_at, role, is_active, token_version
+	FROM users
+	WHERE email = $1 AND deleted_at IS NULL
+	`
+
+	var user User
+	err := s.db.readPrimary(ctx).QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Passwort, &user.Created,
+		&user.Role, &user.IsActive, &user.TokenVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// List liefert alle nicht gelöschten User, älteste zuerst
+func (s *UsersStorage) List(ctx context.Context, limit, offset int) ([]User, error) {
+	query := `
+	SELECT id, username, email, created_at, deleted_at, role, is_active
+	FROM users
+	WHERE deleted_at IS NULL
+	ORDER BY id
+	LIMIT $1 OFFSET $2
+	`
+
+	return listUsers(ctx, s.db.read(ctx), query, limit, offset)
+}
+
+// listUsers scannt die Spalten von List und ListDeleted (Postgres und SQLite)
+func listUsers(ctx context.Context, db querier, query string, args ...any) ([]User, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		var user User
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Created, &user.DeletedAt, &user.Role, &user.IsActive)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// SetRole ändert die Rolle (z.B. admin promote)
+func (s *UsersStorage) SetRole(ctx context.Context, id int64, role string) error {
+	query := `UPDATE users SET role = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id, role)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// UpdatePassword setzt einen neuen Passwort-Hash (siehe User.SetPassword)
+func (s *UsersStorage) UpdatePassword(ctx context.Context, id int64, hash string) error {
+	query := `UPDATE users SET password = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id, hash)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// SetActive (de)aktiviert einen User. Deaktivierte User können sich nicht
+// einloggen und ihre Tokens werden abgelehnt, die Daten bleiben aber sichtbar.
+func (s *UsersStorage) SetActive(ctx context.Context, id int64, active bool) error {
+	query := `UPDATE users SET is_active = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id, active)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// RevokeTokens macht alle bisher ausgestellten Access Tokens des Users ungültig
+func (s *UsersStorage) RevokeTokens(ctx context.Context, id int64) error {
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// SetAvatar speichert den Blob-Key des Avatars
+func (s *UsersStorage) SetAvatar(ctx context.Context, id int64, key string) error {
+	query := `UPDATE users SET avatar_key = $2 WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id, key)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Delete markiert den User nur als gelöscht (Soft Delete)
+func (s *UsersStorage) Delete(ctx context.Context, id int64) error {
+	query := `UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Restore macht einen Soft Delete rückgängig
+func (s *UsersStorage) Restore(ctx context.Context, id int64) error {
+	query := `UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// ListDeleted liefert alle soft-deleted User, zuletzt gelöschte zuerst
+func (s *UsersStorage) ListDeleted(ctx context.Context, limit, offset int) ([]User, error) {
+	query := `
+	SELECT id, username, email, created_at, deleted_at, role, is_active
+	FROM users
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id DESC
+	LIMIT $1 OFFSET $2
+	`
+
+	return listUsers(ctx, s.db.read(ctx), query, limit, offset)
+}
+
+// Purge löscht alle User endgültig, die vor before soft-deleted wurden.
+// Ihre Posts verschwinden per ON DELETE CASCADE gleich mit.
+func (s *UsersStorage) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
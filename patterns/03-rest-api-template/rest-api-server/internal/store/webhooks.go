@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Webhook ist eine Subscription eines Integrators auf bestimmte Events
+type Webhook struct {
+	ID           int64    `json:"id"`
+	URL          string   `json:"url"`
+	Secret       string   `json:"-"`
+	Events       []string `json:"events"`
+	Active       bool     `json:"active"`
+	FailureCount int      `json:"failure_count"`
+	DisabledAt   *string  `json:"disabled_at,omitempty"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// Subscribed prüft, ob der Webhook das Event abonniert hat
+func (w *Webhook) Subscribed(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery ist ein einzelner Zustellversuch
+type WebhookDelivery struct {
+	ID         int64           `json:"id"`
+	WebhookID  int64           `json:"webhook_id"`
+	EventID    string          `json:"event_id"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"`
+	StatusCode int             `json:"status_code"`
+	Error      string          `json:"error,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+	Success    bool            `json:"success"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+type WebhooksStorage struct {
+	db router
+}
+
+func (s *WebhooksStorage) Create(ctx context.Context, hook *Webhook) error {
+	query := `
+	INSERT INTO webhooks (url, secret, events)
+	VALUES ($1, $2, $3) RETURNING id, active, created_at
+	`
+
+	return s.db.write(ctx).QueryRowContext(ctx, query, hook.URL, hook.Secret, pq.Array(hook.Events)).
+		Scan(&hook.ID, &hook.Active, &hook.CreatedAt)
+}
+
+const webhookColumns = `id, url, secret, events, active, failure_count, disabled_at, created_at`
+
+func scanWebhook(row interface{ Scan(...any) error }, hook *Webhook) error {
+	return row.Scan(&hook.ID, &hook.URL, &hook.Secret, pq.Array(&hook.Events), &hook.Active,
+		&hook.FailureCount, &hook.DisabledAt, &hook.CreatedAt)
+}
+
+func (s *WebhooksStorage) GetByID(ctx context.Context, id int64) (*Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	var hook Webhook
+	if err := scanWebhook(s.db.read(ctx).QueryRowContext(ctx, query, id), &hook); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+// List liefert alle Webhooks, aktive und deaktivierte
+func (s *WebhooksStorage) List(ctx context.Context) ([]Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+	return s.list(ctx, query)
+}
+
+// ListActiveForEvent liefert alle aktiven Webhooks, die event abonniert haben
+func (s *WebhooksStorage) ListActiveForEvent(ctx context.Context, event string) ([]Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE active AND $1 = ANY(events) ORDER BY id`
+	return s.list(ctx, query, event)
+}
+
+func (s *WebhooksStorage) list(ctx context.Context, query string, args ...any) ([]Webhook, error) {
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var hook Webhook
+		if err := scanWebhook(rows, &hook); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+func (s *WebhooksStorage) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.write(ctx).ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// Enable aktiviert einen (automatisch) deaktivierten Webhook wieder
+func (s *WebhooksStorage) Enable(ctx context.Context, id int64) error {
+	query := `UPDATE webhooks SET active = true, failure_count = 0, disabled_at = NULL WHERE id = $1`
+
+	res, err := s.db.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return affectedOrNotFound(res)
+}
+
+// RecordDelivery speichert einen Zustellversuch und aktualisiert den Fehlerzähler.
+// Nach maxFailures Fehlversuchen in Folge wird der Webhook deaktiviert (disabled = true).
+func (s *WebhooksStorage) RecordDelivery(ctx context.Context, d *WebhookDelivery, maxFailures int) (bool, error) {
+	query := `
+	INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, attempt, status_code, error, duration_ms, success)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at
+	`
+
+	err := s.db.write(ctx).QueryRowContext(ctx, query, d.WebhookID, d.EventID, d.Event, []byte(d.Payload), d.Attempt,
+		d.StatusCode, d.Error, d.DurationMS, d.Success).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	if d.Success {
+		_, err := s.db.write(ctx).ExecContext(ctx, `UPDATE webhooks SET failure_count = 0 WHERE id = $1`, d.WebhookID)
+		return false, err
+	}
+
+	update := `
+	UPDATE webhooks
+	SET failure_count = failure_count + 1,
+		active = active AND failure_count + 1 < $2,
+		disabled_at = CASE WHEN active AND failure_count + 1 >= $2 THEN NOW() ELSE disabled_at END
+	WHERE id = $1
+	RETURNING active
+	`
+
+	var active bool
+	if err := s.db.write(ctx).QueryRowContext(ctx, update, d.WebhookID, maxFailures).Scan(&active); err != nil {
+		return false, err
+	}
+
+	return !active, nil
+}
+
+// ListDeliveries liefert die Zustellversuche eines Webhooks, neueste zuerst
+func (s *WebhooksStorage) ListDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]WebhookDelivery, error) {
+	query := `
+	SELECT id, webhook_id, event_id, event, payload, attempt, status_code, error, duration_ms, success, created_at
+	FROM webhook_deliveries
+	WHERE webhook_id = $1
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.read(ctx).QueryContext(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *WebhooksStorage) GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	query := `
+	SELECT id, webhook_id, event_id, event, payload, attempt, status_code, error, duration_ms, success, created_at
+	FROM webhook_deliveries
+	WHERE id = $1
+	`
+
+	var d WebhookDelivery
+	if err := scanDelivery(s.db.read(ctx).QueryRowContext(ctx, query, id), &d); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func scanDelivery(row interface{ Scan(...any) error }, d *WebhookDelivery) error {
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &payload, &d.Attempt,
+		&d.StatusCode, &d.Error, &d.DurationMS, &d.Success, &d.CreatedAt)
+	d.Payload = payload
+	return err
+}
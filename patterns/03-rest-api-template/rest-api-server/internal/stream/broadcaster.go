@@ -0,0 +1,160 @@
+package stream
+
+import (
+	"sync"
+)
+
+// Message ist ein veröffentlichtes Event mit fortlaufender ID (für Last-Event-ID)
+type Message[T any] struct {
+	ID   int64
+	Data T
+}
+
+// Broadcaster verteilt Events im Prozess an alle Subscriber und hält die
+// letzten Events in einem Ringpuffer, damit Clients nach einem Reconnect
+// verpasste Events nachholen können.
+//
+// Publish blockiert nie: ist der Puffer eines Subscribers voll, wird er
+// getrennt. Der Client verbindet sich neu und holt den Rest per Replay nach.
+type Broadcaster[T any] struct {
+	mu         sync.Mutex
+	nextID     int64
+	replay     []Message[T] // Ringpuffer, ältestes Event bei replay[head]
+	head       int
+	bufferSize int
+	subs       map[*Subscription[T]]struct{}
+	closed     bool
+}
+
+// NewBroadcaster erstellt einen Broadcaster, der replaySize Events für Replays
+// aufhebt und jedem Subscriber bufferSize Events Vorsprung lässt
+func NewBroadcaster[T any](replaySize, bufferSize int) *Broadcaster[T] {
+	if replaySize < 1 {
+		replaySize = 1
+	}
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	return &Broadcaster[T]{
+		replay:     make([]Message[T], 0, replaySize),
+		bufferSize: bufferSize,
+		subs:       make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Subscription empfängt Events über C. C wird geschlossen, wenn der Subscriber
+// zu langsam war, der Broadcaster geschlossen wurde oder Close aufgerufen wurde.
+type Subscription[T any] struct {
+	C <-chan Message[T]
+
+	c       chan Message[T]
+	match   func(T) bool
+	b       *Broadcaster[T]
+	dropped bool
+}
+
+// Dropped ist true, wenn die Subscription wegen eines vollen Puffers getrennt wurde
+func (s *Subscription[T]) Dropped() bool {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	return s.dropped
+}
+
+// Close meldet die Subscription ab
+func (s *Subscription[T]) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	s.b.remove(s)
+}
+
+// Publish vergibt die nächste ID, speichert das Event für Replays und stellt
+// es allen passenden Subscribern zu, ohne auf sie zu warten
+func (b *Broadcaster[T]) Publish(data T) Message[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	msg := Message[T]{ID: b.nextID, Data: data}
+
+	if len(b.replay) < cap(b.replay) {
+		b.replay = append(b.replay, msg)
+	} else {
+		b.replay[b.head] = msg
+		b.head = (b.head + 1) % len(b.replay)
+	}
+
+	for sub := range b.subs {
+		if sub.match != nil && !sub.match(data) {
+			continue
+		}
+
+		select {
+		case sub.c <- msg:
+		default:
+			sub.dropped = true
+			b.remove(sub)
+		}
+	}
+
+	return msg
+}
+
+// Subscribe meldet einen Subscriber an. match filtert Events (nil = alle).
+// Ist lastID > 0, liefert replay alle gepufferten passenden Events danach.
+// IDs aus einem früheren Prozess (größer als die aktuelle ID) werden ignoriert.
+func (b *Broadcaster[T]) Subscribe(lastID int64, match func(T) bool) (sub *Subscription[T], replay []Message[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Message[T], b.bufferSize)
+	sub = &Subscription[T]{C: c, c: c, match: match, b: b}
+
+	if b.closed {
+		close(c)
+		return sub, nil
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastID > 0 && lastID <= b.nextID {
+		for i := 0; i < len(b.replay); i++ {
+			msg := b.replay[(b.head+i)%len(b.replay)]
+			if msg.ID > lastID && (match == nil || match(msg.Data)) {
+				replay = append(replay, msg)
+			}
+		}
+	}
+
+	return sub, replay
+}
+
+// Subscribers zählt die aktiven Subscriptions
+func (b *Broadcaster[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subs)
+}
+
+// Close trennt alle Subscriber, z.B. beim Shutdown des HTTP-Servers
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// remove schließt den Channel genau einmal. Aufrufer hält den Lock.
+func (b *Broadcaster[T]) remove(sub *Subscription[T]) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+
+	delete(b.subs, sub)
+	close(sub.c)
+}
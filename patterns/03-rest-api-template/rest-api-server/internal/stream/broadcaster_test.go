@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestBroadcasterFiltersAndDelivers(t *testing.T) {
+	b := NewBroadcaster[int](10, 10)
+
+	even, _ := b.Subscribe(0, func(n int) bool { return n%2 == 0 })
+	defer even.Close()
+
+	for n := 1; n <= 4; n++ {
+		b.Publish(n)
+	}
+
+	for _, want := range []int{2, 4} {
+		msg := <-even.C
+		if msg.Data != want {
+			t.Errorf("Expected %d, got %d", want, msg.Data)
+		}
+	}
+}
+
+func TestBroadcasterReplay(t *testing.T) {
+	b := NewBroadcaster[string](3, 10)
+
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		b.Publish(s)
+	}
+
+	tests := []struct {
+		name   string
+		lastID int64
+		want   string
+	}{
+		{"no Last-Event-ID", 0, ""},
+		{"within buffer", 3, "de"},
+		{"older than buffer", 1, "cde"},
+		{"up to date", 5, ""},
+		{"id from previous process", 42, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay := b.Subscribe(tt.lastID, nil)
+			defer sub.Close()
+
+			got := ""
+			for _, msg := range replay {
+				got += msg.Data
+			}
+			if got != tt.want {
+				t.Errorf("Expected replay %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBroadcasterDropsSlowSubscriber(t *testing.T) {
+	b := NewBroadcaster[int](10, 2)
+
+	slow, _ := b.Subscribe(0, nil)
+	fast, _ := b.Subscribe(0, nil)
+
+	// Niemand liest von slow: Publish darf trotzdem nicht blockieren
+	for n := 1; n <= 2; n++ {
+		b.Publish(n)
+		<-fast.C
+	}
+	b.Publish(3)
+
+	if !slow.Dropped() {
+		t.Fatal("Expected slow subscriber to be dropped")
+	}
+	if fast.Dropped() || b.Subscribers() != 1 {
+		t.Fatalf("Expected fast subscriber to stay connected, got %d subscribers", b.Subscribers())
+	}
+
+	// Gepufferte Events bleiben lesbar, danach ist der Channel zu
+	count := 0
+	for range slow.C {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 buffered events before close, got %d", count)
+	}
+}
+
+func TestBroadcasterClose(t *testing.T) {
+	b := NewBroadcaster[int](10, 10)
+
+	sub, _ := b.Subscribe(0, nil)
+	b.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected channel to be closed")
+	}
+
+	sub.Close() // darf nach Close nicht paniken
+
+	late, _ := b.Subscribe(0, nil)
+	if _, ok := <-late.C; ok {
+		t.Error("Expected subscription after Close to be closed")
+	}
+}
@@ -0,0 +1,235 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter schreibt fertige Spans weg, z.B. in eine Datei oder an einen Collector
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Recorder sammelt beendete Spans und exportiert sie gebündelt im Hintergrund.
+// Requests warten nie auf den Export: ist der Puffer voll, wird verworfen.
+type Recorder struct {
+	exporter  Exporter
+	spans     chan SpanData
+	interval  time.Duration
+	batchSize int
+	dropped   atomic.Int64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRecorder startet den Export. Mit exporter == nil werden zwar IDs erzeugt
+// und weitergegeben, aber keine Spans aufgezeichnet.
+func NewRecorder(exporter Exporter, bufferSize int, flushInterval time.Duration) *Recorder {
+	r := &Recorder{
+		exporter:  exporter,
+		spans:     make(chan SpanData, bufferSize),
+		interval:  flushInterval,
+		batchSize: 100,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	if exporter == nil {
+		close(r.done)
+		return r
+	}
+
+	go r.loop()
+	return r
+}
+
+// Enabled ist true, wenn Spans exportiert werden
+func (r *Recorder) Enabled() bool {
+	return r != nil && r.exporter != nil
+}
+
+// Dropped zählt Spans, die wegen eines vollen Puffers verloren gingen
+func (r *Recorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// StartRequest beginnt den Wurzel-Span eines eingehenden Requests. Ist remote
+// gültig (traceparent vom Client), wird dessen Trace fortgesetzt.
+func (r *Recorder) StartRequest(ctx context.Context, name string, remote SpanContext, requestID string) (context.Context, *Span) {
+	span := &Span{
+		recorder: r,
+		sc:       SpanContext{TraceID: NewTraceID(), SpanID: NewSpanID(), Sampled: r.Enabled()},
+		name:     name,
+		reqID:    requestID,
+		start:    time.Now(),
+	}
+
+	if remote.IsValid() {
+		span.sc.TraceID = remote.TraceID
+		span.sc.Sampled = remote.Sampled && r.Enabled()
+		span.parentID = remote.SpanID
+	}
+
+	ctx = WithRequestID(ctx, requestID)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (r *Recorder) record(data SpanData) {
+	if !r.Enabled() {
+		return
+	}
+
+	select {
+	case r.spans <- data:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+func (r *Recorder) loop() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, r.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := r.exporter.Export(ctx, batch); err != nil {
+			log.Printf("trace: export %d spans: %s", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-r.spans:
+			batch = append(batch, span)
+			if len(batch) >= r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-r.stop:
+			// Was schon im Puffer liegt, wird noch exportiert
+			for {
+				select {
+				case span := <-r.spans:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close exportiert die restlichen Spans und schließt den Exporter
+func (r *Recorder) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if closer, ok := r.exporter.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// FileExporter hängt Spans als NDJSON (ein Span pro Zeile) an eine Datei an
+type FileExporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileExporter(path string) (*FileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileExporter{file: file}, nil
+}
+
+func (e *FileExporter) Export(ctx context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, span := range spans {
+		if err := enc.Encode(span); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err := e.file.Write(buf.Bytes())
+	return err
+}
+
+func (e *FileExporter) Close() error {
+	return e.file.Close()
+}
+
+// HTTPExporter schickt Spans als {"spans": [...]} per POST, z.B. an einen
+// lokalen Collector-Ersatz
+type HTTPExporter struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPExporter(url string, timeout time.Duration) *HTTPExporter {
+	return &HTTPExporter{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (e *HTTPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(map[string][]SpanData{"spans": spans})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %s", res.Status)
+	}
+	return nil
+}
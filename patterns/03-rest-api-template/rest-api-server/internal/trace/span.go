@@ -0,0 +1,145 @@
+package trace
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SpanData ist ein abgeschlossener Span, so wie ihn die Exporter schreiben
+type SpanData struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_span_id,omitempty"`
+	Name       string         `json:"name"`
+	RequestID  string         `json:"request_id,omitempty"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	DurationMS float64        `json:"duration_ms"`
+	Error      string         `json:"error,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// Span ist eine laufende Operation. Alle Methoden sind auf nil sicher, damit
+// Code ohne Tracing (z.B. Tests oder Jobs) einfach weiterläuft.
+type Span struct {
+	recorder *Recorder
+	sc       SpanContext
+	parentID [8]byte
+	name     string
+	reqID    string
+	start    time.Time
+
+	mu    sync.Mutex
+	attrs map[string]any
+	err   string
+	ended bool
+}
+
+type spanKey struct{}
+
+// SpanContextFrom liefert den SpanContext des aktuellen Spans in ctx
+func SpanContextFrom(ctx context.Context) SpanContext {
+	if span, ok := ctx.Value(spanKey{}).(*Span); ok {
+		return span.sc
+	}
+	return SpanContext{}
+}
+
+// Start beginnt einen Kind-Span des Spans in ctx. Ohne Span in ctx gibt es
+// nur einen nil-Span, es wird also nichts aufgezeichnet.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent, ok := ctx.Value(spanKey{}).(*Span)
+	if !ok {
+		return ctx, nil
+	}
+
+	span := &Span{
+		recorder: parent.recorder,
+		sc:       SpanContext{TraceID: parent.sc.TraceID, SpanID: NewSpanID(), Sampled: parent.sc.Sampled},
+		parentID: parent.sc.SpanID,
+		name:     name,
+		reqID:    parent.reqID,
+		start:    time.Now(),
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Context liefert den SpanContext, z.B. für den traceparent Response-Header
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttr hängt ein Attribut an, z.B. http.status_code oder db.statement
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+}
+
+// RecordError markiert den Span als fehlgeschlagen. sql.ErrNoRows ist kein
+// Fehler, sondern ein normales "nicht gefunden".
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err.Error()
+}
+
+// End schließt den Span ab und übergibt ihn dem Recorder (nur einmal)
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := s.data(time.Now())
+	s.mu.Unlock()
+
+	if s.sc.Sampled {
+		s.recorder.record(data)
+	}
+}
+
+func (s *Span) data(end time.Time) SpanData {
+	data := SpanData{
+		TraceID:    hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:     hex.EncodeToString(s.sc.SpanID[:]),
+		Name:       s.name,
+		RequestID:  s.reqID,
+		Start:      s.start,
+		End:        end,
+		DurationMS: float64(end.Sub(s.start).Microseconds()) / 1000,
+		Error:      s.err,
+		Attributes: s.attrs,
+	}
+	if s.parentID != [8]byte{} {
+		data.ParentID = hex.EncodeToString(s.parentID[:])
+	}
+
+	return data
+}
@@ -0,0 +1,156 @@
+// Package trace setzt W3C Trace Context (traceparent) und Request-IDs um und
+// zeichnet Spans auf, die ein Recorder als JSON exportiert. Bewusst klein
+// gehalten statt OpenTelemetry: IDs und Format sind aber kompatibel.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SpanContext ist der Teil eines Spans, der über Prozessgrenzen weitergegeben wird
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid ist false für den Null-Kontext (Trace- und Span-ID nur aus Nullen)
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formatiert sc als Header, z.B. 00-4bf9...-00f0...-01
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent liest einen traceparent Header. Ungültige Header werden laut
+// Spezifikation ignoriert (false), dann beginnt ein neuer Trace.
+func ParseTraceparent(header string) (SpanContext, bool) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	// Version 00 hat genau vier Felder, spätere Versionen dürfen mehr haben
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version) || len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 ||
+		!isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return sc, false
+	}
+
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags))
+	sc.Sampled = f[0]&1 == 1
+
+	return sc, sc.IsValid()
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewTraceID und NewSpanID erzeugen zufällige IDs
+func NewTraceID() (id [16]byte) {
+	rand.Read(id[:])
+	return id
+}
+
+func NewSpanID() (id [8]byte) {
+	rand.Read(id[:])
+	return id
+}
+
+// NewRequestID erzeugt eine Request-ID, wenn der Client keine mitschickt
+func NewRequestID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID begrenzt Request-IDs von Clients auf harmlose Zeichen. Sie
+// landen in Logs und SQL-Kommentaren und dürfen dort nichts ausbrechen lassen.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+type contextKey string
+
+const requestIDKey contextKey = "requestID"
+
+// WithRequestID legt die Request-ID in den Context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID liefert die Request-ID aus ctx oder ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Logf schreibt eine Logzeile mit Request- und Trace-ID aus ctx als Präfix
+func Logf(ctx context.Context, format string, args ...any) {
+	log.Print(logPrefix(ctx) + fmt.Sprintf(format, args...))
+}
+
+func logPrefix(ctx context.Context) string {
+	var fields []string
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, "request_id="+id)
+	}
+	if sc := SpanContextFrom(ctx); sc.IsValid() {
+		fields = append(fields, "trace_id="+hex.EncodeToString(sc.TraceID[:]))
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(fields, " ") + "] "
+}
+
+// SQLComment liefert einen Kommentar im sqlcommenter-Format, den der Store an
+// Queries hängt, damit sie in pg_stat_activity und Slow-Query-Logs dem Request
+// zuzuordnen sind. Leer, wenn ctx weder Request- noch Trace-ID hat.
+func SQLComment(ctx context.Context) string {
+	var fields []string
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, "request_id='"+id+"'")
+	}
+	if sc := SpanContextFrom(ctx); sc.IsValid() {
+		fields = append(fields, "traceparent='"+sc.Traceparent()+"'")
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return "/*" + strings.Join(fields, ",") + "*/"
+}
@@ -0,0 +1,127 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz", true, true},
+		{"extra field in version 00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz", false, false},
+		{"uppercase hex", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"short trace id", "00-4bf92f35-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tt.header)
+			if ok != tt.valid {
+				t.Fatalf("Expected valid=%v, got %v", tt.valid, ok)
+			}
+			if ok && sc.Sampled != tt.sampled {
+				t.Errorf("Expected sampled=%v, got %v", tt.sampled, sc.Sampled)
+			}
+			if ok && tt.header[:2] == "00" && sc.Traceparent() != tt.header {
+				t.Errorf("Expected round trip to %q, got %q", tt.header, sc.Traceparent())
+			}
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"abc-123_DEF.4:5": true,
+		"":                false,
+		"a b":             false,
+		"x*/ DROP":        false,
+		"ümlaut":          false,
+	} {
+		if got := ValidRequestID(id); got != want {
+			t.Errorf("Expected ValidRequestID(%q) = %v, got %v", id, want, got)
+		}
+	}
+}
+
+func TestRecorderExportsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.ndjson")
+	exporter, err := NewFileExporter(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	r := NewRecorder(exporter, 10, time.Hour)
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := r.StartRequest(context.Background(), "GET /v1/posts", remote, "req-1")
+	_, child := Start(ctx, "db.query")
+	child.SetAttr("db.statement", "SELECT 1")
+	child.End()
+	child.End() // doppelt beenden exportiert nicht doppelt
+	root.End()
+
+	if got := SQLComment(ctx); got != "/*request_id='req-1',traceparent='"+root.Context().Traceparent()+"'*/" {
+		t.Errorf("Unexpected SQL comment %q", got)
+	}
+
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error closing, got %v", err)
+	}
+
+	file, _ := os.Open(path)
+	defer file.Close()
+
+	var spans []SpanData
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span SpanData
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatalf("Expected one JSON span per line, got %q", scanner.Text())
+		}
+		spans = append(spans, span)
+	}
+
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	db, req := spans[0], spans[1]
+	if req.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || req.ParentID != "00f067aa0ba902b7" || req.RequestID != "req-1" {
+		t.Errorf("Expected the request span to continue the remote trace, got %+v", req)
+	}
+	if db.TraceID != req.TraceID || db.ParentID != req.SpanID || db.Attributes["db.statement"] != "SELECT 1" {
+		t.Errorf("Expected the db span as child of the request span, got %+v", db)
+	}
+}
+
+func TestUnsampledAndDisabled(t *testing.T) {
+	ctx, span := NewRecorder(nil, 1, time.Second).StartRequest(context.Background(), "GET /", SpanContext{}, "req-2")
+	if span.Context().Sampled || !span.Context().IsValid() {
+		t.Errorf("Expected valid but unsampled IDs without exporter, got %+v", span.Context())
+	}
+	if RequestID(ctx) != "req-2" {
+		t.Errorf("Expected request ID in context, got %q", RequestID(ctx))
+	}
+
+	// Ohne Span im Context ist Start ein No-op
+	_, none := Start(context.Background(), "db.query")
+	none.SetAttr("k", "v")
+	none.End()
+	if none != nil {
+		t.Error("Expected a nil span without parent")
+	}
+}
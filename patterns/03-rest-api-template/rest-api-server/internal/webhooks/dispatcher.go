@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/timour/go-api/internal/store"
+)
+
+// deliveryRecorder ist der Teil des Webhooks Stores, den der Dispatcher braucht
+type deliveryRecorder interface {
+	RecordDelivery(ctx context.Context, delivery *store.WebhookDelivery, maxFailures int) (bool, error)
+}
+
+// Dispatcher stellt Events per HTTP POST zu und protokolliert jeden Versuch
+type Dispatcher struct {
+	client      *http.Client
+	store       deliveryRecorder
+	maxFailures int // Fehlversuche in Folge, nach denen ein Webhook deaktiviert wird
+}
+
+func NewDispatcher(store deliveryRecorder, timeout time.Duration, maxFailures int) *Dispatcher {
+	return &Dispatcher{
+		client:      &http.Client{Timeout: timeout},
+		store:       store,
+		maxFailures: maxFailures,
+	}
+}
+
+// Deliver schickt body an hook und speichert das Ergebnis als WebhookDelivery.
+// Jede Antwort außer 2xx ist ein Fehler, damit die Job Queue es erneut versucht.
+func (d *Dispatcher) Deliver(ctx context.Context, hook *store.Webhook, eventID, eventType string, body []byte, attempt int) error {
+	delivery := &store.WebhookDelivery{
+		WebhookID: hook.ID,
+		EventID:   eventID,
+		Event:     eventType,
+		Payload:   body,
+		Attempt:   attempt,
+	}
+
+	start := time.Now()
+	deliverErr := d.send(ctx, hook, eventID, eventType, body, delivery)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	delivery.Success = deliverErr == nil
+	if deliverErr != nil {
+		delivery.Error = deliverErr.Error()
+	}
+
+	disabled, err := d.store.RecordDelivery(ctx, delivery, d.maxFailures)
+	if err != nil {
+		return err
+	}
+
+	if disabled {
+		return fmt.Errorf("webhook %d disabled after %d consecutive failures: %w", hook.ID, d.maxFailures, deliverErr)
+	}
+
+	return deliverErr
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook *store.Webhook, eventID, eventType string, body []byte, delivery *store.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-api-webhooks/1.0")
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(EventIDHeader, eventID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, time.Now(), body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// Body lesen, damit die Connection wiederverwendet werden kann
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	delivery.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	return nil
+}